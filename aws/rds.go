@@ -8,13 +8,12 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
 	ie2utilities "github.com/insightengine2/ie2-utilities/utils"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 const ENV_RDS_HOST = "IE2_RDS_HOST"
@@ -23,6 +22,10 @@ const ENV_RDS_PORT = "IE2_RDS_PORT"
 const ENV_REGION = "AWS_REGION"
 const ENV_USERNAME = "IE2_RDS_UNAME"
 const ENV_SECRETKEY = "IE2_RDS_PWD_KEY"
+const ENV_AUTHMODE = "IE2_RDS_AUTH_MODE"
+
+const RDS_AUTH_MODE_SECRET = "secret"
+const RDS_AUTH_MODE_IAM = "iam"
 
 type RDSLogin struct {
 	UserName string `json:"username"`
@@ -82,7 +85,7 @@ func getRDSParams() (*ie2datatypes.RDSParams, error) {
 	return &res, nil
 }
 
-func getRDSLogin() (*RDSLogin, error) {
+func getRDSLogin(ctx context.Context) (*RDSLogin, error) {
 
 	log.Print("Retrieving RDS password")
 	secretKey := os.Getenv(ENV_SECRETKEY)
@@ -93,38 +96,16 @@ func getRDSLogin() (*RDSLogin, error) {
 		return nil, errors.New(msg)
 	}
 
-	log.Print("Loading the default config")
-	config, err := config.LoadDefaultConfig(context.TODO())
-
-	if err != nil {
-		log.Print(err.Error())
-		return nil, err
-	}
-
-	log.Print("Creating a new secrets manager client")
-	sm := secretsmanager.NewFromConfig(config)
-
-	if sm == nil {
-		msg := "failed to create secretsmanager client"
-		log.Print(msg)
-		return nil, errors.New(msg)
-	}
-
-	log.Print("Retrieving secret value")
-	val, err := sm.GetSecretValue(context.TODO(), &secretsmanager.GetSecretValueInput{
-		SecretId:     aws.String(secretKey),
-		VersionStage: aws.String("AWSCURRENT"),
-	})
+	val, err := IE2GetSecretString(ctx, secretKey, SECRET_VERSION_CURRENT)
 
 	if err != nil {
-		log.Print(err)
 		return nil, err
 	}
 
 	// the value is returned as a JSON formatted string...sure
 	// so we need to convert it to a JSON object and access the retrieved value
 	var login RDSLogin
-	err = json.Unmarshal([]byte(*val.SecretString), &login)
+	err = json.Unmarshal([]byte(val), &login)
 
 	if err != nil {
 		log.Print(err)
@@ -134,39 +115,149 @@ func getRDSLogin() (*RDSLogin, error) {
 	return &login, nil
 }
 
+// invalidateRDSLogin drops the cached RDS login so the next lookup
+// picks up a rotated password.
+func invalidateRDSLogin() {
+
+	secretKey := os.Getenv(ENV_SECRETKEY)
+
+	if len(secretKey) > 0 {
+		log.Print("Invalidating cached RDS password")
+		IE2InvalidateSecret(secretKey, SECRET_VERSION_CURRENT)
+	}
+}
+
+// isRDSAuthError reports whether err is postgres rejecting our credentials,
+// which is what we see when the secret was rotated after we cached it.
+func isRDSAuthError(err error) bool {
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "28P01" || pgErr.Code == "28000"
+	}
+
+	return false
+}
+
+// getRDSAuthMode resolves the auth mode to use, preferring mode
+// over the IE2_RDS_AUTH_MODE environment variable.
+// Static Secrets Manager passwords are the default.
+func getRDSAuthMode(mode string) (string, error) {
+
+	if len(mode) <= 0 {
+		mode = os.Getenv(ENV_AUTHMODE)
+	}
+
+	mode = strings.ToLower(strings.TrimSpace(mode))
+
+	switch mode {
+	case "", RDS_AUTH_MODE_SECRET:
+		return RDS_AUTH_MODE_SECRET, nil
+	case RDS_AUTH_MODE_IAM:
+		return RDS_AUTH_MODE_IAM, nil
+	}
+
+	return "", fmt.Errorf("unsupported rds auth mode: %s", mode)
+}
+
+func getRDSConnString(ctx context.Context, mode string) (string, *ie2datatypes.RDSParams, error) {
+
+	rdsParams, err := getRDSParams()
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	password := ""
+
+	if mode == RDS_AUTH_MODE_IAM {
+
+		log.Print("Using RDS IAM authentication")
+		token, err := getRDSIAMToken(ctx, rdsParams)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		password = token
+
+	} else {
+
+		login, err := getRDSLogin(ctx)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		if login == nil {
+			return "", nil, errors.New("database password is empty or nil")
+		}
+
+		// use secrets username if it exists
+		if len(login.UserName) > 0 {
+			log.Print("Using username returned by secrets manager")
+			rdsParams.DBUserName = login.UserName
+		}
+
+		password = login.Password
+	}
+
+	log.Print("URL escape connection string")
+	escapedPWD := url.QueryEscape(password)
+
+	// connection string - assemble!
+	connString := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", url.QueryEscape(rdsParams.DBUserName), escapedPWD, rdsParams.DBHost, rdsParams.DBPort, rdsParams.DBName)
+
+	// rds refuses iam authentication over unencrypted connections
+	if mode == RDS_AUTH_MODE_IAM {
+		connString += "?sslmode=require"
+	}
+
+	return connString, rdsParams, nil
+}
+
+// IE2RDSPostgresConnection opens a connection to the database described by the
+// IE2_RDS_* environment variables.
+//
+// Deprecated: use IE2RDSPostgresConnectionContext, which honours cancellation.
 func IE2RDSPostgresConnection() (*pgx.Conn, error) {
+	return IE2RDSPostgresConnectionContext(context.Background())
+}
+
+// IE2RDSPostgresConnectionContext opens a connection to the database described by the
+// IE2_RDS_* environment variables. ctx bounds the credential lookups and the dial.
+func IE2RDSPostgresConnectionContext(ctx context.Context) (*pgx.Conn, error) {
 
 	log.Print("Creating a Postgres Connection")
-	rdsParams, err := getRDSParams()
+	mode, err := getRDSAuthMode("")
 
 	if err != nil {
 		return nil, err
 	}
 
-	login, err := getRDSLogin()
+	connString, _, err := getRDSConnString(ctx, mode)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if login == nil {
-		return nil, errors.New("database password is empty or nil")
-	}
+	log.Print("Attempting to create DB Connection")
+	db, err := pgx.Connect(ctx, connString)
 
-	log.Print("URL escape connection string")
-	escapedPWD := url.QueryEscape(login.Password)
+	if err != nil && mode == RDS_AUTH_MODE_SECRET && isRDSAuthError(err) {
 
-	// use secrets username if it exists
-	if len(login.UserName) >= 0 {
-		log.Print("Using username returned by secrets manager")
-		rdsParams.DBUserName = login.UserName
-	}
+		log.Print("Authentication failed, the RDS password may have been rotated. Retrying with a fresh secret.")
+		invalidateRDSLogin()
 
-	// connection string - assemble!
-	connString := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", rdsParams.DBUserName, escapedPWD, rdsParams.DBHost, rdsParams.DBPort, rdsParams.DBName)
+		connString, _, err = getRDSConnString(ctx, mode)
 
-	log.Print("Attempting to create DB Connection")
-	db, err := pgx.Connect(context.Background(), connString)
+		if err != nil {
+			return nil, err
+		}
+
+		db, err = pgx.Connect(ctx, connString)
+	}
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,69 @@
+package ie2aws
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/rds/auth"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+// RDS IAM auth tokens are valid for 15 minutes, we refresh them
+// once they are within RDS_IAM_TOKEN_REFRESH of expiring
+const RDS_IAM_TOKEN_TTL = 15 * time.Minute
+const RDS_IAM_TOKEN_REFRESH = 5 * time.Minute
+
+type rdsIAMToken struct {
+	mu      sync.Mutex
+	key     string
+	token   string
+	expires time.Time
+}
+
+var rdsToken rdsIAMToken
+
+// getRDSIAMToken returns a SigV4 signed auth token for the given params,
+// reusing the previous token while it is still comfortably valid.
+func getRDSIAMToken(ctx context.Context, params *ie2datatypes.RDSParams) (string, error) {
+
+	if params == nil {
+		return "", errors.New("rds params can not be empty")
+	}
+
+	endpoint := net.JoinHostPort(params.DBHost, params.DBPort)
+	key := params.DBRegion + "|" + endpoint + "|" + params.DBUserName
+
+	rdsToken.mu.Lock()
+	defer rdsToken.mu.Unlock()
+
+	if rdsToken.key == key && len(rdsToken.token) > 0 && time.Until(rdsToken.expires) > RDS_IAM_TOKEN_REFRESH {
+		return rdsToken.token, nil
+	}
+
+	log.Printf("Generating RDS IAM auth token for %s on %s", params.DBUserName, endpoint)
+	conf, err := config.LoadDefaultConfig(ctx, config.WithRegion(params.DBRegion))
+
+	if err != nil {
+		log.Print(err)
+		return "", err
+	}
+
+	issued := time.Now()
+	token, err := auth.BuildAuthToken(ctx, endpoint, params.DBRegion, params.DBUserName, conf.Credentials)
+
+	if err != nil {
+		log.Print(err)
+		return "", err
+	}
+
+	rdsToken.key = key
+	rdsToken.token = token
+	rdsToken.expires = issued.Add(RDS_IAM_TOKEN_TTL)
+
+	return token, nil
+}
@@ -0,0 +1,183 @@
+package ie2aws
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// the pool lives at package level so warm lambda invocations
+// reuse the connections opened by the first invocation
+var rdsPool *pgxpool.Pool
+var rdsPoolMu sync.Mutex
+
+// IE2RDSPostgresPool returns a pgxpool.Pool built from the same environment
+// and Secrets Manager inputs as IE2RDSPostgresConnection.
+// The auth mode comes from params.AuthMode or the IE2_RDS_AUTH_MODE environment variable.
+// The first successful call creates the pool, later calls return the cached pool
+// and ignore params. Use IE2RDSPostgresPoolClose to discard it.
+//
+// Deprecated: use IE2RDSPostgresPoolContext, which honours cancellation.
+func IE2RDSPostgresPool(params *ie2datatypes.RDSPoolParams) (*pgxpool.Pool, error) {
+	return IE2RDSPostgresPoolContext(context.Background(), params)
+}
+
+// IE2RDSPostgresPoolContext is IE2RDSPostgresPool with ctx bounding the credential
+// lookups and the initial connectivity check. Connections opened later by the pool
+// use the context of the query that needed them.
+func IE2RDSPostgresPoolContext(ctx context.Context, params *ie2datatypes.RDSPoolParams) (*pgxpool.Pool, error) {
+
+	rdsPoolMu.Lock()
+	defer rdsPoolMu.Unlock()
+
+	if rdsPool != nil {
+		log.Print("Reusing cached Postgres connection pool")
+		return rdsPool, nil
+	}
+
+	log.Print("Creating a Postgres connection pool")
+	mode := ""
+
+	if params != nil {
+		mode = params.AuthMode
+	}
+
+	mode, err := getRDSAuthMode(mode)
+
+	if err != nil {
+		return nil, err
+	}
+
+	connString, rdsParams, err := getRDSConnString(ctx, mode)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := pgxpool.ParseConfig(connString)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+
+		if params.MaxConns > 0 {
+			config.MaxConns = params.MaxConns
+		}
+
+		if params.MinConns > 0 {
+			config.MinConns = params.MinConns
+		}
+
+		if params.HealthCheckPeriod > 0 {
+			config.HealthCheckPeriod = params.HealthCheckPeriod
+		}
+
+		if params.MaxConnIdleTime > 0 {
+			config.MaxConnIdleTime = params.MaxConnIdleTime
+		}
+	}
+
+	// every new pool connection gets a token that is valid for a while yet,
+	// so connections opened long after the pool was created still authenticate
+	if mode == RDS_AUTH_MODE_IAM {
+		config.BeforeConnect = func(ctx context.Context, cc *pgx.ConnConfig) error {
+
+			token, err := getRDSIAMToken(ctx, rdsParams)
+
+			if err != nil {
+				return err
+			}
+
+			cc.Password = token
+
+			return nil
+		}
+	} else {
+
+		// new pool connections read the password through the secret cache,
+		// so a rotated password is picked up once the cached value expires
+		// or is invalidated after an authentication failure, see OnPgError below
+		config.BeforeConnect = func(ctx context.Context, cc *pgx.ConnConfig) error {
+
+			login, err := getRDSLogin(ctx)
+
+			if err != nil {
+				return err
+			}
+
+			if len(login.UserName) > 0 {
+				cc.User = login.UserName
+			}
+
+			cc.Password = login.Password
+
+			return nil
+		}
+
+		// pgx can't retry a connection inside the pool, but the error is seen while the
+		// connection is being authenticated, so drop the stale password right there and
+		// the next connection the pool opens reads the rotated one
+		onPgError := config.ConnConfig.OnPgError
+		config.ConnConfig.OnPgError = func(conn *pgconn.PgConn, pgErr *pgconn.PgError) bool {
+
+			if isRDSAuthError(pgErr) {
+				log.Print("Authentication failed, the RDS password may have been rotated.")
+				invalidateRDSLogin()
+			}
+
+			if onPgError != nil {
+				return onPgError(conn, pgErr)
+			}
+
+			return true
+		}
+	}
+
+	log.Printf("Attempting to create DB pool with %d max and %d min connections", config.MaxConns, config.MinConns)
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// make sure the credentials work before handing the pool out
+	log.Print("Checking DB pool connectivity")
+	err = pool.Ping(ctx)
+
+	// the password was dropped by OnPgError, one more try reads the rotated one
+	if err != nil && mode == RDS_AUTH_MODE_SECRET && isRDSAuthError(err) {
+
+		log.Print("Retrying with a fresh secret.")
+		err = pool.Ping(ctx)
+	}
+
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	rdsPool = pool
+
+	return rdsPool, nil
+}
+
+// IE2RDSPostgresPoolClose closes the cached pool, if any.
+// The next call to IE2RDSPostgresPool creates a new one.
+func IE2RDSPostgresPoolClose() {
+
+	rdsPoolMu.Lock()
+	defer rdsPoolMu.Unlock()
+
+	if rdsPool != nil {
+		log.Print("Closing cached Postgres connection pool")
+		rdsPool.Close()
+		rdsPool = nil
+	}
+}
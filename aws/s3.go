@@ -41,14 +41,28 @@ func S3ObjectToBuff(obj *s3.GetObjectOutput) (*bytes.Buffer, error) {
 	return buffer, nil
 }
 
+// Deprecated: use S3GetObjectContext, which takes a context.Context.
 func S3GetObject(conf *aws.Config, ctx *context.Context, bucket string, key string) (*s3.GetObjectOutput, error) {
 
+	if conf == nil {
+		return nil, errors.New("aws.config can not be empty")
+	}
+
+	if ctx == nil {
+		return nil, errors.New("context can not be empty")
+	}
+
+	return S3GetObjectContext(*ctx, *conf, bucket, key)
+}
+
+func S3GetObjectContext(ctx context.Context, conf aws.Config, bucket string, key string) (*s3.GetObjectOutput, error) {
+
 	log.Printf("Create S3 client from config.")
-	client := s3.NewFromConfig(*conf)
+	client := s3.NewFromConfig(conf)
 
 	log.Printf("Attempting to retrieve s3 object: %s", bucket+"/"+key)
 
-	res, err := client.GetObject(*ctx, &s3.GetObjectInput{
+	res, err := client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	})
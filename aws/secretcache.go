@@ -0,0 +1,180 @@
+package ie2aws
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	ie2utilities "github.com/insightengine2/ie2-utilities/utils"
+	"golang.org/x/sync/singleflight"
+)
+
+const SECRET_CACHE_TTL = 5 * time.Minute
+const SECRET_VERSION_CURRENT = "AWSCURRENT"
+
+type secretCacheEntry struct {
+	value   string
+	expires time.Time
+}
+
+// SecretCache keeps Secrets Manager values in memory for TTL so warm invocations
+// don't pay for a GetSecretValue call every time. Concurrent lookups of the same
+// secret share a single request. The zero value is ready to use but caches nothing
+// until TTL is set, NewSecretCache sets it.
+type SecretCache struct {
+	TTL    time.Duration
+	Client ie2utilities.SecretsManagerAPI
+
+	mu      sync.Mutex
+	entries map[string]secretCacheEntry
+	group   singleflight.Group
+}
+
+var defaultSecretCache = NewSecretCache(SECRET_CACHE_TTL)
+
+// NewSecretCache returns an empty cache. The Secrets Manager client is created
+// from the default config on first use unless Client is set (e.g. to a fake in tests).
+func NewSecretCache(ttl time.Duration) *SecretCache {
+
+	return &SecretCache{
+		TTL:     ttl,
+		entries: map[string]secretCacheEntry{},
+	}
+}
+
+// NewServiceSecretCache returns an empty cache that reads secrets with the Secrets Manager
+// client of s, so the cache shares the config of the other AWSService clients, or the fakes.
+func NewServiceSecretCache(s *ie2utilities.AWSService, ttl time.Duration) *SecretCache {
+
+	c := NewSecretCache(ttl)
+
+	if s != nil {
+		c.Client = s.SecretsManager
+	}
+
+	return c
+}
+
+func secretCacheKey(secretId string, versionStage string) string {
+
+	if len(versionStage) <= 0 {
+		versionStage = SECRET_VERSION_CURRENT
+	}
+
+	return secretId + "|" + versionStage
+}
+
+// GetSecretString returns the SecretString for secretId at versionStage,
+// defaulting to AWSCURRENT when versionStage is empty.
+func (c *SecretCache) GetSecretString(ctx context.Context, secretId string, versionStage string) (string, error) {
+
+	if len(secretId) <= 0 {
+		return "", errors.New("secret id can not be empty")
+	}
+
+	if len(versionStage) <= 0 {
+		versionStage = SECRET_VERSION_CURRENT
+	}
+
+	key := secretCacheKey(secretId, versionStage)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value, nil
+	}
+
+	val, err, _ := c.group.Do(key, func() (interface{}, error) {
+
+		log.Printf("Retrieving secret value for %s (%s)", secretId, versionStage)
+		client, err := c.client(ctx)
+
+		if err != nil {
+			return "", err
+		}
+
+		out, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+			SecretId:     aws.String(secretId),
+			VersionStage: aws.String(versionStage),
+		})
+
+		if err != nil {
+			log.Print(err)
+			return "", err
+		}
+
+		if out.SecretString == nil {
+			return "", errors.New("secret does not contain a string value")
+		}
+
+		c.mu.Lock()
+
+		if c.entries == nil {
+			c.entries = map[string]secretCacheEntry{}
+		}
+
+		c.entries[key] = secretCacheEntry{
+			value:   *out.SecretString,
+			expires: time.Now().Add(c.TTL),
+		}
+		c.mu.Unlock()
+
+		return *out.SecretString, nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return val.(string), nil
+}
+
+// Invalidate drops the cached value so the next lookup goes back to Secrets Manager.
+// Use it when a secret is known to have been rotated.
+func (c *SecretCache) Invalidate(secretId string, versionStage string) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, secretCacheKey(secretId, versionStage))
+}
+
+func (c *SecretCache) client(ctx context.Context) (ie2utilities.SecretsManagerAPI, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Client != nil {
+		return c.Client, nil
+	}
+
+	log.Print("Loading the default config")
+	conf, err := config.LoadDefaultConfig(ctx)
+
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	log.Print("Creating a new secrets manager client")
+	c.Client = secretsmanager.NewFromConfig(conf)
+
+	return c.Client, nil
+}
+
+// IE2GetSecretString looks secretId up through the package level cache.
+func IE2GetSecretString(ctx context.Context, secretId string, versionStage string) (string, error) {
+	return defaultSecretCache.GetSecretString(ctx, secretId, versionStage)
+}
+
+// IE2InvalidateSecret drops secretId from the package level cache.
+func IE2InvalidateSecret(secretId string, versionStage string) {
+	defaultSecretCache.Invalidate(secretId, versionStage)
+}
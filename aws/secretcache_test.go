@@ -0,0 +1,115 @@
+package ie2aws
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// countingSecrets answers every GetSecretValue with the secret id and counts the calls.
+// When release is set each call waits for it to be closed first.
+type countingSecrets struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (c *countingSecrets) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+
+	c.calls.Add(1)
+
+	if c.release != nil {
+		<-c.release
+	}
+
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String("value of " + aws.ToString(params.SecretId))}, nil
+}
+
+func TestSecretCacheTTL(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		zero       bool
+		invalidate bool
+		wantCalls  int32
+	}{
+		{name: "cached", ttl: time.Minute, wantCalls: 1},
+		{name: "expired", ttl: 0, wantCalls: 3},
+		{name: "invalidated", ttl: time.Minute, invalidate: true, wantCalls: 3},
+		{name: "zero value", zero: true, wantCalls: 3},
+		{name: "zero value with a ttl", zero: true, ttl: time.Minute, wantCalls: 1},
+		{name: "zero value invalidated", zero: true, ttl: time.Minute, invalidate: true, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fake := &countingSecrets{}
+			c := NewSecretCache(tt.ttl)
+
+			if tt.zero {
+				c = &SecretCache{TTL: tt.ttl}
+			}
+
+			c.Client = fake
+
+			for i := 0; i < 3; i++ {
+
+				val, err := c.GetSecretString(context.Background(), "db", "")
+
+				if err != nil {
+					t.Fatalf("GetSecretString() error = %v", err)
+				}
+
+				if val != "value of db" {
+					t.Errorf("GetSecretString() = %q", val)
+				}
+
+				if tt.invalidate {
+					c.Invalidate("db", SECRET_VERSION_CURRENT)
+				}
+			}
+
+			if got := fake.calls.Load(); got != tt.wantCalls {
+				t.Errorf("GetSecretValue called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSecretCacheSingleflight(t *testing.T) {
+
+	fake := &countingSecrets{release: make(chan struct{})}
+	c := NewSecretCache(time.Minute)
+	c.Client = fake
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+
+		wg.Add(1)
+
+		go func() {
+
+			defer wg.Done()
+
+			if _, err := c.GetSecretString(context.Background(), "db", ""); err != nil {
+				t.Errorf("GetSecretString() error = %v", err)
+			}
+		}()
+	}
+
+	// give every lookup time to join the one in flight
+	time.Sleep(50 * time.Millisecond)
+	close(fake.release)
+	wg.Wait()
+
+	if got := fake.calls.Load(); got != 1 {
+		t.Errorf("GetSecretValue called %d times for concurrent lookups, want 1", got)
+	}
+}
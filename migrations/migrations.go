@@ -0,0 +1,321 @@
+package ie2migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	ie2aws "github.com/insightengine2/ie2-utilities/aws"
+	"github.com/jackc/pgx/v5"
+)
+
+// migration files are named <version>_<name>.up.sql and <version>_<name>.down.sql
+//
+//go:embed sql/*.sql
+var migrationFiles embed.FS
+
+// arbitrary but fixed key so every runner contends for the same advisory lock
+const MIGRATION_LOCK_KEY int64 = 0x1e2d0b5c
+
+const migrationTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		appliedon TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrations returns the embedded migrations ordered by version.
+func Migrations() ([]Migration, error) {
+
+	entries, err := fs.ReadDir(migrationFiles, "sql")
+
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, entry := range entries {
+
+		filename := entry.Name()
+		base := strings.TrimSuffix(filename, ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+
+		parts := strings.SplitN(base, "_", 2)
+
+		if len(parts) != 2 || (direction != ".up" && direction != ".down") {
+			return nil, fmt.Errorf("invalid migration file name: %s", filename)
+		}
+
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", filename, err)
+		}
+
+		contents, err := migrationFiles.ReadFile("sql/" + filename)
+
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		}
+
+		if m.Name != parts[1] {
+			return nil, fmt.Errorf("migration %d has mismatched names %s and %s", version, m.Name, parts[1])
+		}
+
+		if direction == ".up" {
+			m.Up = string(contents)
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	ret := make([]Migration, 0, len(byVersion))
+
+	for _, m := range byVersion {
+
+		if len(m.Up) <= 0 {
+			return nil, fmt.Errorf("migration %d is missing an up file", m.Version)
+		}
+
+		ret = append(ret, *m)
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Version < ret[j].Version })
+
+	return ret, nil
+}
+
+// withMigrationLock runs fn while holding a session level advisory lock,
+// so concurrent runners (e.g. two lambdas cold starting together) apply migrations one at a time.
+func withMigrationLock(ctx context.Context, conn *pgx.Conn, fn func() error) error {
+
+	log.Print("Acquiring migration lock")
+	_, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, MIGRATION_LOCK_KEY)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+
+		log.Print("Releasing migration lock")
+		_, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, MIGRATION_LOCK_KEY)
+
+		if err != nil {
+			log.Print(err)
+		}
+	}()
+
+	_, err = conn.Exec(ctx, migrationTable)
+
+	if err != nil {
+		return err
+	}
+
+	return fn()
+}
+
+func appliedVersions(ctx context.Context, conn *pgx.Conn) (map[int64]bool, error) {
+
+	rows, err := conn.Query(ctx, `SELECT version FROM schema_migrations`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret := map[int64]bool{}
+
+	for _, v := range versions {
+		ret[v] = true
+	}
+
+	return ret, nil
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the versions it applied.
+func Up(ctx context.Context, conn *pgx.Conn) ([]int64, error) {
+
+	if conn == nil {
+		return nil, errors.New("connection can not be null")
+	}
+
+	migrations, err := Migrations()
+
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []int64{}
+
+	err = withMigrationLock(ctx, conn, func() error {
+
+		done, err := appliedVersions(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+
+			if done[m.Version] {
+				continue
+			}
+
+			log.Printf("Applying migration %d %s", m.Version, m.Name)
+
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+
+				if _, err := tx.Exec(ctx, m.Up); err != nil {
+					return err
+				}
+
+				_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+
+				return err
+			})
+
+			if err != nil {
+				return fmt.Errorf("migration %d %s failed: %w", m.Version, m.Name, err)
+			}
+
+			applied = append(applied, m.Version)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return applied, err
+	}
+
+	log.Printf("Applied %d migrations", len(applied))
+
+	return applied, nil
+}
+
+// Down reverts the most recent steps applied migrations, newest first,
+// and returns the versions it reverted.
+func Down(ctx context.Context, conn *pgx.Conn, steps int) ([]int64, error) {
+
+	if conn == nil {
+		return nil, errors.New("connection can not be null")
+	}
+
+	if steps <= 0 {
+		return nil, errors.New("steps must be greater than zero")
+	}
+
+	migrations, err := Migrations()
+
+	if err != nil {
+		return nil, err
+	}
+
+	reverted := []int64{}
+
+	err = withMigrationLock(ctx, conn, func() error {
+
+		done, err := appliedVersions(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+
+			m := migrations[i]
+
+			if !done[m.Version] {
+				continue
+			}
+
+			if len(m.Down) <= 0 {
+				return fmt.Errorf("migration %d %s can not be reverted, it has no down file", m.Version, m.Name)
+			}
+
+			log.Printf("Reverting migration %d %s", m.Version, m.Name)
+
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+
+				if _, err := tx.Exec(ctx, m.Down); err != nil {
+					return err
+				}
+
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+
+				return err
+			})
+
+			if err != nil {
+				return fmt.Errorf("reverting migration %d %s failed: %w", m.Version, m.Name, err)
+			}
+
+			reverted = append(reverted, m.Version)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Version returns the highest applied migration version, or zero if none have been applied.
+func Version(ctx context.Context, conn *pgx.Conn) (int64, error) {
+
+	if conn == nil {
+		return 0, errors.New("connection can not be null")
+	}
+
+	var version int64
+
+	err := withMigrationLock(ctx, conn, func() error {
+		return conn.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	})
+
+	return version, err
+}
+
+// IE2RDSApplyMigrations opens a connection with IE2RDSPostgresConnectionContext
+// and applies any pending migrations.
+func IE2RDSApplyMigrations(ctx context.Context) ([]int64, error) {
+
+	conn, err := ie2aws.IE2RDSPostgresConnectionContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close(context.Background())
+
+	return Up(ctx, conn)
+}
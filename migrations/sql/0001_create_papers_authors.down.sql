@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS paperresearcharea;
+DROP TABLE IF EXISTS researcharea;
+DROP TABLE IF EXISTS authorpaper;
+DROP TABLE IF EXISTS author;
+DROP TABLE IF EXISTS paper;
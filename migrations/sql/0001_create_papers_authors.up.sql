@@ -0,0 +1,40 @@
+CREATE TABLE paper (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    abstract TEXT,
+    url TEXT,
+    createdon TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updatedon TIMESTAMPTZ,
+    deletedon TIMESTAMPTZ
+);
+
+CREATE TABLE author (
+    id SERIAL PRIMARY KEY,
+    fname TEXT NOT NULL,
+    mname TEXT,
+    lname TEXT NOT NULL,
+    title TEXT,
+    isactive BOOLEAN NOT NULL DEFAULT TRUE,
+    createdon TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updatedon TIMESTAMPTZ,
+    deletedon TIMESTAMPTZ
+);
+
+CREATE TABLE authorpaper (
+    authorid INTEGER NOT NULL REFERENCES author (id),
+    paperid INTEGER NOT NULL REFERENCES paper (id),
+    PRIMARY KEY (authorid, paperid)
+);
+
+CREATE INDEX authorpaper_paperid_idx ON authorpaper (paperid);
+
+CREATE TABLE researcharea (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE paperresearcharea (
+    paperid INTEGER NOT NULL REFERENCES paper (id),
+    researchareaid INTEGER NOT NULL REFERENCES researcharea (id),
+    PRIMARY KEY (paperid, researchareaid)
+);
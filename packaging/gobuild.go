@@ -0,0 +1,168 @@
+package ie2packaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+// the provided.al2023 runtime runs the executable with this name at the root of the zip
+const BOOTSTRAP = "bootstrap"
+
+// the go command used for builds
+const GO_BUILD_COMMAND = "go"
+
+// lambda handlers built for provided runtimes don't need the legacy go1.x rpc support
+const GO_BUILD_TAG_NORPC = "lambda.norpc"
+
+// GoArch maps a lambda architecture to the GOARCH to build for.
+func GoArch(architecture string) (string, error) {
+
+	switch strings.ToLower(architecture) {
+	case "", "x86_64":
+		return "amd64", nil
+	case "arm64":
+		return "arm64", nil
+	}
+
+	return "", fmt.Errorf("unsupported lambda architecture %s", architecture)
+}
+
+// BuildGoBootstrap cross compiles params.Package for linux and the lambda architecture
+// and writes the binary to output. The build is trimmed and stripped of build ids and
+// vcs stamps so the same source gives the same binary.
+func BuildGoBootstrap(ctx context.Context, params ie2datatypes.GoBuildParams, output string) error {
+
+	if ctx == nil {
+		return errors.New("context can not be empty")
+	}
+
+	if len(params.Package) <= 0 {
+		return errors.New("package can not be empty")
+	}
+
+	goarch, e := GoArch(params.Architecture)
+
+	if e != nil {
+		return e
+	}
+
+	tags := append([]string{GO_BUILD_TAG_NORPC}, params.Tags...)
+
+	args := []string{
+		"build",
+		"-trimpath",
+		"-buildvcs=false",
+		"-ldflags=-s -w -buildid=",
+		"-tags=" + strings.Join(tags, ","),
+		"-o", output,
+		params.Package,
+	}
+
+	cmd := exec.CommandContext(ctx, GO_BUILD_COMMAND, args...)
+	cmd.Dir = params.Dir
+	cmd.Env = append(os.Environ(), params.Env...)
+	cmd.Env = append(cmd.Env, "GOOS=linux", "GOARCH="+goarch, "CGO_ENABLED=0")
+
+	log.Printf("Building %s for linux/%s", params.Package, goarch)
+	out, e := cmd.CombinedOutput()
+
+	if e != nil {
+		return fmt.Errorf("go build %s failed: %w\n%s", params.Package, e, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// PackageGoHandler builds params.Package into a bootstrap binary and zips it with
+// params.Files for the provided.al2023 runtime. The zip is reproducible, so its
+// CodeSha256 only changes when the handler does.
+func PackageGoHandler(ctx context.Context, params ie2datatypes.GoBuildParams) (*Package, error) {
+
+	dir, e := os.MkdirTemp("", "ie2packaging")
+
+	if e != nil {
+		return nil, e
+	}
+
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, BOOTSTRAP)
+	e = BuildGoBootstrap(ctx, params, output)
+
+	if e != nil {
+		return nil, e
+	}
+
+	data, e := os.ReadFile(output)
+
+	if e != nil {
+		return nil, e
+	}
+
+	entries := []ZipEntry{{Name: BOOTSTRAP, Data: data, Executable: true}}
+
+	for name, p := range params.Files {
+
+		if name == BOOTSTRAP {
+			return nil, fmt.Errorf("file %s would replace the bootstrap binary", p)
+		}
+
+		info, e := os.Stat(p)
+
+		if e != nil {
+			return nil, e
+		}
+
+		data, e := os.ReadFile(p)
+
+		if e != nil {
+			return nil, e
+		}
+
+		entries = append(entries, ZipEntry{Name: name, Data: data, Executable: info.Mode()&0111 != 0})
+	}
+
+	pkg, e := Zip(entries)
+
+	if e != nil {
+		return nil, e
+	}
+
+	log.Printf("Packaged %s into %d bytes with CodeSha256 %s", params.Package, len(pkg.Zip), pkg.CodeSha256)
+
+	return pkg, nil
+}
+
+// PackageGoLambda packages the Go main package pkg for the architecture of config
+// and writes the zip to config.Filename when it is set.
+func PackageGoLambda(ctx context.Context, config ie2datatypes.LambdaConfig, dir string, pkg string) (*Package, error) {
+
+	p, e := PackageGoHandler(ctx, ie2datatypes.GoBuildParams{
+		Dir:          dir,
+		Package:      pkg,
+		Architecture: config.Architecture,
+	})
+
+	if e != nil {
+		return nil, e
+	}
+
+	if len(config.Filename) > 0 {
+
+		e = p.WriteFile(config.Filename)
+
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	return p, nil
+}
@@ -0,0 +1,181 @@
+package ie2packaging
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// every entry gets this timestamp so the same files always give the same zip
+var ZIP_MODIFIED = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ZipEntry is one file in a zip. Executable files get 0755 permissions, the rest 0644.
+type ZipEntry struct {
+	Name       string
+	Data       []byte
+	Executable bool
+}
+
+// Package is a lambda deployment zip along with its hashes.
+// CodeSha256 is in the same form as the CodeSha256 lambda reports for deployed code.
+type Package struct {
+	Zip        []byte
+	Sha256     string
+	CodeSha256 string
+}
+
+// NewPackage wraps a zip and works out its hashes.
+func NewPackage(data []byte) *Package {
+
+	sum := sha256.Sum256(data)
+
+	return &Package{
+		Zip:        data,
+		Sha256:     hex.EncodeToString(sum[:]),
+		CodeSha256: base64.StdEncoding.EncodeToString(sum[:]),
+	}
+}
+
+// WriteFile writes the zip to path, e.g. LambdaConfig.Filename.
+func (p *Package) WriteFile(path string) error {
+
+	if p == nil || len(p.Zip) <= 0 {
+		return errors.New("package is empty")
+	}
+
+	return os.WriteFile(path, p.Zip, 0644)
+}
+
+// CodeSha256 returns the base64 SHA-256 of data, the form lambda reports CodeSha256 in.
+func CodeSha256(data []byte) string {
+
+	sum := sha256.Sum256(data)
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Zip builds a reproducible zip of entries. Entries are sorted by name and get a
+// fixed timestamp and permissions, so the same entries always give the same bytes.
+func Zip(entries []ZipEntry) (*Package, error) {
+
+	if len(entries) <= 0 {
+		return nil, errors.New("nothing to zip")
+	}
+
+	sorted := make([]ZipEntry, len(entries))
+	copy(sorted, entries)
+
+	sort.Slice(sorted, func(i int, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	buffer := new(bytes.Buffer)
+	w := zip.NewWriter(buffer)
+
+	for i, entry := range sorted {
+
+		if len(entry.Name) <= 0 {
+			return nil, errors.New("zip entry name can not be empty")
+		}
+
+		if i > 0 && sorted[i-1].Name == entry.Name {
+			return nil, fmt.Errorf("zip entry %s is duplicated", entry.Name)
+		}
+
+		mode := fs.FileMode(0644)
+
+		if entry.Executable {
+			mode = 0755
+		}
+
+		header := &zip.FileHeader{
+			Name:     entry.Name,
+			Method:   zip.Deflate,
+			Modified: ZIP_MODIFIED,
+		}
+		header.SetMode(mode)
+
+		f, e := w.CreateHeader(header)
+
+		if e != nil {
+			return nil, e
+		}
+
+		_, e = f.Write(entry.Data)
+
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	e := w.Close()
+
+	if e != nil {
+		return nil, e
+	}
+
+	return NewPackage(buffer.Bytes()), nil
+}
+
+// ZipDirectory zips every regular file under dir with paths relative to dir.
+// Files keep whether they are executable, everything else about them is fixed.
+func ZipDirectory(dir string) (*Package, error) {
+
+	entries := []ZipEntry{}
+
+	e := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(p)
+
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, ZipEntry{
+			Name:       filepath.ToSlash(rel),
+			Data:       data,
+			Executable: info.Mode()&0111 != 0,
+		})
+
+		return nil
+	})
+
+	if e != nil {
+		return nil, e
+	}
+
+	if len(entries) <= 0 {
+		return nil, fmt.Errorf("directory %s has no files to zip", dir)
+	}
+
+	return Zip(entries)
+}
@@ -0,0 +1,143 @@
+package ie2packaging
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestZipReproducible(t *testing.T) {
+
+	bootstrap := ZipEntry{Name: "bootstrap", Data: []byte("binary"), Executable: true}
+	config := ZipEntry{Name: "config/papers.yaml", Data: []byte("name: papers\n")}
+	readme := ZipEntry{Name: "README.md", Data: []byte("# papers\n")}
+
+	tests := []struct {
+		name    string
+		entries []ZipEntry
+		other   []ZipEntry
+	}{
+		{name: "same order", entries: []ZipEntry{bootstrap, config}, other: []ZipEntry{bootstrap, config}},
+		{name: "reversed", entries: []ZipEntry{bootstrap, config, readme}, other: []ZipEntry{readme, config, bootstrap}},
+		{name: "shuffled", entries: []ZipEntry{config, bootstrap, readme}, other: []ZipEntry{bootstrap, readme, config}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			p, e := Zip(tt.entries)
+
+			if e != nil {
+				t.Fatalf("Zip() error = %v", e)
+			}
+
+			other, e := Zip(tt.other)
+
+			if e != nil {
+				t.Fatalf("Zip() error = %v", e)
+			}
+
+			if !bytes.Equal(p.Zip, other.Zip) {
+				t.Error("Zip() of the same entries in another order gave different bytes")
+			}
+
+			if p.CodeSha256 != other.CodeSha256 || p.Sha256 != other.Sha256 {
+				t.Errorf("Zip() CodeSha256 = %s and %s, want them equal", p.CodeSha256, other.CodeSha256)
+			}
+
+			if p.CodeSha256 != CodeSha256(p.Zip) {
+				t.Errorf("Zip() CodeSha256 = %s, want %s", p.CodeSha256, CodeSha256(p.Zip))
+			}
+
+			r, e := zip.NewReader(bytes.NewReader(p.Zip), int64(len(p.Zip)))
+
+			if e != nil {
+				t.Fatal(e)
+			}
+
+			names := []string{}
+
+			for _, f := range r.File {
+
+				names = append(names, f.Name)
+
+				if !f.Modified.Equal(ZIP_MODIFIED) {
+					t.Errorf("%s modified = %s, want %s", f.Name, f.Modified, ZIP_MODIFIED)
+				}
+
+				if f.Name == "bootstrap" && f.Mode().Perm() != 0755 {
+					t.Errorf("bootstrap mode = %s, want 0755", f.Mode())
+				}
+			}
+
+			if !slices.IsSorted(names) {
+				t.Errorf("Zip() entries = %v, want them sorted", names)
+			}
+		})
+	}
+}
+
+func TestZipErrors(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		entries []ZipEntry
+	}{
+		{name: "no entries"},
+		{name: "empty name", entries: []ZipEntry{{Name: "", Data: []byte("x")}}},
+		{name: "duplicate", entries: []ZipEntry{{Name: "bootstrap"}, {Name: "main.go"}, {Name: "bootstrap"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if _, e := Zip(tt.entries); e == nil {
+				t.Error("Zip() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestZipDirectory(t *testing.T) {
+
+	dir := t.TempDir()
+
+	for name, data := range map[string]string{"bootstrap": "binary", "config/papers.yaml": "name: papers\n"} {
+
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		if e := os.MkdirAll(filepath.Dir(path), 0755); e != nil {
+			t.Fatal(e)
+		}
+
+		if e := os.WriteFile(path, []byte(data), 0644); e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	if e := os.Chmod(filepath.Join(dir, "bootstrap"), 0755); e != nil {
+		t.Fatal(e)
+	}
+
+	got, e := ZipDirectory(dir)
+
+	if e != nil {
+		t.Fatalf("ZipDirectory() error = %v", e)
+	}
+
+	want, e := Zip([]ZipEntry{
+		{Name: "config/papers.yaml", Data: []byte("name: papers\n")},
+		{Name: "bootstrap", Data: []byte("binary"), Executable: true},
+	})
+
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if got.CodeSha256 != want.CodeSha256 {
+		t.Errorf("ZipDirectory() CodeSha256 = %s, want the same as Zip() %s", got.CodeSha256, want.CodeSha256)
+	}
+}
@@ -0,0 +1,280 @@
+package ie2repository
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+	"github.com/jackc/pgx/v5"
+)
+
+const authorColumns = "a.id, a.fname, a.mname, a.lname, a.title, a.isactive, a.createdon::text, a.updatedon::text, a.deletedon::text"
+
+type AuthorRepository struct {
+	db DBTX
+}
+
+func NewAuthorRepository(db DBTX) *AuthorRepository {
+	return &AuthorRepository{db: db}
+}
+
+func scanAuthor(row pgx.Row, a *ie2datatypes.Author) error {
+	return row.Scan(&a.Id, &a.FirstName, &a.MiddleName, &a.LastName, &a.Title, &a.IsActive, &a.CreatedOn, &a.UpdatedOn, &a.DeletedOn)
+}
+
+// attachPapers fills in Papers, and each paper's ResearchAreas, for every author
+// using one query for the papers and one for the research areas.
+// Soft deleted papers are left out.
+func attachPapers(ctx context.Context, db DBTX, authors []ie2datatypes.Author) error {
+
+	ids := make([]int, 0, len(authors))
+
+	for _, a := range authors {
+		ids = append(ids, *a.Id)
+	}
+
+	if len(ids) <= 0 {
+		return nil
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT ap.authorid, `+paperColumns+`
+		FROM authorpaper ap
+		JOIN paper p ON p.id = ap.paperid
+		WHERE ap.authorid = ANY($1) AND p.deletedon IS NULL
+		ORDER BY p.id`, ids)
+
+	if err != nil {
+		return err
+	}
+
+	byAuthor := map[int][]ie2datatypes.Paper{}
+	papers := []ie2datatypes.Paper{}
+	owners := []int{}
+
+	for rows.Next() {
+
+		var authorid int
+		p := ie2datatypes.Paper{}
+
+		err := rows.Scan(&authorid, &p.Id, &p.Title, &p.Abstract, &p.Url, &p.CreatedOn, &p.UpdatedOn, &p.DeletedOn)
+
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		papers = append(papers, p)
+		owners = append(owners, authorid)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	err = attachResearchAreas(ctx, db, papers)
+
+	if err != nil {
+		return err
+	}
+
+	for i, p := range papers {
+		byAuthor[owners[i]] = append(byAuthor[owners[i]], p)
+	}
+
+	for i := range authors {
+		authors[i].Papers = byAuthor[*authors[i].Id]
+	}
+
+	return nil
+}
+
+// linkPapers links the author to every paper in papers that has an Id.
+func linkPapers(ctx context.Context, db DBTX, authorid int, papers []ie2datatypes.Paper) error {
+
+	ids := []int{}
+
+	for _, p := range papers {
+		if p.Id != nil {
+			ids = append(ids, *p.Id)
+		}
+	}
+
+	if len(ids) <= 0 {
+		return nil
+	}
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO authorpaper (authorid, paperid)
+		SELECT $1, unnest($2::int[])
+		ON CONFLICT DO NOTHING`, authorid, ids)
+
+	return err
+}
+
+// Create inserts a and sets Id and CreatedOn.
+// Any entries in a.Papers with an Id are linked to the new author.
+func (r *AuthorRepository) Create(ctx context.Context, a *ie2datatypes.Author) error {
+
+	if a == nil {
+		return errors.New("author can not be null")
+	}
+
+	if len(a.FirstName) <= 0 || len(a.LastName) <= 0 {
+		return errors.New("author first and last name can not be empty")
+	}
+
+	log.Printf("Creating author %s %s", a.FirstName, a.LastName)
+
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+
+		err := tx.QueryRow(ctx, `
+			INSERT INTO author (fname, mname, lname, title, isactive)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, createdon::text`, a.FirstName, a.MiddleName, a.LastName, a.Title, a.IsActive).Scan(&a.Id, &a.CreatedOn)
+
+		if err != nil {
+			return err
+		}
+
+		return linkPapers(ctx, tx, *a.Id, a.Papers)
+	})
+}
+
+// Get returns the author with id and their papers,
+// or ErrNotFound if the author doesn't exist or was soft deleted.
+func (r *AuthorRepository) Get(ctx context.Context, id int) (*ie2datatypes.Author, error) {
+
+	a := ie2datatypes.Author{}
+
+	err := scanAuthor(r.db.QueryRow(ctx, `
+		SELECT `+authorColumns+`
+		FROM author a
+		WHERE a.id = $1 AND a.deletedon IS NULL`, id), &a)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	authors := []ie2datatypes.Author{a}
+	err = attachPapers(ctx, r.db, authors)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &authors[0], nil
+}
+
+// List returns authors ordered by id along with their papers.
+func (r *AuthorRepository) List(ctx context.Context, opts *ListOptions) ([]ie2datatypes.Author, error) {
+
+	rows, err := r.db.Query(ctx, `
+		SELECT `+authorColumns+`
+		FROM author a
+		WHERE $1 OR a.deletedon IS NULL
+		ORDER BY a.id
+		LIMIT $2 OFFSET $3`, opts.includeDeleted(), opts.limit(), opts.offset())
+
+	if err != nil {
+		return nil, err
+	}
+
+	authors, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ie2datatypes.Author, error) {
+		a := ie2datatypes.Author{}
+		err := scanAuthor(row, &a)
+		return a, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = attachPapers(ctx, r.db, authors)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return authors, nil
+}
+
+// Update saves the name, title and active flag of a and sets UpdatedOn.
+// Paper links are managed with LinkPaper and UnlinkPaper.
+func (r *AuthorRepository) Update(ctx context.Context, a *ie2datatypes.Author) error {
+
+	if a == nil || a.Id == nil {
+		return errors.New("author id can not be empty")
+	}
+
+	log.Printf("Updating author %d", *a.Id)
+
+	err := r.db.QueryRow(ctx, `
+		UPDATE author
+		SET fname = $2, mname = $3, lname = $4, title = $5, isactive = $6, updatedon = now()
+		WHERE id = $1 AND deletedon IS NULL
+		RETURNING updatedon::text`, *a.Id, a.FirstName, a.MiddleName, a.LastName, a.Title, a.IsActive).Scan(&a.UpdatedOn)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+// SoftDelete sets DeletedOn for the author. Links to papers are kept.
+func (r *AuthorRepository) SoftDelete(ctx context.Context, id int) error {
+
+	log.Printf("Soft deleting author %d", id)
+
+	tag, err := r.db.Exec(ctx, `UPDATE author SET deletedon = now() WHERE id = $1 AND deletedon IS NULL`, id)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() <= 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// LinkPaper records authorid as an author of paperid through authorpaper.
+// Linking an existing pair is a no-op.
+func (r *AuthorRepository) LinkPaper(ctx context.Context, link ie2datatypes.AuthorPaper) error {
+
+	log.Printf("Linking author %d to paper %d", link.AuthorId, link.PaperId)
+
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO authorpaper (authorid, paperid)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, link.AuthorId, link.PaperId)
+
+	return err
+}
+
+// UnlinkPaper removes the authorpaper row for the pair, returning ErrNotFound if there wasn't one.
+func (r *AuthorRepository) UnlinkPaper(ctx context.Context, link ie2datatypes.AuthorPaper) error {
+
+	log.Printf("Unlinking author %d from paper %d", link.AuthorId, link.PaperId)
+
+	tag, err := r.db.Exec(ctx, `DELETE FROM authorpaper WHERE authorid = $1 AND paperid = $2`, link.AuthorId, link.PaperId)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() <= 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package ie2repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var ErrNotFound = errors.New("record not found")
+
+// DBTX is the subset of pgx used by the repositories.
+// *pgx.Conn, *pgxpool.Pool and pgx.Tx all satisfy it.
+type DBTX interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// ListOptions pages through List results. A Limit of zero returns every row.
+// Soft deleted rows are skipped unless IncludeDeleted is set.
+type ListOptions struct {
+	Limit          int
+	Offset         int
+	IncludeDeleted bool
+}
+
+func (o *ListOptions) limit() *int {
+
+	if o == nil || o.Limit <= 0 {
+		return nil
+	}
+
+	return &o.Limit
+}
+
+func (o *ListOptions) offset() int {
+
+	if o == nil || o.Offset < 0 {
+		return 0
+	}
+
+	return o.Offset
+}
+
+func (o *ListOptions) includeDeleted() bool {
+	return o != nil && o.IncludeDeleted
+}
@@ -0,0 +1,264 @@
+package ie2repository
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+	"github.com/jackc/pgx/v5"
+)
+
+// timestamps are handed back as text to match the *string fields on the types
+const paperColumns = "p.id, p.title, p.abstract, p.url, p.createdon::text, p.updatedon::text, p.deletedon::text"
+
+type PaperRepository struct {
+	db DBTX
+}
+
+func NewPaperRepository(db DBTX) *PaperRepository {
+	return &PaperRepository{db: db}
+}
+
+func scanPaper(row pgx.Row, p *ie2datatypes.Paper) error {
+	return row.Scan(&p.Id, &p.Title, &p.Abstract, &p.Url, &p.CreatedOn, &p.UpdatedOn, &p.DeletedOn)
+}
+
+// loadResearchAreas fetches the research areas of every paper in ids with a single query.
+func loadResearchAreas(ctx context.Context, db DBTX, ids []int) (map[int][]ie2datatypes.ResearchArea, error) {
+
+	ret := map[int][]ie2datatypes.ResearchArea{}
+
+	if len(ids) <= 0 {
+		return ret, nil
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT pra.paperid, ra.name
+		FROM paperresearcharea pra
+		JOIN researcharea ra ON ra.id = pra.researchareaid
+		WHERE pra.paperid = ANY($1)
+		ORDER BY ra.name`, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var id int
+		var area ie2datatypes.ResearchArea
+
+		if err := rows.Scan(&id, &area.Name); err != nil {
+			return nil, err
+		}
+
+		ret[id] = append(ret[id], area)
+	}
+
+	return ret, rows.Err()
+}
+
+// saveResearchAreas replaces the research areas linked to a paper,
+// creating any area names that don't exist yet.
+func saveResearchAreas(ctx context.Context, db DBTX, id int, areas []ie2datatypes.ResearchArea) error {
+
+	_, err := db.Exec(ctx, `DELETE FROM paperresearcharea WHERE paperid = $1`, id)
+
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(areas))
+
+	for _, area := range areas {
+		if len(area.Name) > 0 {
+			names = append(names, area.Name)
+		}
+	}
+
+	if len(names) <= 0 {
+		return nil
+	}
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO researcharea (name)
+		SELECT DISTINCT unnest($1::text[])
+		ON CONFLICT (name) DO NOTHING`, names)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO paperresearcharea (paperid, researchareaid)
+		SELECT $1, ra.id FROM researcharea ra WHERE ra.name = ANY($2)
+		ON CONFLICT DO NOTHING`, id, names)
+
+	return err
+}
+
+// attachResearchAreas fills in ResearchAreas on each paper using one batched query.
+func attachResearchAreas(ctx context.Context, db DBTX, papers []ie2datatypes.Paper) error {
+
+	ids := make([]int, 0, len(papers))
+
+	for _, p := range papers {
+		ids = append(ids, *p.Id)
+	}
+
+	areas, err := loadResearchAreas(ctx, db, ids)
+
+	if err != nil {
+		return err
+	}
+
+	for i := range papers {
+
+		papers[i].ResearchAreas = areas[*papers[i].Id]
+
+		if papers[i].ResearchAreas == nil {
+			papers[i].ResearchAreas = []ie2datatypes.ResearchArea{}
+		}
+	}
+
+	return nil
+}
+
+// Create inserts p along with its research areas and sets Id and CreatedOn.
+func (r *PaperRepository) Create(ctx context.Context, p *ie2datatypes.Paper) error {
+
+	if p == nil {
+		return errors.New("paper can not be null")
+	}
+
+	if len(p.Title) <= 0 {
+		return errors.New("paper title can not be empty")
+	}
+
+	log.Printf("Creating paper %s", p.Title)
+
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+
+		err := tx.QueryRow(ctx, `
+			INSERT INTO paper (title, abstract, url)
+			VALUES ($1, $2, $3)
+			RETURNING id, createdon::text`, p.Title, p.Abstract, p.Url).Scan(&p.Id, &p.CreatedOn)
+
+		if err != nil {
+			return err
+		}
+
+		return saveResearchAreas(ctx, tx, *p.Id, p.ResearchAreas)
+	})
+}
+
+// Get returns the paper with id, or ErrNotFound if it doesn't exist or was soft deleted.
+func (r *PaperRepository) Get(ctx context.Context, id int) (*ie2datatypes.Paper, error) {
+
+	p := ie2datatypes.Paper{}
+
+	err := scanPaper(r.db.QueryRow(ctx, `
+		SELECT `+paperColumns+`
+		FROM paper p
+		WHERE p.id = $1 AND p.deletedon IS NULL`, id), &p)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	papers := []ie2datatypes.Paper{p}
+	err = attachResearchAreas(ctx, r.db, papers)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &papers[0], nil
+}
+
+// List returns papers ordered by id.
+func (r *PaperRepository) List(ctx context.Context, opts *ListOptions) ([]ie2datatypes.Paper, error) {
+
+	rows, err := r.db.Query(ctx, `
+		SELECT `+paperColumns+`
+		FROM paper p
+		WHERE $1 OR p.deletedon IS NULL
+		ORDER BY p.id
+		LIMIT $2 OFFSET $3`, opts.includeDeleted(), opts.limit(), opts.offset())
+
+	if err != nil {
+		return nil, err
+	}
+
+	papers, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ie2datatypes.Paper, error) {
+		p := ie2datatypes.Paper{}
+		err := scanPaper(row, &p)
+		return p, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = attachResearchAreas(ctx, r.db, papers)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return papers, nil
+}
+
+// Update saves the title, abstract, url and research areas of p and sets UpdatedOn.
+func (r *PaperRepository) Update(ctx context.Context, p *ie2datatypes.Paper) error {
+
+	if p == nil || p.Id == nil {
+		return errors.New("paper id can not be empty")
+	}
+
+	log.Printf("Updating paper %d", *p.Id)
+
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+
+		err := tx.QueryRow(ctx, `
+			UPDATE paper
+			SET title = $2, abstract = $3, url = $4, updatedon = now()
+			WHERE id = $1 AND deletedon IS NULL
+			RETURNING updatedon::text`, *p.Id, p.Title, p.Abstract, p.Url).Scan(&p.UpdatedOn)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return saveResearchAreas(ctx, tx, *p.Id, p.ResearchAreas)
+	})
+}
+
+// SoftDelete sets DeletedOn for the paper. Links to authors are kept.
+func (r *PaperRepository) SoftDelete(ctx context.Context, id int) error {
+
+	log.Printf("Soft deleting paper %d", id)
+
+	tag, err := r.db.Exec(ctx, `UPDATE paper SET deletedon = now() WHERE id = $1 AND deletedon IS NULL`, id)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() <= 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,1615 @@
+package ie2testing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	api "github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	ie2utilities "github.com/insightengine2/ie2-utilities/utils"
+)
+
+var _ ie2utilities.APIGatewayAPI = (*FakeAPIGateway)(nil)
+
+type FakeRestApi struct {
+	Api               types.RestApi
+	Resources         map[string]*types.Resource
+	Authorizers       map[string]*types.Authorizer
+	Models            map[string]*types.Model
+	RequestValidators map[string]*types.RequestValidator
+	GatewayResponses  map[types.GatewayResponseType]*types.GatewayResponse
+	Deployments       []string
+	Stages            map[string]*types.Stage
+}
+
+// FakeAPIGateway keeps REST apis, their resources, methods, integrations,
+// deployments and stages in memory, along with api keys and usage plans.
+// List calls page like the real service, honouring Limit (default 25) and Position.
+type FakeAPIGateway struct {
+	mu            sync.Mutex
+	ids           fakeIds
+	Apis          map[string]*FakeRestApi
+	ApiKeys       map[string]*types.ApiKey
+	UsagePlans    map[string]*types.UsagePlan
+	UsagePlanKeys map[string]map[string]bool
+
+	// Usage is what GetUsage returns, [used, remaining] per day keyed by usage plan id then key id.
+	Usage map[string]map[string][][]int64
+}
+
+func NewFakeAPIGateway() *FakeAPIGateway {
+	return &FakeAPIGateway{
+		Apis:          map[string]*FakeRestApi{},
+		ApiKeys:       map[string]*types.ApiKey{},
+		UsagePlans:    map[string]*types.UsagePlan{},
+		UsagePlanKeys: map[string]map[string]bool{},
+		Usage:         map[string]map[string][][]int64{},
+	}
+}
+
+func apiNotFound(format string, args ...any) error {
+	return &types.NotFoundException{Message: aws.String(fmt.Sprintf(format, args...))}
+}
+
+func apiConflict(format string, args ...any) error {
+	return &types.ConflictException{Message: aws.String(fmt.Sprintf(format, args...))}
+}
+
+// page returns the slice of ids for one page along with the position of the next page, if any.
+func page(ids []string, limit *int32, position *string) ([]string, *string) {
+
+	size := 25
+
+	if limit != nil && *limit > 0 {
+		size = int(*limit)
+	}
+
+	start := 0
+
+	if position != nil {
+		start, _ = strconv.Atoi(*position)
+	}
+
+	if start > len(ids) {
+		start = len(ids)
+	}
+
+	end := start + size
+
+	if end >= len(ids) {
+		return ids[start:], nil
+	}
+
+	return ids[start:end], aws.String(strconv.Itoa(end))
+}
+
+// AddRestApi creates an api with its root resource and returns its id.
+func (f *FakeAPIGateway) AddRestApi(name string) string {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.ids.id()
+	rootid := f.ids.id()
+
+	f.Apis[id] = &FakeRestApi{
+		Api: types.RestApi{
+			Id:             aws.String(id),
+			Name:           aws.String(name),
+			RootResourceId: aws.String(rootid),
+		},
+		Resources: map[string]*types.Resource{
+			rootid: {
+				Id:              aws.String(rootid),
+				Path:            aws.String("/"),
+				ResourceMethods: map[string]types.Method{},
+			},
+		},
+		Authorizers:       map[string]*types.Authorizer{},
+		Models:            map[string]*types.Model{},
+		RequestValidators: map[string]*types.RequestValidator{},
+		GatewayResponses:  map[types.GatewayResponseType]*types.GatewayResponse{},
+		Stages:            map[string]*types.Stage{},
+	}
+
+	return id
+}
+
+func (f *FakeAPIGateway) restApi(apiid *string) (*FakeRestApi, error) {
+
+	a, ok := f.Apis[aws.ToString(apiid)]
+
+	if !ok {
+		return nil, apiNotFound("Invalid API identifier specified %s:%s", FAKE_ACCOUNT_ID, aws.ToString(apiid))
+	}
+
+	return a, nil
+}
+
+func (f *FakeAPIGateway) resource(apiid *string, resourceid *string) (*FakeRestApi, *types.Resource, error) {
+
+	a, err := f.restApi(apiid)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, ok := a.Resources[aws.ToString(resourceid)]
+
+	if !ok {
+		return nil, nil, apiNotFound("Invalid Resource identifier specified")
+	}
+
+	return a, r, nil
+}
+
+func (f *FakeAPIGateway) method(apiid *string, resourceid *string, httpmethod *string) (*types.Resource, types.Method, error) {
+
+	_, r, err := f.resource(apiid, resourceid)
+
+	if err != nil {
+		return nil, types.Method{}, err
+	}
+
+	m, ok := r.ResourceMethods[aws.ToString(httpmethod)]
+
+	if !ok {
+		return nil, types.Method{}, apiNotFound("Invalid Method identifier specified")
+	}
+
+	return r, m, nil
+}
+
+func (f *FakeAPIGateway) GetRestApi(ctx context.Context, params *api.GetRestApiInput, optFns ...func(*api.Options)) (*api.GetRestApiOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "GetRestApi", err)
+	}
+
+	return &api.GetRestApiOutput{
+		Id:             a.Api.Id,
+		Name:           a.Api.Name,
+		RootResourceId: a.Api.RootResourceId,
+	}, nil
+}
+
+func (f *FakeAPIGateway) GetRestApis(ctx context.Context, params *api.GetRestApisInput, optFns ...func(*api.Options)) (*api.GetRestApisOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := []string{}
+
+	for id := range f.Apis {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	ids, next := page(ids, params.Limit, params.Position)
+
+	out := &api.GetRestApisOutput{Position: next}
+
+	for _, id := range ids {
+		out.Items = append(out.Items, f.Apis[id].Api)
+	}
+
+	return out, nil
+}
+
+func (f *FakeAPIGateway) GetResources(ctx context.Context, params *api.GetResourcesInput, optFns ...func(*api.Options)) (*api.GetResourcesOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "GetResources", err)
+	}
+
+	embed := false
+
+	for _, e := range params.Embed {
+		if e == "methods" {
+			embed = true
+		}
+	}
+
+	ids := []string{}
+
+	for id := range a.Resources {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	ids, next := page(ids, params.Limit, params.Position)
+
+	out := &api.GetResourcesOutput{Position: next}
+
+	for _, id := range ids {
+
+		r := *a.Resources[id]
+
+		if embed {
+
+			methods := map[string]types.Method{}
+
+			for k, v := range r.ResourceMethods {
+				methods[k] = v
+			}
+
+			r.ResourceMethods = methods
+
+		} else {
+			r.ResourceMethods = nil
+		}
+
+		out.Items = append(out.Items, r)
+	}
+
+	return out, nil
+}
+
+func (f *FakeAPIGateway) CreateResource(ctx context.Context, params *api.CreateResourceInput, optFns ...func(*api.Options)) (*api.CreateResourceOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, parent, err := f.resource(params.RestApiId, params.ParentId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "CreateResource", err)
+	}
+
+	path := aws.ToString(parent.Path)
+
+	if path != "/" {
+		path += "/"
+	}
+
+	path += aws.ToString(params.PathPart)
+
+	for _, r := range a.Resources {
+		if aws.ToString(r.Path) == path {
+			return nil, fakeError("API Gateway", "CreateResource", apiConflict("Another resource with the same parent already has this name: %s", aws.ToString(params.PathPart)))
+		}
+	}
+
+	id := f.ids.id()
+	a.Resources[id] = &types.Resource{
+		Id:              aws.String(id),
+		ParentId:        params.ParentId,
+		Path:            aws.String(path),
+		PathPart:        params.PathPart,
+		ResourceMethods: map[string]types.Method{},
+	}
+
+	return &api.CreateResourceOutput{
+		Id:       aws.String(id),
+		ParentId: params.ParentId,
+		Path:     aws.String(path),
+		PathPart: params.PathPart,
+	}, nil
+}
+
+func (f *FakeAPIGateway) GetMethod(ctx context.Context, params *api.GetMethodInput, optFns ...func(*api.Options)) (*api.GetMethodOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, m, err := f.method(params.RestApiId, params.ResourceId, params.HttpMethod)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "GetMethod", err)
+	}
+
+	return &api.GetMethodOutput{
+		ApiKeyRequired:      m.ApiKeyRequired,
+		AuthorizationScopes: m.AuthorizationScopes,
+		AuthorizationType:   m.AuthorizationType,
+		AuthorizerId:        m.AuthorizerId,
+		HttpMethod:          m.HttpMethod,
+		MethodIntegration:   m.MethodIntegration,
+		MethodResponses:     m.MethodResponses,
+		RequestModels:       m.RequestModels,
+		RequestValidatorId:  m.RequestValidatorId,
+	}, nil
+}
+
+func (f *FakeAPIGateway) PutMethod(ctx context.Context, params *api.PutMethodInput, optFns ...func(*api.Options)) (*api.PutMethodOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, r, err := f.resource(params.RestApiId, params.ResourceId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "PutMethod", err)
+	}
+
+	name := aws.ToString(params.HttpMethod)
+
+	if _, ok := r.ResourceMethods[name]; ok {
+		return nil, fakeError("API Gateway", "PutMethod", apiConflict("Method already exists for this resource"))
+	}
+
+	a, _ := f.restApi(params.RestApiId)
+
+	if err := checkAuthorizer(a, params.AuthorizationType, params.AuthorizerId); err != nil {
+		return nil, fakeError("API Gateway", "PutMethod", err)
+	}
+
+	if err := checkMethodModels(a, params.RequestModels, params.RequestValidatorId); err != nil {
+		return nil, fakeError("API Gateway", "PutMethod", err)
+	}
+
+	r.ResourceMethods[name] = types.Method{
+		ApiKeyRequired:      aws.Bool(params.ApiKeyRequired),
+		AuthorizationScopes: params.AuthorizationScopes,
+		AuthorizationType:   params.AuthorizationType,
+		AuthorizerId:        params.AuthorizerId,
+		HttpMethod:          params.HttpMethod,
+		RequestModels:       params.RequestModels,
+		RequestValidatorId:  params.RequestValidatorId,
+	}
+
+	return &api.PutMethodOutput{
+		ApiKeyRequired:      aws.Bool(params.ApiKeyRequired),
+		AuthorizationScopes: params.AuthorizationScopes,
+		AuthorizationType:   params.AuthorizationType,
+		AuthorizerId:        params.AuthorizerId,
+		HttpMethod:          params.HttpMethod,
+		RequestModels:       params.RequestModels,
+		RequestValidatorId:  params.RequestValidatorId,
+	}, nil
+}
+
+// checkAuthorizer rejects methods using an authorizer that doesn't exist, like the real service.
+func checkAuthorizer(a *FakeRestApi, authorizationType *string, authorizerid *string) error {
+
+	switch aws.ToString(authorizationType) {
+	case "CUSTOM", "COGNITO_USER_POOLS":
+
+		if _, ok := a.Authorizers[aws.ToString(authorizerid)]; !ok {
+			return &types.BadRequestException{Message: aws.String("Invalid authorizer ID specified")}
+		}
+	}
+
+	return nil
+}
+
+// checkMethodModels rejects methods using a model or request validator that doesn't exist.
+func checkMethodModels(a *FakeRestApi, models map[string]string, validatorid *string) error {
+
+	for _, model := range models {
+		if _, ok := a.Models[model]; !ok {
+			return &types.BadRequestException{Message: aws.String(fmt.Sprintf("Invalid model identifier specified: %s", model))}
+		}
+	}
+
+	if len(aws.ToString(validatorid)) > 0 {
+		if _, ok := a.RequestValidators[aws.ToString(validatorid)]; !ok {
+			return &types.BadRequestException{Message: aws.String("Invalid Request Validator identifier specified")}
+		}
+	}
+
+	return nil
+}
+
+// patchMap applies an add, replace or remove patch operation to the map key
+// at the end of path, e.g. /requestModels/application~1json.
+func patchMap(values map[string]string, op types.PatchOperation) map[string]string {
+
+	path := aws.ToString(op.Path)
+	key := path[strings.LastIndex(path, "/")+1:]
+	key = strings.ReplaceAll(strings.ReplaceAll(key, "~1", "/"), "~0", "~")
+
+	ret := map[string]string{}
+
+	for k, v := range values {
+		ret[k] = v
+	}
+
+	if op.Op == types.OpRemove {
+		delete(ret, key)
+	} else {
+		ret[key] = aws.ToString(op.Value)
+	}
+
+	return ret
+}
+
+// UpdateMethod applies the patch operations this module uses on methods.
+func (f *FakeAPIGateway) UpdateMethod(ctx context.Context, params *api.UpdateMethodInput, optFns ...func(*api.Options)) (*api.UpdateMethodOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, m, err := f.method(params.RestApiId, params.ResourceId, params.HttpMethod)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "UpdateMethod", err)
+	}
+
+	for _, op := range params.PatchOperations {
+
+		value := aws.ToString(op.Value)
+
+		// ids can only be cleared with remove, the real service rejects an empty one
+		if op.Op == types.OpReplace && len(value) <= 0 && (aws.ToString(op.Path) == "/authorizerId" || aws.ToString(op.Path) == "/requestValidatorId") {
+			return nil, fakeError("API Gateway", "UpdateMethod", &types.BadRequestException{Message: aws.String(fmt.Sprintf("Invalid empty value for %s", aws.ToString(op.Path)))})
+		}
+
+		// remove clears the optional fields
+		var set *string
+
+		if op.Op != types.OpRemove {
+			set = aws.String(value)
+		}
+
+		switch aws.ToString(op.Path) {
+		case "/authorizationType":
+			m.AuthorizationType = aws.String(value)
+		case "/authorizerId":
+			m.AuthorizerId = set
+		case "/apiKeyRequired":
+			m.ApiKeyRequired = aws.Bool(value == "true")
+		case "/authorizationScopes":
+			m.AuthorizationScopes = patchList(m.AuthorizationScopes, op.Op, value)
+		case "/requestValidatorId":
+			m.RequestValidatorId = set
+		default:
+			if strings.HasPrefix(aws.ToString(op.Path), "/requestModels/") {
+				m.RequestModels = patchMap(m.RequestModels, op)
+				continue
+			}
+
+			return nil, fakeError("API Gateway", "UpdateMethod", &types.BadRequestException{Message: aws.String(fmt.Sprintf("Invalid patch path %s", aws.ToString(op.Path)))})
+		}
+	}
+
+	a, _ := f.restApi(params.RestApiId)
+
+	if err := checkAuthorizer(a, m.AuthorizationType, m.AuthorizerId); err != nil {
+		return nil, fakeError("API Gateway", "UpdateMethod", err)
+	}
+
+	if err := checkMethodModels(a, m.RequestModels, m.RequestValidatorId); err != nil {
+		return nil, fakeError("API Gateway", "UpdateMethod", err)
+	}
+
+	r.ResourceMethods[aws.ToString(params.HttpMethod)] = m
+
+	return &api.UpdateMethodOutput{
+		ApiKeyRequired:      m.ApiKeyRequired,
+		AuthorizationScopes: m.AuthorizationScopes,
+		AuthorizationType:   m.AuthorizationType,
+		AuthorizerId:        m.AuthorizerId,
+		HttpMethod:          m.HttpMethod,
+		RequestModels:       m.RequestModels,
+		RequestValidatorId:  m.RequestValidatorId,
+	}, nil
+}
+
+// patchList applies an add or remove patch operation to a list value.
+func patchList(values []string, op types.Op, value string) []string {
+
+	ret := []string{}
+
+	for _, v := range values {
+		if v != value {
+			ret = append(ret, v)
+		}
+	}
+
+	if op == types.OpAdd {
+		ret = append(ret, value)
+	}
+
+	return ret
+}
+
+func (f *FakeAPIGateway) CreateAuthorizer(ctx context.Context, params *api.CreateAuthorizerInput, optFns ...func(*api.Options)) (*api.CreateAuthorizerOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "CreateAuthorizer", err)
+	}
+
+	for _, existing := range a.Authorizers {
+		if aws.ToString(existing.Name) == aws.ToString(params.Name) {
+			return nil, fakeError("API Gateway", "CreateAuthorizer", apiConflict("Authorizer name must be unique. Authorizer %s already exists in this RestApi.", aws.ToString(params.Name)))
+		}
+	}
+
+	id := f.ids.id()
+	a.Authorizers[id] = &types.Authorizer{
+		Id:                           aws.String(id),
+		Name:                         params.Name,
+		Type:                         params.Type,
+		AuthorizerUri:                params.AuthorizerUri,
+		AuthorizerCredentials:        params.AuthorizerCredentials,
+		AuthorizerResultTtlInSeconds: params.AuthorizerResultTtlInSeconds,
+		IdentitySource:               params.IdentitySource,
+		ProviderARNs:                 params.ProviderARNs,
+	}
+
+	au := a.Authorizers[id]
+
+	return &api.CreateAuthorizerOutput{
+		Id:                           au.Id,
+		Name:                         au.Name,
+		Type:                         au.Type,
+		AuthorizerUri:                au.AuthorizerUri,
+		AuthorizerCredentials:        au.AuthorizerCredentials,
+		AuthorizerResultTtlInSeconds: au.AuthorizerResultTtlInSeconds,
+		IdentitySource:               au.IdentitySource,
+		ProviderARNs:                 au.ProviderARNs,
+	}, nil
+}
+
+func (f *FakeAPIGateway) GetAuthorizers(ctx context.Context, params *api.GetAuthorizersInput, optFns ...func(*api.Options)) (*api.GetAuthorizersOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "GetAuthorizers", err)
+	}
+
+	ids := []string{}
+
+	for id := range a.Authorizers {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	ids, next := page(ids, params.Limit, params.Position)
+
+	out := &api.GetAuthorizersOutput{Position: next}
+
+	for _, id := range ids {
+		out.Items = append(out.Items, *a.Authorizers[id])
+	}
+
+	return out, nil
+}
+
+// UpdateAuthorizer applies the patch operations this module uses on authorizers.
+func (f *FakeAPIGateway) UpdateAuthorizer(ctx context.Context, params *api.UpdateAuthorizerInput, optFns ...func(*api.Options)) (*api.UpdateAuthorizerOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "UpdateAuthorizer", err)
+	}
+
+	au, ok := a.Authorizers[aws.ToString(params.AuthorizerId)]
+
+	if !ok {
+		return nil, fakeError("API Gateway", "UpdateAuthorizer", apiNotFound("Invalid Authorizer identifier specified"))
+	}
+
+	for _, op := range params.PatchOperations {
+
+		value := aws.ToString(op.Value)
+
+		if op.Op == types.OpReplace && op.Value == nil {
+			return nil, fakeError("API Gateway", "UpdateAuthorizer", &types.BadRequestException{Message: aws.String(fmt.Sprintf("Invalid null value for %s", aws.ToString(op.Path)))})
+		}
+
+		// remove clears the optional fields
+		var set *string
+
+		if op.Op != types.OpRemove {
+			set = aws.String(value)
+		}
+
+		switch aws.ToString(op.Path) {
+		case "/name":
+			au.Name = aws.String(value)
+		case "/type":
+			au.Type = types.AuthorizerType(value)
+		case "/authorizerUri":
+			au.AuthorizerUri = set
+		case "/identitySource":
+			au.IdentitySource = set
+		case "/authorizerCredentials":
+			au.AuthorizerCredentials = set
+		case "/authorizerResultTtlInSeconds":
+			ttl, _ := strconv.Atoi(value)
+			au.AuthorizerResultTtlInSeconds = aws.Int32(int32(ttl))
+		case "/providerARNs":
+			au.ProviderARNs = patchList(au.ProviderARNs, op.Op, value)
+		default:
+			return nil, fakeError("API Gateway", "UpdateAuthorizer", &types.BadRequestException{Message: aws.String(fmt.Sprintf("Invalid patch path %s", aws.ToString(op.Path)))})
+		}
+	}
+
+	return &api.UpdateAuthorizerOutput{
+		Id:                           au.Id,
+		Name:                         au.Name,
+		Type:                         au.Type,
+		AuthorizerUri:                au.AuthorizerUri,
+		AuthorizerCredentials:        au.AuthorizerCredentials,
+		AuthorizerResultTtlInSeconds: au.AuthorizerResultTtlInSeconds,
+		IdentitySource:               au.IdentitySource,
+		ProviderARNs:                 au.ProviderARNs,
+	}, nil
+}
+
+// PutMethodResponse fails if the method already has a response for the status code, like the real service.
+func (f *FakeAPIGateway) PutMethodResponse(ctx context.Context, params *api.PutMethodResponseInput, optFns ...func(*api.Options)) (*api.PutMethodResponseOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, m, err := f.method(params.RestApiId, params.ResourceId, params.HttpMethod)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "PutMethodResponse", err)
+	}
+
+	status := aws.ToString(params.StatusCode)
+
+	if _, ok := m.MethodResponses[status]; ok {
+		return nil, fakeError("API Gateway", "PutMethodResponse", apiConflict("Response already exists for this resource"))
+	}
+
+	a, _ := f.restApi(params.RestApiId)
+
+	if err := checkMethodModels(a, params.ResponseModels, nil); err != nil {
+		return nil, fakeError("API Gateway", "PutMethodResponse", err)
+	}
+
+	responses := map[string]types.MethodResponse{}
+
+	for k, v := range m.MethodResponses {
+		responses[k] = v
+	}
+
+	responses[status] = types.MethodResponse{
+		StatusCode:         params.StatusCode,
+		ResponseModels:     params.ResponseModels,
+		ResponseParameters: params.ResponseParameters,
+	}
+
+	m.MethodResponses = responses
+	r.ResourceMethods[aws.ToString(params.HttpMethod)] = m
+
+	return &api.PutMethodResponseOutput{
+		StatusCode:         params.StatusCode,
+		ResponseModels:     params.ResponseModels,
+		ResponseParameters: params.ResponseParameters,
+	}, nil
+}
+
+// UpdateMethodResponse supports patching /responseModels/<content type>.
+func (f *FakeAPIGateway) UpdateMethodResponse(ctx context.Context, params *api.UpdateMethodResponseInput, optFns ...func(*api.Options)) (*api.UpdateMethodResponseOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, m, err := f.method(params.RestApiId, params.ResourceId, params.HttpMethod)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "UpdateMethodResponse", err)
+	}
+
+	status := aws.ToString(params.StatusCode)
+	response, ok := m.MethodResponses[status]
+
+	if !ok {
+		return nil, fakeError("API Gateway", "UpdateMethodResponse", apiNotFound("Invalid Response status code specified"))
+	}
+
+	for _, op := range params.PatchOperations {
+
+		if !strings.HasPrefix(aws.ToString(op.Path), "/responseModels/") {
+			return nil, fakeError("API Gateway", "UpdateMethodResponse", &types.BadRequestException{Message: aws.String(fmt.Sprintf("Invalid patch path %s", aws.ToString(op.Path)))})
+		}
+
+		response.ResponseModels = patchMap(response.ResponseModels, op)
+	}
+
+	a, _ := f.restApi(params.RestApiId)
+
+	if err := checkMethodModels(a, response.ResponseModels, nil); err != nil {
+		return nil, fakeError("API Gateway", "UpdateMethodResponse", err)
+	}
+
+	responses := map[string]types.MethodResponse{}
+
+	for k, v := range m.MethodResponses {
+		responses[k] = v
+	}
+
+	responses[status] = response
+	m.MethodResponses = responses
+	r.ResourceMethods[aws.ToString(params.HttpMethod)] = m
+
+	return &api.UpdateMethodResponseOutput{
+		StatusCode:         response.StatusCode,
+		ResponseModels:     response.ResponseModels,
+		ResponseParameters: response.ResponseParameters,
+	}, nil
+}
+
+// CreateModel keeps models by name, which is also how they are looked up.
+func (f *FakeAPIGateway) CreateModel(ctx context.Context, params *api.CreateModelInput, optFns ...func(*api.Options)) (*api.CreateModelOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "CreateModel", err)
+	}
+
+	name := aws.ToString(params.Name)
+
+	if _, ok := a.Models[name]; ok {
+		return nil, fakeError("API Gateway", "CreateModel", apiConflict("Model name already exists for this REST API"))
+	}
+
+	if !json.Valid([]byte(aws.ToString(params.Schema))) {
+		return nil, fakeError("API Gateway", "CreateModel", &types.BadRequestException{Message: aws.String("Invalid model specified: Validation Result: warnings : [], errors : [Invalid model schema specified]")})
+	}
+
+	a.Models[name] = &types.Model{
+		Id:          aws.String(f.ids.id()),
+		Name:        params.Name,
+		ContentType: params.ContentType,
+		Description: params.Description,
+		Schema:      params.Schema,
+	}
+
+	m := a.Models[name]
+
+	return &api.CreateModelOutput{
+		Id:          m.Id,
+		Name:        m.Name,
+		ContentType: m.ContentType,
+		Description: m.Description,
+		Schema:      m.Schema,
+	}, nil
+}
+
+func (f *FakeAPIGateway) GetModel(ctx context.Context, params *api.GetModelInput, optFns ...func(*api.Options)) (*api.GetModelOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "GetModel", err)
+	}
+
+	m, ok := a.Models[aws.ToString(params.ModelName)]
+
+	if !ok {
+		return nil, fakeError("API Gateway", "GetModel", apiNotFound("Invalid model name specified: %s", aws.ToString(params.ModelName)))
+	}
+
+	return &api.GetModelOutput{
+		Id:          m.Id,
+		Name:        m.Name,
+		ContentType: m.ContentType,
+		Description: m.Description,
+		Schema:      m.Schema,
+	}, nil
+}
+
+// UpdateModel supports replacing /schema and /description.
+func (f *FakeAPIGateway) UpdateModel(ctx context.Context, params *api.UpdateModelInput, optFns ...func(*api.Options)) (*api.UpdateModelOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "UpdateModel", err)
+	}
+
+	m, ok := a.Models[aws.ToString(params.ModelName)]
+
+	if !ok {
+		return nil, fakeError("API Gateway", "UpdateModel", apiNotFound("Invalid model name specified: %s", aws.ToString(params.ModelName)))
+	}
+
+	for _, op := range params.PatchOperations {
+
+		switch aws.ToString(op.Path) {
+		case "/schema":
+			if !json.Valid([]byte(aws.ToString(op.Value))) {
+				return nil, fakeError("API Gateway", "UpdateModel", &types.BadRequestException{Message: aws.String("Invalid model schema specified")})
+			}
+
+			m.Schema = op.Value
+		case "/description":
+			m.Description = op.Value
+		default:
+			return nil, fakeError("API Gateway", "UpdateModel", &types.BadRequestException{Message: aws.String(fmt.Sprintf("Invalid patch path %s", aws.ToString(op.Path)))})
+		}
+	}
+
+	return &api.UpdateModelOutput{
+		Id:          m.Id,
+		Name:        m.Name,
+		ContentType: m.ContentType,
+		Description: m.Description,
+		Schema:      m.Schema,
+	}, nil
+}
+
+func (f *FakeAPIGateway) CreateRequestValidator(ctx context.Context, params *api.CreateRequestValidatorInput, optFns ...func(*api.Options)) (*api.CreateRequestValidatorOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "CreateRequestValidator", err)
+	}
+
+	for _, existing := range a.RequestValidators {
+		if aws.ToString(existing.Name) == aws.ToString(params.Name) {
+			return nil, fakeError("API Gateway", "CreateRequestValidator", apiConflict("Validator name already exists for this REST API"))
+		}
+	}
+
+	id := f.ids.id()
+	a.RequestValidators[id] = &types.RequestValidator{
+		Id:                        aws.String(id),
+		Name:                      params.Name,
+		ValidateRequestBody:       params.ValidateRequestBody,
+		ValidateRequestParameters: params.ValidateRequestParameters,
+	}
+
+	return &api.CreateRequestValidatorOutput{
+		Id:                        aws.String(id),
+		Name:                      params.Name,
+		ValidateRequestBody:       params.ValidateRequestBody,
+		ValidateRequestParameters: params.ValidateRequestParameters,
+	}, nil
+}
+
+func (f *FakeAPIGateway) GetRequestValidators(ctx context.Context, params *api.GetRequestValidatorsInput, optFns ...func(*api.Options)) (*api.GetRequestValidatorsOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "GetRequestValidators", err)
+	}
+
+	ids := []string{}
+
+	for id := range a.RequestValidators {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	ids, next := page(ids, params.Limit, params.Position)
+
+	out := &api.GetRequestValidatorsOutput{Position: next}
+
+	for _, id := range ids {
+		out.Items = append(out.Items, *a.RequestValidators[id])
+	}
+
+	return out, nil
+}
+
+func (f *FakeAPIGateway) GetIntegration(ctx context.Context, params *api.GetIntegrationInput, optFns ...func(*api.Options)) (*api.GetIntegrationOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, m, err := f.method(params.RestApiId, params.ResourceId, params.HttpMethod)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "GetIntegration", err)
+	}
+
+	if m.MethodIntegration == nil {
+		return nil, fakeError("API Gateway", "GetIntegration", apiNotFound("No integration defined for method"))
+	}
+
+	i := m.MethodIntegration
+
+	return &api.GetIntegrationOutput{
+		HttpMethod:           i.HttpMethod,
+		IntegrationResponses: i.IntegrationResponses,
+		PassthroughBehavior:  i.PassthroughBehavior,
+		RequestParameters:    i.RequestParameters,
+		RequestTemplates:     i.RequestTemplates,
+		Type:                 i.Type,
+		Uri:                  i.Uri,
+	}, nil
+}
+
+func (f *FakeAPIGateway) PutIntegration(ctx context.Context, params *api.PutIntegrationInput, optFns ...func(*api.Options)) (*api.PutIntegrationOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, m, err := f.method(params.RestApiId, params.ResourceId, params.HttpMethod)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "PutIntegration", err)
+	}
+
+	m.MethodIntegration = &types.Integration{
+		HttpMethod:          params.IntegrationHttpMethod,
+		PassthroughBehavior: params.PassthroughBehavior,
+		RequestParameters:   params.RequestParameters,
+		RequestTemplates:    params.RequestTemplates,
+		Type:                params.Type,
+		Uri:                 params.Uri,
+	}
+
+	r.ResourceMethods[aws.ToString(params.HttpMethod)] = m
+
+	return &api.PutIntegrationOutput{
+		HttpMethod:          params.IntegrationHttpMethod,
+		PassthroughBehavior: params.PassthroughBehavior,
+		RequestParameters:   params.RequestParameters,
+		RequestTemplates:    params.RequestTemplates,
+		Type:                params.Type,
+		Uri:                 params.Uri,
+	}, nil
+}
+
+// PutIntegrationResponse needs a method response with the same status code, and every
+// header it sets has to be declared on that method response, like the real service.
+func (f *FakeAPIGateway) PutIntegrationResponse(ctx context.Context, params *api.PutIntegrationResponseInput, optFns ...func(*api.Options)) (*api.PutIntegrationResponseOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, m, err := f.method(params.RestApiId, params.ResourceId, params.HttpMethod)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "PutIntegrationResponse", err)
+	}
+
+	if m.MethodIntegration == nil {
+		return nil, fakeError("API Gateway", "PutIntegrationResponse", apiNotFound("No integration defined for method"))
+	}
+
+	status := aws.ToString(params.StatusCode)
+	response, ok := m.MethodResponses[status]
+
+	if !ok {
+		return nil, fakeError("API Gateway", "PutIntegrationResponse", apiNotFound("Invalid Method Response identifier specified"))
+	}
+
+	for name := range params.ResponseParameters {
+		if _, ok := response.ResponseParameters[name]; !ok {
+			return nil, fakeError("API Gateway", "PutIntegrationResponse", &types.BadRequestException{Message: aws.String(fmt.Sprintf("Invalid mapping expression specified: Validation Result: warnings : [], errors : [No method response exists for method. %s]", name))})
+		}
+	}
+
+	integration := *m.MethodIntegration
+	responses := map[string]types.IntegrationResponse{}
+
+	for k, v := range integration.IntegrationResponses {
+		responses[k] = v
+	}
+
+	responses[status] = types.IntegrationResponse{
+		StatusCode:         params.StatusCode,
+		ResponseParameters: params.ResponseParameters,
+		ResponseTemplates:  params.ResponseTemplates,
+		SelectionPattern:   params.SelectionPattern,
+	}
+
+	integration.IntegrationResponses = responses
+	m.MethodIntegration = &integration
+	r.ResourceMethods[aws.ToString(params.HttpMethod)] = m
+
+	return &api.PutIntegrationResponseOutput{
+		StatusCode:         params.StatusCode,
+		ResponseParameters: params.ResponseParameters,
+		ResponseTemplates:  params.ResponseTemplates,
+		SelectionPattern:   params.SelectionPattern,
+	}, nil
+}
+
+// DeleteMethod removes a method along with its integration and responses.
+func (f *FakeAPIGateway) DeleteMethod(ctx context.Context, params *api.DeleteMethodInput, optFns ...func(*api.Options)) (*api.DeleteMethodOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, _, err := f.method(params.RestApiId, params.ResourceId, params.HttpMethod)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "DeleteMethod", err)
+	}
+
+	delete(r.ResourceMethods, aws.ToString(params.HttpMethod))
+
+	return &api.DeleteMethodOutput{}, nil
+}
+
+// PutGatewayResponse creates or replaces the gateway response of a type.
+func (f *FakeAPIGateway) PutGatewayResponse(ctx context.Context, params *api.PutGatewayResponseInput, optFns ...func(*api.Options)) (*api.PutGatewayResponseOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "PutGatewayResponse", err)
+	}
+
+	a.GatewayResponses[params.ResponseType] = &types.GatewayResponse{
+		ResponseType:       params.ResponseType,
+		StatusCode:         params.StatusCode,
+		ResponseParameters: params.ResponseParameters,
+		ResponseTemplates:  params.ResponseTemplates,
+	}
+
+	return &api.PutGatewayResponseOutput{
+		ResponseType:       params.ResponseType,
+		StatusCode:         params.StatusCode,
+		ResponseParameters: params.ResponseParameters,
+		ResponseTemplates:  params.ResponseTemplates,
+	}, nil
+}
+
+func (f *FakeAPIGateway) DeleteIntegration(ctx context.Context, params *api.DeleteIntegrationInput, optFns ...func(*api.Options)) (*api.DeleteIntegrationOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, m, err := f.method(params.RestApiId, params.ResourceId, params.HttpMethod)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "DeleteIntegration", err)
+	}
+
+	if m.MethodIntegration == nil {
+		return nil, fakeError("API Gateway", "DeleteIntegration", apiNotFound("No integration defined for method"))
+	}
+
+	m.MethodIntegration = nil
+	r.ResourceMethods[aws.ToString(params.HttpMethod)] = m
+
+	return &api.DeleteIntegrationOutput{}, nil
+}
+
+func (f *FakeAPIGateway) CreateDeployment(ctx context.Context, params *api.CreateDeploymentInput, optFns ...func(*api.Options)) (*api.CreateDeploymentOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "CreateDeployment", err)
+	}
+
+	id := f.ids.id()
+	a.Deployments = append(a.Deployments, id)
+
+	if params.StageName != nil {
+		a.Stages[*params.StageName] = &types.Stage{
+			DeploymentId: aws.String(id),
+			StageName:    params.StageName,
+		}
+	}
+
+	return &api.CreateDeploymentOutput{
+		Id:          aws.String(id),
+		Description: params.Description,
+	}, nil
+}
+
+func (f *FakeAPIGateway) GetStage(ctx context.Context, params *api.GetStageInput, optFns ...func(*api.Options)) (*api.GetStageOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "GetStage", err)
+	}
+
+	st, ok := a.Stages[aws.ToString(params.StageName)]
+
+	if !ok {
+		return nil, fakeError("API Gateway", "GetStage", apiNotFound("Invalid Stage identifier specified"))
+	}
+
+	return &api.GetStageOutput{
+		DeploymentId: st.DeploymentId,
+		StageName:    st.StageName,
+		Variables:    st.Variables,
+	}, nil
+}
+
+func (f *FakeAPIGateway) CreateStage(ctx context.Context, params *api.CreateStageInput, optFns ...func(*api.Options)) (*api.CreateStageOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "CreateStage", err)
+	}
+
+	name := aws.ToString(params.StageName)
+
+	if _, ok := a.Stages[name]; ok {
+		return nil, fakeError("API Gateway", "CreateStage", apiConflict("Stage already exists"))
+	}
+
+	a.Stages[name] = &types.Stage{
+		DeploymentId: params.DeploymentId,
+		StageName:    params.StageName,
+		Variables:    params.Variables,
+	}
+
+	return &api.CreateStageOutput{
+		DeploymentId: params.DeploymentId,
+		StageName:    params.StageName,
+		Variables:    params.Variables,
+	}, nil
+}
+
+// UpdateStage supports replacing /deploymentId, which is all this module patches.
+func (f *FakeAPIGateway) UpdateStage(ctx context.Context, params *api.UpdateStageInput, optFns ...func(*api.Options)) (*api.UpdateStageOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "UpdateStage", err)
+	}
+
+	st, ok := a.Stages[aws.ToString(params.StageName)]
+
+	if !ok {
+		return nil, fakeError("API Gateway", "UpdateStage", apiNotFound("Invalid Stage identifier specified"))
+	}
+
+	for _, op := range params.PatchOperations {
+		if op.Op == types.OpReplace && aws.ToString(op.Path) == "/deploymentId" {
+			st.DeploymentId = op.Value
+		}
+	}
+
+	return &api.UpdateStageOutput{
+		DeploymentId: st.DeploymentId,
+		StageName:    st.StageName,
+		Variables:    st.Variables,
+	}, nil
+}
+
+func (f *FakeAPIGateway) CreateApiKey(ctx context.Context, params *api.CreateApiKeyInput, optFns ...func(*api.Options)) (*api.CreateApiKeyOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.ids.id()
+	value := aws.ToString(params.Value)
+
+	if len(value) <= 0 {
+		value = fmt.Sprintf("%s-value", id)
+	}
+
+	now := time.Now()
+	f.ApiKeys[id] = &types.ApiKey{
+		Id:              aws.String(id),
+		Name:            params.Name,
+		Description:     params.Description,
+		Enabled:         params.Enabled,
+		Value:           aws.String(value),
+		CreatedDate:     &now,
+		LastUpdatedDate: &now,
+	}
+
+	k := f.ApiKeys[id]
+
+	return &api.CreateApiKeyOutput{
+		Id:          k.Id,
+		Name:        k.Name,
+		Description: k.Description,
+		Enabled:     k.Enabled,
+		Value:       k.Value,
+		CreatedDate: k.CreatedDate,
+	}, nil
+}
+
+// GetApiKeys matches NameQuery as a prefix and only returns key values when IncludeValues is set.
+func (f *FakeAPIGateway) GetApiKeys(ctx context.Context, params *api.GetApiKeysInput, optFns ...func(*api.Options)) (*api.GetApiKeysOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := []string{}
+
+	for id, k := range f.ApiKeys {
+		if strings.HasPrefix(aws.ToString(k.Name), aws.ToString(params.NameQuery)) {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+	ids, next := page(ids, params.Limit, params.Position)
+
+	out := &api.GetApiKeysOutput{Position: next}
+
+	for _, id := range ids {
+
+		k := *f.ApiKeys[id]
+
+		if !aws.ToBool(params.IncludeValues) {
+			k.Value = nil
+		}
+
+		out.Items = append(out.Items, k)
+	}
+
+	return out, nil
+}
+
+// UpdateApiKey supports replacing /enabled, /name and /description.
+func (f *FakeAPIGateway) UpdateApiKey(ctx context.Context, params *api.UpdateApiKeyInput, optFns ...func(*api.Options)) (*api.UpdateApiKeyOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	k, ok := f.ApiKeys[aws.ToString(params.ApiKey)]
+
+	if !ok {
+		return nil, fakeError("API Gateway", "UpdateApiKey", apiNotFound("Invalid API Key identifier specified"))
+	}
+
+	for _, op := range params.PatchOperations {
+
+		value := aws.ToString(op.Value)
+
+		switch aws.ToString(op.Path) {
+		case "/enabled":
+			k.Enabled = value == "true"
+		case "/name":
+			k.Name = aws.String(value)
+		case "/description":
+			k.Description = aws.String(value)
+		default:
+			return nil, fakeError("API Gateway", "UpdateApiKey", &types.BadRequestException{Message: aws.String(fmt.Sprintf("Invalid patch path %s", aws.ToString(op.Path)))})
+		}
+	}
+
+	now := time.Now()
+	k.LastUpdatedDate = &now
+
+	return &api.UpdateApiKeyOutput{
+		Id:          k.Id,
+		Name:        k.Name,
+		Description: k.Description,
+		Enabled:     k.Enabled,
+		CreatedDate: k.CreatedDate,
+	}, nil
+}
+
+// checkApiStage fails like the real service when a usage plan names an api or stage that doesn't exist.
+func (f *FakeAPIGateway) checkApiStage(apiid string, stage string) error {
+
+	a, err := f.restApi(aws.String(apiid))
+
+	if err != nil {
+		return err
+	}
+
+	if _, ok := a.Stages[stage]; !ok {
+		return apiNotFound("Invalid stage identifier specified")
+	}
+
+	return nil
+}
+
+func (f *FakeAPIGateway) CreateUsagePlan(ctx context.Context, params *api.CreateUsagePlanInput, optFns ...func(*api.Options)) (*api.CreateUsagePlanOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, stage := range params.ApiStages {
+		if err := f.checkApiStage(aws.ToString(stage.ApiId), aws.ToString(stage.Stage)); err != nil {
+			return nil, fakeError("API Gateway", "CreateUsagePlan", err)
+		}
+	}
+
+	id := f.ids.id()
+	f.UsagePlans[id] = &types.UsagePlan{
+		Id:          aws.String(id),
+		Name:        params.Name,
+		Description: params.Description,
+		ApiStages:   params.ApiStages,
+		Throttle:    params.Throttle,
+		Quota:       params.Quota,
+	}
+	f.UsagePlanKeys[id] = map[string]bool{}
+
+	p := f.UsagePlans[id]
+
+	return &api.CreateUsagePlanOutput{
+		Id:          p.Id,
+		Name:        p.Name,
+		Description: p.Description,
+		ApiStages:   p.ApiStages,
+		Throttle:    p.Throttle,
+		Quota:       p.Quota,
+	}, nil
+}
+
+// GetUsagePlans only returns the plans KeyId is in when it is set.
+func (f *FakeAPIGateway) GetUsagePlans(ctx context.Context, params *api.GetUsagePlansInput, optFns ...func(*api.Options)) (*api.GetUsagePlansOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := []string{}
+
+	for id := range f.UsagePlans {
+		if params.KeyId == nil || f.UsagePlanKeys[id][aws.ToString(params.KeyId)] {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+	ids, next := page(ids, params.Limit, params.Position)
+
+	out := &api.GetUsagePlansOutput{Position: next}
+
+	for _, id := range ids {
+		out.Items = append(out.Items, *f.UsagePlans[id])
+	}
+
+	return out, nil
+}
+
+// usagePlanThrottleKey turns the <escaped resource path>/<method> of a throttle patch path
+// into the key of the throttle map, e.g. ~1v1~1papers/GET is /v1/papers/GET.
+func usagePlanThrottleKey(resource string, method string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(resource, "~1", "/"), "~0", "~") + "/" + method
+}
+
+func patchThrottle(throttle *types.ThrottleSettings, field string, value string) error {
+
+	switch field {
+	case "rateLimit":
+		rate, err := strconv.ParseFloat(value, 64)
+
+		if err != nil {
+			return err
+		}
+
+		throttle.RateLimit = rate
+	case "burstLimit":
+		burst, err := strconv.Atoi(value)
+
+		if err != nil {
+			return err
+		}
+
+		throttle.BurstLimit = int32(burst)
+	default:
+		return fmt.Errorf("invalid throttle field %s", field)
+	}
+
+	return nil
+}
+
+// UpdateUsagePlan applies the patch operations this module uses on usage plans.
+func (f *FakeAPIGateway) UpdateUsagePlan(ctx context.Context, params *api.UpdateUsagePlanInput, optFns ...func(*api.Options)) (*api.UpdateUsagePlanOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.UsagePlans[aws.ToString(params.UsagePlanId)]
+
+	if !ok {
+		return nil, fakeError("API Gateway", "UpdateUsagePlan", apiNotFound("Invalid Usage Plan ID specified"))
+	}
+
+	badRequest := func(path string) error {
+		return fakeError("API Gateway", "UpdateUsagePlan", &types.BadRequestException{Message: aws.String(fmt.Sprintf("Invalid patch path %s", path))})
+	}
+
+	for _, op := range params.PatchOperations {
+
+		path := aws.ToString(op.Path)
+		value := aws.ToString(op.Value)
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+		switch {
+		case path == "/description":
+			p.Description = aws.String(value)
+		case path == "/throttle" && op.Op == types.OpRemove:
+			p.Throttle = nil
+		case parts[0] == "throttle" && len(parts) == 2:
+			if p.Throttle == nil {
+				p.Throttle = &types.ThrottleSettings{}
+			}
+
+			if err := patchThrottle(p.Throttle, parts[1], value); err != nil {
+				return nil, badRequest(path)
+			}
+		case path == "/quota" && op.Op == types.OpRemove:
+			p.Quota = nil
+		case parts[0] == "quota" && len(parts) == 2:
+			if p.Quota == nil {
+				p.Quota = &types.QuotaSettings{}
+			}
+
+			n, _ := strconv.Atoi(value)
+
+			switch parts[1] {
+			case "limit":
+				p.Quota.Limit = int32(n)
+			case "offset":
+				p.Quota.Offset = int32(n)
+			case "period":
+				p.Quota.Period = types.QuotaPeriodType(value)
+			default:
+				return nil, badRequest(path)
+			}
+		case path == "/apiStages" && op.Op == types.OpAdd:
+			apiid, stage, _ := strings.Cut(value, ":")
+
+			if err := f.checkApiStage(apiid, stage); err != nil {
+				return nil, fakeError("API Gateway", "UpdateUsagePlan", err)
+			}
+
+			p.ApiStages = append(p.ApiStages, types.ApiStage{ApiId: aws.String(apiid), Stage: aws.String(stage)})
+		case path == "/apiStages" && op.Op == types.OpRemove:
+			stages := []types.ApiStage{}
+
+			for _, stage := range p.ApiStages {
+				if fmt.Sprintf("%s:%s", aws.ToString(stage.ApiId), aws.ToString(stage.Stage)) != value {
+					stages = append(stages, stage)
+				}
+			}
+
+			p.ApiStages = stages
+		case parts[0] == "apiStages" && len(parts) >= 5 && parts[2] == "throttle":
+			i := slices.IndexFunc(p.ApiStages, func(stage types.ApiStage) bool {
+				return fmt.Sprintf("%s:%s", aws.ToString(stage.ApiId), aws.ToString(stage.Stage)) == parts[1]
+			})
+
+			if i < 0 {
+				return nil, fakeError("API Gateway", "UpdateUsagePlan", apiNotFound("Invalid API stage %s", parts[1]))
+			}
+
+			stage := &p.ApiStages[i]
+			key := usagePlanThrottleKey(parts[3], parts[4])
+
+			if op.Op == types.OpRemove && len(parts) == 5 {
+				delete(stage.Throttle, key)
+				continue
+			}
+
+			if len(parts) != 6 {
+				return nil, badRequest(path)
+			}
+
+			if stage.Throttle == nil {
+				stage.Throttle = map[string]types.ThrottleSettings{}
+			}
+
+			throttle := stage.Throttle[key]
+
+			if err := patchThrottle(&throttle, parts[5], value); err != nil {
+				return nil, badRequest(path)
+			}
+
+			stage.Throttle[key] = throttle
+		default:
+			return nil, badRequest(path)
+		}
+	}
+
+	return &api.UpdateUsagePlanOutput{
+		Id:          p.Id,
+		Name:        p.Name,
+		Description: p.Description,
+		ApiStages:   p.ApiStages,
+		Throttle:    p.Throttle,
+		Quota:       p.Quota,
+	}, nil
+}
+
+func (f *FakeAPIGateway) CreateUsagePlanKey(ctx context.Context, params *api.CreateUsagePlanKeyInput, optFns ...func(*api.Options)) (*api.CreateUsagePlanKeyOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys, ok := f.UsagePlanKeys[aws.ToString(params.UsagePlanId)]
+
+	if !ok {
+		return nil, fakeError("API Gateway", "CreateUsagePlanKey", apiNotFound("Invalid Usage Plan ID specified"))
+	}
+
+	k, ok := f.ApiKeys[aws.ToString(params.KeyId)]
+
+	if !ok {
+		return nil, fakeError("API Gateway", "CreateUsagePlanKey", apiNotFound("Invalid API Key identifier specified"))
+	}
+
+	if keys[aws.ToString(k.Id)] {
+		return nil, fakeError("API Gateway", "CreateUsagePlanKey", apiConflict("Usage Plan %s cannot be added because API Key %s cannot reference another Usage Plan with the same API Stage", aws.ToString(params.UsagePlanId), aws.ToString(k.Id)))
+	}
+
+	keys[aws.ToString(k.Id)] = true
+
+	return &api.CreateUsagePlanKeyOutput{
+		Id:    k.Id,
+		Name:  k.Name,
+		Type:  params.KeyType,
+		Value: k.Value,
+	}, nil
+}
+
+// GetUsage returns what is in Usage for the plan, ignoring the dates asked for.
+func (f *FakeAPIGateway) GetUsage(ctx context.Context, params *api.GetUsageInput, optFns ...func(*api.Options)) (*api.GetUsageOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.UsagePlans[aws.ToString(params.UsagePlanId)]; !ok {
+		return nil, fakeError("API Gateway", "GetUsage", apiNotFound("Invalid Usage Plan ID specified"))
+	}
+
+	out := &api.GetUsageOutput{
+		UsagePlanId: params.UsagePlanId,
+		StartDate:   params.StartDate,
+		EndDate:     params.EndDate,
+		Items:       map[string][][]int64{},
+	}
+
+	for keyid, days := range f.Usage[aws.ToString(params.UsagePlanId)] {
+		if params.KeyId == nil || aws.ToString(params.KeyId) == keyid {
+			out.Items[keyid] = days
+		}
+	}
+
+	return out, nil
+}
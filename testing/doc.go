@@ -0,0 +1,69 @@
+// Package ie2testing provides in-memory fakes of the AWS clients used by ie2utilities
+// so deployment logic can be exercised offline:
+//
+//	svc := &ie2utilities.AWSService{
+//		Region:     "us-east-1",
+//		Lambda:     ie2testing.NewFakeLambda(),
+//		APIGateway: ie2testing.NewFakeAPIGateway(),
+//		STS:        ie2testing.NewFakeSTS(),
+//	}
+//
+// The fakes keep just enough state to behave like the real services for the calls
+// this module makes, and return the same typed SDK errors (e.g. NotFoundException)
+// for missing or conflicting items.
+package ie2testing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+const FAKE_ACCOUNT_ID = "123456789012"
+const FAKE_REGION = "us-east-1"
+
+// fakeIds hands out predictable ids, e.g. fake000001
+type fakeIds struct {
+	next int
+}
+
+func (f *fakeIds) id() string {
+	f.next++
+	return fmt.Sprintf("fake%06d", f.next)
+}
+
+// fakeError wraps err the same way the SDK wraps service errors, so callers see
+// the same error text and errors.As behaviour as they would against AWS.
+func fakeError(service string, operation string, err error) error {
+
+	status := http.StatusBadRequest
+
+	var apiErr smithy.APIError
+
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NotFoundException", "ResourceNotFoundException", "NoSuchKey", "NotFound":
+			status = http.StatusNotFound
+		case "ConflictException", "ResourceConflictException":
+			status = http.StatusConflict
+		case "PreconditionFailedException":
+			status = http.StatusPreconditionFailed
+		}
+	}
+
+	return &smithy.OperationError{
+		ServiceID:     service,
+		OperationName: operation,
+		Err: &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+				Err:      err,
+			},
+			RequestID: "fake-request-id",
+		},
+	}
+}
@@ -0,0 +1,793 @@
+package ie2testing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	ie2utilities "github.com/insightengine2/ie2-utilities/utils"
+)
+
+var _ ie2utilities.LambdaAPI = (*FakeLambda)(nil)
+
+// FakeLambda keeps function configurations and permissions in memory.
+// Functions are Active with a Successful last update as soon as they are created or updated,
+// unless PendingPolls is set, in which case they report Pending (or InProgress for updates)
+// to that many GetFunction calls first and reject further updates until then, like the real service.
+// When S3 is set, code from S3 is hashed from the stored object like the real service does.
+// Permissions are keyed by function name, or name:alias for permissions on an alias.
+type FakeLambda struct {
+	mu           sync.Mutex
+	S3           *FakeS3
+	PendingPolls int
+	Functions    map[string]*types.FunctionConfiguration
+	Permissions  map[string]map[string]lambda.AddPermissionInput
+	Tags         map[string]map[string]string
+	Versions     map[string][]*types.FunctionConfiguration
+	Aliases      map[string]map[string]*types.AliasConfiguration
+	pending      map[string]int
+	revisions    fakeIds
+}
+
+func NewFakeLambda() *FakeLambda {
+
+	return &FakeLambda{
+		Functions:   map[string]*types.FunctionConfiguration{},
+		Permissions: map[string]map[string]lambda.AddPermissionInput{},
+		Tags:        map[string]map[string]string{},
+		Versions:    map[string][]*types.FunctionConfiguration{},
+		Aliases:     map[string]map[string]*types.AliasConfiguration{},
+		pending:     map[string]int{},
+	}
+}
+
+func fakeFunctionArn(name string) string {
+	return fmt.Sprintf("arn:aws:lambda:%s:%s:function:%s", FAKE_REGION, FAKE_ACCOUNT_ID, name)
+}
+
+func notFound(name string) error {
+	return &types.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("Function not found: %s", fakeFunctionArn(name)))}
+}
+
+// codeSha256 hashes the zip, the S3 object holding it when S3 is set, or the image uri.
+func (f *FakeLambda) codeSha256(bucket *string, key *string, zip []byte, image *string) string {
+
+	data := zip
+
+	if image != nil {
+		data = []byte(aws.ToString(image))
+	}
+
+	if data == nil {
+
+		data = []byte(fmt.Sprintf("s3://%s/%s", aws.ToString(bucket), aws.ToString(key)))
+
+		if f.S3 != nil {
+
+			f.S3.mu.Lock()
+			obj, err := f.S3.object(aws.ToString(bucket), aws.ToString(key))
+			f.S3.mu.Unlock()
+
+			if err == nil {
+				data = obj.Body
+			}
+		}
+	}
+
+	sum := sha256.Sum256(data)
+
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// startUpdate marks a function as changing for PendingPolls calls to GetFunction.
+func (f *FakeLambda) startUpdate(fn *types.FunctionConfiguration, creating bool) {
+
+	if f.PendingPolls <= 0 {
+		return
+	}
+
+	if f.pending == nil {
+		f.pending = map[string]int{}
+	}
+
+	f.pending[aws.ToString(fn.FunctionName)] = f.PendingPolls
+
+	if creating {
+		fn.State = types.StatePending
+		fn.LastUpdateStatus = ""
+	} else {
+		fn.LastUpdateStatus = types.LastUpdateStatusInProgress
+	}
+}
+
+// poll counts down a pending update and settles the function once it reaches zero.
+func (f *FakeLambda) poll(fn *types.FunctionConfiguration) {
+
+	name := aws.ToString(fn.FunctionName)
+
+	if f.pending[name] <= 0 {
+		return
+	}
+
+	f.pending[name]--
+
+	if f.pending[name] <= 0 {
+		fn.State = types.StateActive
+		fn.LastUpdateStatus = types.LastUpdateStatusSuccessful
+	}
+}
+
+// busy returns the error the real service gives when a function is updated mid update.
+func (f *FakeLambda) busy(name string) error {
+
+	if f.pending[name] > 0 {
+		return &types.ResourceConflictException{Message: aws.String(fmt.Sprintf("The operation cannot be performed at this time. An update is in progress for resource: %s", fakeFunctionArn(name)))}
+	}
+
+	return nil
+}
+
+// applySettings copies the optional settings shared by CreateFunction and UpdateFunctionConfiguration onto fn.
+func applySettings(
+	fn *types.FunctionConfiguration,
+	memory *int32,
+	timeout *int32,
+	env *types.Environment,
+	vpc *types.VpcConfig,
+	layers []string,
+	storage *types.EphemeralStorage,
+	tracing *types.TracingConfig,
+	dlq *types.DeadLetterConfig) {
+
+	if memory != nil {
+		fn.MemorySize = memory
+	}
+
+	if timeout != nil {
+		fn.Timeout = timeout
+	}
+
+	if env != nil {
+		fn.Environment = &types.EnvironmentResponse{Variables: maps.Clone(env.Variables)}
+	}
+
+	if vpc != nil {
+		fn.VpcConfig = &types.VpcConfigResponse{SubnetIds: vpc.SubnetIds, SecurityGroupIds: vpc.SecurityGroupIds}
+	}
+
+	if layers != nil {
+
+		fn.Layers = []types.Layer{}
+
+		for _, arn := range layers {
+			fn.Layers = append(fn.Layers, types.Layer{Arn: aws.String(arn)})
+		}
+	}
+
+	if storage != nil {
+		fn.EphemeralStorage = storage
+	}
+
+	if tracing != nil {
+		fn.TracingConfig = &types.TracingConfigResponse{Mode: tracing.Mode}
+	}
+
+	if dlq != nil {
+		fn.DeadLetterConfig = dlq
+	}
+}
+
+// functionName accepts a function name or arn, like the real service.
+func functionName(nameOrArn string) string {
+
+	if i := strings.LastIndex(nameOrArn, ":function:"); i >= 0 {
+		return nameOrArn[i+len(":function:"):]
+	}
+
+	return nameOrArn
+}
+
+func (f *FakeLambda) function(name string) (*types.FunctionConfiguration, error) {
+
+	fn, ok := f.Functions[name]
+
+	if !ok {
+		return nil, notFound(name)
+	}
+
+	return fn, nil
+}
+
+func (f *FakeLambda) GetFunction(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fn, err := f.function(aws.ToString(params.FunctionName))
+
+	if err != nil {
+		return nil, fakeError("Lambda", "GetFunction", err)
+	}
+
+	cfg := *fn
+	f.poll(fn)
+
+	return &lambda.GetFunctionOutput{Configuration: &cfg, Tags: maps.Clone(f.Tags[aws.ToString(fn.FunctionName)])}, nil
+}
+
+func (f *FakeLambda) CreateFunction(ctx context.Context, params *lambda.CreateFunctionInput, optFns ...func(*lambda.Options)) (*lambda.CreateFunctionOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+
+	if _, ok := f.Functions[name]; ok {
+		return nil, fakeError("Lambda", "CreateFunction", &types.ResourceConflictException{Message: aws.String(fmt.Sprintf("Function already exist: %s", name))})
+	}
+
+	// unset settings get the same defaults as the real service
+	fn := &types.FunctionConfiguration{
+		Architectures:    params.Architectures,
+		EphemeralStorage: &types.EphemeralStorage{Size: aws.Int32(512)},
+		FunctionArn:      aws.String(fakeFunctionArn(name)),
+		FunctionName:     aws.String(name),
+		Handler:          params.Handler,
+		LastUpdateStatus: types.LastUpdateStatusSuccessful,
+		MemorySize:       aws.Int32(128),
+		Role:             params.Role,
+		Runtime:          params.Runtime,
+		State:            types.StateActive,
+		Timeout:          aws.Int32(3),
+		TracingConfig:    &types.TracingConfigResponse{Mode: types.TracingModePassThrough},
+		Version:          aws.String("$LATEST"),
+	}
+
+	applySettings(fn, params.MemorySize, params.Timeout, params.Environment, params.VpcConfig, params.Layers, params.EphemeralStorage, params.TracingConfig, params.DeadLetterConfig)
+
+	if params.Tags != nil {
+		f.Tags[name] = maps.Clone(params.Tags)
+	}
+
+	if params.Code != nil {
+		fn.CodeSha256 = aws.String(f.codeSha256(params.Code.S3Bucket, params.Code.S3Key, params.Code.ZipFile, params.Code.ImageUri))
+		fn.PackageType = params.PackageType
+	}
+
+	f.startUpdate(fn, true)
+	f.Functions[name] = fn
+	cfg := *fn
+
+	return &lambda.CreateFunctionOutput{
+		Architectures:    cfg.Architectures,
+		CodeSha256:       cfg.CodeSha256,
+		FunctionArn:      cfg.FunctionArn,
+		FunctionName:     cfg.FunctionName,
+		Handler:          cfg.Handler,
+		LastUpdateStatus: cfg.LastUpdateStatus,
+		Role:             cfg.Role,
+		Runtime:          cfg.Runtime,
+		State:            cfg.State,
+		Version:          cfg.Version,
+	}, nil
+}
+
+func (f *FakeLambda) UpdateFunctionCode(ctx context.Context, params *lambda.UpdateFunctionCodeInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionCodeOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fn, err := f.function(aws.ToString(params.FunctionName))
+
+	if err != nil {
+		return nil, fakeError("Lambda", "UpdateFunctionCode", err)
+	}
+
+	if err := f.busy(aws.ToString(params.FunctionName)); err != nil {
+		return nil, fakeError("Lambda", "UpdateFunctionCode", err)
+	}
+
+	sha := f.codeSha256(params.S3Bucket, params.S3Key, params.ZipFile, params.ImageUri)
+
+	if !params.DryRun {
+
+		fn.CodeSha256 = aws.String(sha)
+		fn.LastUpdateStatus = types.LastUpdateStatusSuccessful
+
+		if len(params.Architectures) > 0 {
+			fn.Architectures = params.Architectures
+		}
+
+		f.startUpdate(fn, false)
+	}
+
+	return &lambda.UpdateFunctionCodeOutput{
+		CodeSha256:       aws.String(sha),
+		FunctionArn:      fn.FunctionArn,
+		FunctionName:     fn.FunctionName,
+		LastUpdateStatus: fn.LastUpdateStatus,
+		State:            fn.State,
+	}, nil
+}
+
+func (f *FakeLambda) UpdateFunctionConfiguration(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fn, err := f.function(aws.ToString(params.FunctionName))
+
+	if err != nil {
+		return nil, fakeError("Lambda", "UpdateFunctionConfiguration", err)
+	}
+
+	if err := f.busy(aws.ToString(params.FunctionName)); err != nil {
+		return nil, fakeError("Lambda", "UpdateFunctionConfiguration", err)
+	}
+
+	if params.Handler != nil {
+		fn.Handler = params.Handler
+	}
+
+	if params.Role != nil {
+		fn.Role = params.Role
+	}
+
+	if len(params.Runtime) > 0 {
+		fn.Runtime = params.Runtime
+	}
+
+	applySettings(fn, params.MemorySize, params.Timeout, params.Environment, params.VpcConfig, params.Layers, params.EphemeralStorage, params.TracingConfig, params.DeadLetterConfig)
+
+	fn.LastUpdateStatus = types.LastUpdateStatusSuccessful
+	f.startUpdate(fn, false)
+
+	return &lambda.UpdateFunctionConfigurationOutput{
+		FunctionArn:      fn.FunctionArn,
+		FunctionName:     fn.FunctionName,
+		Handler:          fn.Handler,
+		LastUpdateStatus: fn.LastUpdateStatus,
+		Role:             fn.Role,
+		Runtime:          fn.Runtime,
+		State:            fn.State,
+	}, nil
+}
+
+func (f *FakeLambda) DeleteFunction(ctx context.Context, params *lambda.DeleteFunctionInput, optFns ...func(*lambda.Options)) (*lambda.DeleteFunctionOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+
+	if _, err := f.function(name); err != nil {
+		return nil, fakeError("Lambda", "DeleteFunction", err)
+	}
+
+	delete(f.Functions, name)
+	delete(f.Permissions, name)
+	delete(f.Tags, name)
+	delete(f.Versions, name)
+	delete(f.Aliases, name)
+
+	return &lambda.DeleteFunctionOutput{}, nil
+}
+
+func (f *FakeLambda) AddPermission(ctx context.Context, params *lambda.AddPermissionInput, optFns ...func(*lambda.Options)) (*lambda.AddPermissionOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+
+	if _, err := f.function(name); err != nil {
+		return nil, fakeError("Lambda", "AddPermission", err)
+	}
+
+	sid := aws.ToString(params.StatementId)
+
+	if params.Qualifier != nil {
+
+		if _, err := f.alias(name, aws.ToString(params.Qualifier)); err != nil {
+			return nil, fakeError("Lambda", "AddPermission", err)
+		}
+
+		name += ":" + aws.ToString(params.Qualifier)
+	}
+
+	if _, ok := f.Permissions[name][sid]; ok {
+		return nil, fakeError("Lambda", "AddPermission", &types.ResourceConflictException{Message: aws.String(fmt.Sprintf("The statement id (%s) provided already exists.", sid))})
+	}
+
+	if _, ok := f.Permissions[name]; !ok {
+		f.Permissions[name] = map[string]lambda.AddPermissionInput{}
+	}
+
+	f.Permissions[name][sid] = *params
+
+	return &lambda.AddPermissionOutput{
+		Statement: aws.String(fmt.Sprintf(`{"Sid":"%s"}`, sid)),
+	}, nil
+}
+
+// GetPolicy builds the resource policy from the permissions added, like the real service
+// it fails with ResourceNotFoundException when there are none.
+func (f *FakeLambda) GetPolicy(ctx context.Context, params *lambda.GetPolicyInput, optFns ...func(*lambda.Options)) (*lambda.GetPolicyOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+
+	if _, err := f.function(name); err != nil {
+		return nil, fakeError("Lambda", "GetPolicy", err)
+	}
+
+	resource := fakeFunctionArn(name)
+
+	if params.Qualifier != nil {
+		name += ":" + aws.ToString(params.Qualifier)
+		resource += ":" + aws.ToString(params.Qualifier)
+	}
+
+	if len(f.Permissions[name]) <= 0 {
+		return nil, fakeError("Lambda", "GetPolicy", &types.ResourceNotFoundException{Message: aws.String("The resource you requested does not exist.")})
+	}
+
+	statements := []map[string]any{}
+	sids := []string{}
+
+	for sid := range f.Permissions[name] {
+		sids = append(sids, sid)
+	}
+
+	sort.Strings(sids)
+
+	for _, sid := range sids {
+
+		p := f.Permissions[name][sid]
+		statement := map[string]any{
+			"Sid":       sid,
+			"Effect":    "Allow",
+			"Principal": map[string]string{"Service": aws.ToString(p.Principal)},
+			"Action":    aws.ToString(p.Action),
+			"Resource":  resource,
+		}
+
+		if p.SourceArn != nil {
+			statement["Condition"] = map[string]any{"ArnLike": map[string]string{"AWS:SourceArn": aws.ToString(p.SourceArn)}}
+		}
+
+		statements = append(statements, statement)
+	}
+
+	policy, err := json.Marshal(map[string]any{"Version": "2012-10-17", "Id": "default", "Statement": statements})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &lambda.GetPolicyOutput{
+		Policy: aws.String(string(policy)),
+	}, nil
+}
+
+func (f *FakeLambda) RemovePermission(ctx context.Context, params *lambda.RemovePermissionInput, optFns ...func(*lambda.Options)) (*lambda.RemovePermissionOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+
+	if _, err := f.function(name); err != nil {
+		return nil, fakeError("Lambda", "RemovePermission", err)
+	}
+
+	if params.Qualifier != nil {
+		name += ":" + aws.ToString(params.Qualifier)
+	}
+
+	sid := aws.ToString(params.StatementId)
+
+	if _, ok := f.Permissions[name][sid]; !ok {
+		return nil, fakeError("Lambda", "RemovePermission", &types.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("Statement %s is not found in resource policy.", sid))})
+	}
+
+	delete(f.Permissions[name], sid)
+
+	return &lambda.RemovePermissionOutput{}, nil
+}
+
+func (f *FakeLambda) TagResource(ctx context.Context, params *lambda.TagResourceInput, optFns ...func(*lambda.Options)) (*lambda.TagResourceOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := functionName(aws.ToString(params.Resource))
+
+	if _, err := f.function(name); err != nil {
+		return nil, fakeError("Lambda", "TagResource", err)
+	}
+
+	if _, ok := f.Tags[name]; !ok {
+		f.Tags[name] = map[string]string{}
+	}
+
+	for k, v := range params.Tags {
+		f.Tags[name][k] = v
+	}
+
+	return &lambda.TagResourceOutput{}, nil
+}
+
+func (f *FakeLambda) UntagResource(ctx context.Context, params *lambda.UntagResourceInput, optFns ...func(*lambda.Options)) (*lambda.UntagResourceOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := functionName(aws.ToString(params.Resource))
+
+	if _, err := f.function(name); err != nil {
+		return nil, fakeError("Lambda", "UntagResource", err)
+	}
+
+	for _, k := range params.TagKeys {
+		delete(f.Tags[name], k)
+	}
+
+	return &lambda.UntagResourceOutput{}, nil
+}
+
+func (f *FakeLambda) alias(name string, alias string) (*types.AliasConfiguration, error) {
+
+	a, ok := f.Aliases[name][alias]
+
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("Alias not found: %s:%s", fakeFunctionArn(name), alias))}
+	}
+
+	return a, nil
+}
+
+// version checks a version exists, $LATEST always does.
+func (f *FakeLambda) version(name string, version string) error {
+
+	if version == "$LATEST" {
+		return nil
+	}
+
+	for _, v := range f.Versions[name] {
+		if aws.ToString(v.Version) == version {
+			return nil
+		}
+	}
+
+	return &types.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("Function not found: %s:%s", fakeFunctionArn(name), version))}
+}
+
+// sameVersion is true when fn has the code and the configuration of version v.
+func sameVersion(v *types.FunctionConfiguration, fn *types.FunctionConfiguration) bool {
+
+	env := func(c *types.FunctionConfiguration) map[string]string {
+
+		if c.Environment == nil {
+			return nil
+		}
+
+		return c.Environment.Variables
+	}
+
+	return aws.ToString(v.CodeSha256) == aws.ToString(fn.CodeSha256) &&
+		aws.ToString(v.Handler) == aws.ToString(fn.Handler) &&
+		v.Runtime == fn.Runtime &&
+		aws.ToString(v.Role) == aws.ToString(fn.Role) &&
+		aws.ToInt32(v.MemorySize) == aws.ToInt32(fn.MemorySize) &&
+		aws.ToInt32(v.Timeout) == aws.ToInt32(fn.Timeout) &&
+		maps.Equal(env(v), env(fn))
+}
+
+// PublishVersion snapshots $LATEST. Like the real service it returns the last version
+// instead of publishing a new one when neither the code nor the configuration changed since.
+func (f *FakeLambda) PublishVersion(ctx context.Context, params *lambda.PublishVersionInput, optFns ...func(*lambda.Options)) (*lambda.PublishVersionOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+	fn, err := f.function(name)
+
+	if err != nil {
+		return nil, fakeError("Lambda", "PublishVersion", err)
+	}
+
+	if err := f.busy(name); err != nil {
+		return nil, fakeError("Lambda", "PublishVersion", err)
+	}
+
+	versions := f.Versions[name]
+	var v *types.FunctionConfiguration
+
+	if len(versions) > 0 && sameVersion(versions[len(versions)-1], fn) {
+
+		v = versions[len(versions)-1]
+
+	} else {
+
+		cfg := *fn
+		cfg.Version = aws.String(fmt.Sprint(len(versions) + 1))
+		cfg.FunctionArn = aws.String(fakeFunctionArn(name) + ":" + aws.ToString(cfg.Version))
+		cfg.Description = params.Description
+		v = &cfg
+		f.Versions[name] = append(versions, v)
+	}
+
+	return &lambda.PublishVersionOutput{
+		CodeSha256:   v.CodeSha256,
+		Description:  v.Description,
+		FunctionArn:  v.FunctionArn,
+		FunctionName: v.FunctionName,
+		State:        v.State,
+		Version:      v.Version,
+	}, nil
+}
+
+// ListVersionsByFunction returns $LATEST followed by every published version in one page.
+func (f *FakeLambda) ListVersionsByFunction(ctx context.Context, params *lambda.ListVersionsByFunctionInput, optFns ...func(*lambda.Options)) (*lambda.ListVersionsByFunctionOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+	fn, err := f.function(name)
+
+	if err != nil {
+		return nil, fakeError("Lambda", "ListVersionsByFunction", err)
+	}
+
+	ret := []types.FunctionConfiguration{*fn}
+
+	for _, v := range f.Versions[name] {
+		ret = append(ret, *v)
+	}
+
+	return &lambda.ListVersionsByFunctionOutput{Versions: ret}, nil
+}
+
+func (f *FakeLambda) GetAlias(ctx context.Context, params *lambda.GetAliasInput, optFns ...func(*lambda.Options)) (*lambda.GetAliasOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+
+	if _, err := f.function(name); err != nil {
+		return nil, fakeError("Lambda", "GetAlias", err)
+	}
+
+	a, err := f.alias(name, aws.ToString(params.Name))
+
+	if err != nil {
+		return nil, fakeError("Lambda", "GetAlias", err)
+	}
+
+	return &lambda.GetAliasOutput{
+		AliasArn:        a.AliasArn,
+		Description:     a.Description,
+		FunctionVersion: a.FunctionVersion,
+		Name:            a.Name,
+		RevisionId:      a.RevisionId,
+		RoutingConfig:   a.RoutingConfig,
+	}, nil
+}
+
+func (f *FakeLambda) CreateAlias(ctx context.Context, params *lambda.CreateAliasInput, optFns ...func(*lambda.Options)) (*lambda.CreateAliasOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+	alias := aws.ToString(params.Name)
+
+	if _, err := f.function(name); err != nil {
+		return nil, fakeError("Lambda", "CreateAlias", err)
+	}
+
+	if _, ok := f.Aliases[name][alias]; ok {
+		return nil, fakeError("Lambda", "CreateAlias", &types.ResourceConflictException{Message: aws.String(fmt.Sprintf("Alias already exists: %s:%s", fakeFunctionArn(name), alias))})
+	}
+
+	if err := f.version(name, aws.ToString(params.FunctionVersion)); err != nil {
+		return nil, fakeError("Lambda", "CreateAlias", err)
+	}
+
+	a := &types.AliasConfiguration{
+		AliasArn:        aws.String(fakeFunctionArn(name) + ":" + alias),
+		Description:     params.Description,
+		FunctionVersion: params.FunctionVersion,
+		Name:            aws.String(alias),
+		RevisionId:      aws.String(f.revisions.id()),
+		RoutingConfig:   params.RoutingConfig,
+	}
+
+	if _, ok := f.Aliases[name]; !ok {
+		f.Aliases[name] = map[string]*types.AliasConfiguration{}
+	}
+
+	f.Aliases[name][alias] = a
+
+	return &lambda.CreateAliasOutput{
+		AliasArn:        a.AliasArn,
+		Description:     a.Description,
+		FunctionVersion: a.FunctionVersion,
+		Name:            a.Name,
+		RevisionId:      a.RevisionId,
+		RoutingConfig:   a.RoutingConfig,
+	}, nil
+}
+
+// UpdateAlias rejects stale RevisionIds like the real service, and only changes what is set.
+func (f *FakeLambda) UpdateAlias(ctx context.Context, params *lambda.UpdateAliasInput, optFns ...func(*lambda.Options)) (*lambda.UpdateAliasOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := aws.ToString(params.FunctionName)
+
+	if _, err := f.function(name); err != nil {
+		return nil, fakeError("Lambda", "UpdateAlias", err)
+	}
+
+	a, err := f.alias(name, aws.ToString(params.Name))
+
+	if err != nil {
+		return nil, fakeError("Lambda", "UpdateAlias", err)
+	}
+
+	if params.RevisionId != nil && aws.ToString(params.RevisionId) != aws.ToString(a.RevisionId) {
+		return nil, fakeError("Lambda", "UpdateAlias", &types.PreconditionFailedException{Message: aws.String("The Revision Id provided does not match the latest Revision Id.")})
+	}
+
+	if params.FunctionVersion != nil {
+
+		if err := f.version(name, aws.ToString(params.FunctionVersion)); err != nil {
+			return nil, fakeError("Lambda", "UpdateAlias", err)
+		}
+
+		a.FunctionVersion = params.FunctionVersion
+	}
+
+	if params.Description != nil {
+		a.Description = params.Description
+	}
+
+	if params.RoutingConfig != nil {
+
+		for v := range params.RoutingConfig.AdditionalVersionWeights {
+			if err := f.version(name, v); err != nil {
+				return nil, fakeError("Lambda", "UpdateAlias", err)
+			}
+		}
+
+		a.RoutingConfig = params.RoutingConfig
+	}
+
+	a.RevisionId = aws.String(f.revisions.id())
+
+	return &lambda.UpdateAliasOutput{
+		AliasArn:        a.AliasArn,
+		Description:     a.Description,
+		FunctionVersion: a.FunctionVersion,
+		Name:            a.Name,
+		RevisionId:      a.RevisionId,
+		RoutingConfig:   a.RoutingConfig,
+	}, nil
+}
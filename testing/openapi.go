@@ -0,0 +1,394 @@
+package ie2testing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	api "github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+	"gopkg.in/yaml.v3"
+)
+
+func openAPIBadRequest(format string, args ...any) error {
+	return &types.BadRequestException{Message: aws.String(fmt.Sprintf(format, args...))}
+}
+
+// decodeOpenAPI reads a json or yaml OpenAPI 3 document.
+func decodeOpenAPI(body []byte) (*ie2datatypes.OpenAPIDocument, error) {
+
+	if !json.Valid(body) {
+
+		parsed := map[string]any{}
+
+		if err := yaml.Unmarshal(body, &parsed); err != nil {
+			return nil, openAPIBadRequest("Invalid OpenAPI input: %s", err)
+		}
+
+		converted, err := json.Marshal(parsed)
+
+		if err != nil {
+			return nil, openAPIBadRequest("Invalid OpenAPI input: %s", err)
+		}
+
+		body = converted
+	}
+
+	doc := &ie2datatypes.OpenAPIDocument{}
+
+	if err := json.Unmarshal(body, doc); err != nil {
+		return nil, openAPIBadRequest("Invalid OpenAPI input: %s", err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") || doc.Paths == nil {
+		return nil, openAPIBadRequest("Invalid OpenAPI input: only OpenAPI 3 documents with paths are supported")
+	}
+
+	return doc, nil
+}
+
+// openAPIModels turns the $refs of a body into the models of a method.
+func openAPIModels(a *FakeRestApi, content map[string]ie2datatypes.OpenAPIMediaType) (map[string]string, error) {
+
+	if len(content) <= 0 {
+		return nil, nil
+	}
+
+	ret := map[string]string{}
+
+	for contentType, media := range content {
+
+		name := strings.TrimPrefix(media.Schema["$ref"], "#/components/schemas/")
+
+		if _, ok := a.Models[name]; !ok {
+			return nil, openAPIBadRequest("Invalid model identifier specified: %s", name)
+		}
+
+		ret[contentType] = name
+	}
+
+	return ret, nil
+}
+
+// openAPIResource returns the resource at path, creating any segment that doesn't exist.
+func (f *FakeAPIGateway) openAPIResource(a *FakeRestApi, path string) *types.Resource {
+
+	byPath := map[string]*types.Resource{}
+
+	for _, r := range a.Resources {
+		byPath[aws.ToString(r.Path)] = r
+	}
+
+	parent := byPath["/"]
+	current := ""
+
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+
+		if len(part) <= 0 {
+			continue
+		}
+
+		current += "/" + part
+
+		if r, ok := byPath[current]; ok {
+			parent = r
+			continue
+		}
+
+		id := f.ids.id()
+		a.Resources[id] = &types.Resource{
+			Id:              aws.String(id),
+			ParentId:        parent.Id,
+			Path:            aws.String(current),
+			PathPart:        aws.String(part),
+			ResourceMethods: map[string]types.Method{},
+		}
+
+		parent = a.Resources[id]
+		byPath[current] = parent
+	}
+
+	return parent
+}
+
+// openAPIMethod builds the method an operation describes.
+func openAPIMethod(a *FakeRestApi, name string, op *ie2datatypes.OpenAPIOperation, validators map[string]string) (types.Method, error) {
+
+	m := types.Method{
+		HttpMethod:        aws.String(name),
+		AuthorizationType: aws.String("NONE"),
+		ApiKeyRequired:    aws.Bool(false),
+	}
+
+	schemes := map[string]*types.Authorizer{}
+
+	for _, au := range a.Authorizers {
+		schemes[aws.ToString(au.Name)] = au
+	}
+
+	for _, requirement := range op.Security {
+		for scheme, scopes := range requirement {
+
+			switch scheme {
+			case "api_key":
+				m.ApiKeyRequired = aws.Bool(true)
+			case "sigv4":
+				m.AuthorizationType = aws.String("AWS_IAM")
+			default:
+				au, ok := schemes[scheme]
+
+				if !ok {
+					return m, openAPIBadRequest("Unable to find security scheme %s", scheme)
+				}
+
+				m.AuthorizationType = aws.String("CUSTOM")
+
+				if au.Type == types.AuthorizerTypeCognitoUserPools {
+					m.AuthorizationType = aws.String("COGNITO_USER_POOLS")
+				}
+
+				m.AuthorizerId = au.Id
+
+				if len(scopes) > 0 {
+					m.AuthorizationScopes = scopes
+				}
+			}
+		}
+	}
+
+	if op.RequestBody != nil {
+
+		models, err := openAPIModels(a, op.RequestBody.Content)
+
+		if err != nil {
+			return m, err
+		}
+
+		m.RequestModels = models
+	}
+
+	if len(op.RequestValidator) > 0 {
+
+		id, ok := validators[op.RequestValidator]
+
+		if !ok {
+			return m, openAPIBadRequest("Invalid request validator %s", op.RequestValidator)
+		}
+
+		m.RequestValidatorId = aws.String(id)
+	}
+
+	m.MethodResponses = map[string]types.MethodResponse{}
+
+	for status, response := range op.Responses {
+
+		models, err := openAPIModels(a, response.Content)
+
+		if err != nil {
+			return m, err
+		}
+
+		declared := map[string]bool{}
+
+		for header := range response.Headers {
+			declared["method.response.header."+header] = false
+		}
+
+		m.MethodResponses[status] = types.MethodResponse{
+			StatusCode:         aws.String(status),
+			ResponseModels:     models,
+			ResponseParameters: declared,
+		}
+	}
+
+	if op.Integration == nil {
+		return m, nil
+	}
+
+	integration := &types.Integration{
+		Type:                 types.IntegrationType(strings.ToUpper(op.Integration.Type)),
+		HttpMethod:           aws.String(op.Integration.HttpMethod),
+		Uri:                  aws.String(op.Integration.Uri),
+		PassthroughBehavior:  aws.String(strings.ToUpper(op.Integration.PassthroughBehavior)),
+		RequestTemplates:     op.Integration.RequestTemplates,
+		IntegrationResponses: map[string]types.IntegrationResponse{},
+	}
+
+	for pattern, response := range op.Integration.Responses {
+
+		ir := types.IntegrationResponse{
+			StatusCode:         aws.String(response.StatusCode),
+			ResponseParameters: response.ResponseParameters,
+			ResponseTemplates:  response.ResponseTemplates,
+		}
+
+		if pattern != "default" {
+			ir.SelectionPattern = aws.String(pattern)
+		}
+
+		integration.IntegrationResponses[response.StatusCode] = ir
+	}
+
+	m.MethodIntegration = integration
+
+	return m, nil
+}
+
+// PutRestApi applies an OpenAPI 3 document like api gateway does, for the parts this module
+// generates: models, request validators, authorizers, gateway responses and the resources
+// and methods of every path. Overwrite starts again from an empty api, merge only replaces
+// the methods the document describes.
+func (f *FakeAPIGateway) PutRestApi(ctx context.Context, params *api.PutRestApiInput, optFns ...func(*api.Options)) (*api.PutRestApiOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	a, err := f.restApi(params.RestApiId)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "PutRestApi", err)
+	}
+
+	doc, err := decodeOpenAPI(params.Body)
+
+	if err != nil {
+		return nil, fakeError("API Gateway", "PutRestApi", err)
+	}
+
+	if params.Mode == types.PutModeOverwrite {
+
+		rootid := aws.ToString(a.Api.RootResourceId)
+		root := a.Resources[rootid]
+		root.ResourceMethods = map[string]types.Method{}
+
+		a.Resources = map[string]*types.Resource{rootid: root}
+		a.Authorizers = map[string]*types.Authorizer{}
+		a.Models = map[string]*types.Model{}
+		a.RequestValidators = map[string]*types.RequestValidator{}
+		a.GatewayResponses = map[types.GatewayResponseType]*types.GatewayResponse{}
+	}
+
+	for name, schema := range doc.Components.Schemas {
+
+		id := f.ids.id()
+
+		if existing, ok := a.Models[name]; ok {
+			id = aws.ToString(existing.Id)
+		}
+
+		a.Models[name] = &types.Model{
+			Id:          aws.String(id),
+			Name:        aws.String(name),
+			ContentType: aws.String("application/json"),
+			Schema:      aws.String(string(schema)),
+		}
+	}
+
+	validators := map[string]string{}
+
+	for _, v := range a.RequestValidators {
+		validators[aws.ToString(v.Name)] = aws.ToString(v.Id)
+	}
+
+	for name, v := range doc.RequestValidators {
+
+		id, ok := validators[name]
+
+		if !ok {
+			id = f.ids.id()
+			validators[name] = id
+		}
+
+		a.RequestValidators[id] = &types.RequestValidator{
+			Id:                        aws.String(id),
+			Name:                      aws.String(name),
+			ValidateRequestBody:       v.ValidateRequestBody,
+			ValidateRequestParameters: v.ValidateRequestParameters,
+		}
+	}
+
+	for name, scheme := range doc.Components.SecuritySchemes {
+
+		if scheme.Authorizer == nil {
+			continue
+		}
+
+		id := f.ids.id()
+
+		for _, existing := range a.Authorizers {
+			if aws.ToString(existing.Name) == name {
+				id = aws.ToString(existing.Id)
+			}
+		}
+
+		au := &types.Authorizer{
+			Id:             aws.String(id),
+			Name:           aws.String(name),
+			Type:           types.AuthorizerType(strings.ToUpper(scheme.Authorizer.Type)),
+			IdentitySource: aws.String(scheme.Authorizer.IdentitySource),
+			ProviderARNs:   scheme.Authorizer.ProviderARNs,
+		}
+
+		if len(scheme.Authorizer.AuthorizerUri) > 0 {
+			au.AuthorizerUri = aws.String(scheme.Authorizer.AuthorizerUri)
+		}
+
+		if len(scheme.Authorizer.AuthorizerCredentials) > 0 {
+			au.AuthorizerCredentials = aws.String(scheme.Authorizer.AuthorizerCredentials)
+		}
+
+		if scheme.Authorizer.AuthorizerResultTtlInSeconds > 0 {
+			au.AuthorizerResultTtlInSeconds = aws.Int32(scheme.Authorizer.AuthorizerResultTtlInSeconds)
+		}
+
+		a.Authorizers[id] = au
+	}
+
+	for name, response := range doc.GatewayResponses {
+		a.GatewayResponses[types.GatewayResponseType(name)] = &types.GatewayResponse{
+			ResponseType:       types.GatewayResponseType(name),
+			ResponseParameters: response.ResponseParameters,
+		}
+	}
+
+	// sorted so resources get the same ids every time
+	paths := []string{}
+
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+
+		r := f.openAPIResource(a, path)
+
+		for key, op := range doc.Paths[path] {
+
+			name := strings.ToUpper(key)
+
+			if key == "x-amazon-apigateway-any-method" {
+				name = "ANY"
+			}
+
+			m, err := openAPIMethod(a, name, op, validators)
+
+			if err != nil {
+				return nil, fakeError("API Gateway", "PutRestApi", err)
+			}
+
+			r.ResourceMethods[name] = m
+		}
+	}
+
+	return &api.PutRestApiOutput{
+		Id:             a.Api.Id,
+		Name:           a.Api.Name,
+		RootResourceId: a.Api.RootResourceId,
+	}, nil
+}
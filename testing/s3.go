@@ -0,0 +1,158 @@
+package ie2testing
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"maps"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	ie2utilities "github.com/insightengine2/ie2-utilities/utils"
+)
+
+var _ ie2utilities.S3API = (*FakeS3)(nil)
+
+type FakeS3Object struct {
+	Body           []byte
+	ContentType    string
+	Metadata       map[string]string
+	ChecksumSHA256 string
+}
+
+// FakeS3 stores objects in memory keyed by bucket and key.
+type FakeS3 struct {
+	mu      sync.Mutex
+	Objects map[string]map[string]FakeS3Object
+}
+
+func NewFakeS3() *FakeS3 {
+	return &FakeS3{Objects: map[string]map[string]FakeS3Object{}}
+}
+
+// Put stores an object, creating the bucket if needed.
+func (f *FakeS3) Put(bucket string, key string, body []byte, contentType string) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.Objects[bucket]; !ok {
+		f.Objects[bucket] = map[string]FakeS3Object{}
+	}
+
+	f.Objects[bucket][key] = FakeS3Object{Body: body, ContentType: contentType}
+}
+
+func (f *FakeS3) object(bucket string, key string) (FakeS3Object, error) {
+
+	obj, ok := f.Objects[bucket][key]
+
+	if !ok {
+		return obj, &types.NoSuchKey{Message: aws.String("The specified key does not exist.")}
+	}
+
+	return obj, nil
+}
+
+func (f *FakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, err := f.object(aws.ToString(params.Bucket), aws.ToString(params.Key))
+
+	if err != nil {
+		return nil, fakeError("S3", "GetObject", err)
+	}
+
+	out := &s3.GetObjectOutput{
+		Body:          io.NopCloser(bytes.NewReader(obj.Body)),
+		ContentLength: aws.Int64(int64(len(obj.Body))),
+		Metadata:      obj.Metadata,
+	}
+
+	if len(obj.ContentType) > 0 {
+		out.ContentType = aws.String(obj.ContentType)
+	}
+
+	return out, nil
+}
+
+// HeadObject returns the same NotFound error as the real service for missing keys,
+// which unlike GetObject's NoSuchKey has no message.
+func (f *FakeS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, err := f.object(aws.ToString(params.Bucket), aws.ToString(params.Key))
+
+	if err != nil {
+		return nil, fakeError("S3", "HeadObject", &types.NotFound{})
+	}
+
+	out := &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(obj.Body))),
+		Metadata:      obj.Metadata,
+	}
+
+	if len(obj.ContentType) > 0 {
+		out.ContentType = aws.String(obj.ContentType)
+	}
+
+	if params.ChecksumMode == types.ChecksumModeEnabled && len(obj.ChecksumSHA256) > 0 {
+		out.ChecksumSHA256 = aws.String(obj.ChecksumSHA256)
+	}
+
+	return out, nil
+}
+
+// PutObject stores the object, keeping its SHA-256 checksum when one was requested.
+func (f *FakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+
+	body := []byte{}
+
+	if params.Body != nil {
+
+		data, err := io.ReadAll(params.Body)
+
+		if err != nil {
+			return nil, err
+		}
+
+		body = data
+	}
+
+	obj := FakeS3Object{
+		Body:        body,
+		ContentType: aws.ToString(params.ContentType),
+		Metadata:    maps.Clone(params.Metadata),
+	}
+
+	if params.ChecksumAlgorithm == types.ChecksumAlgorithmSha256 || params.ChecksumSHA256 != nil {
+		sum := sha256.Sum256(body)
+		obj.ChecksumSHA256 = base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket := aws.ToString(params.Bucket)
+
+	if _, ok := f.Objects[bucket]; !ok {
+		f.Objects[bucket] = map[string]FakeS3Object{}
+	}
+
+	f.Objects[bucket][aws.ToString(params.Key)] = obj
+	out := &s3.PutObjectOutput{}
+
+	if len(obj.ChecksumSHA256) > 0 {
+		out.ChecksumSHA256 = aws.String(obj.ChecksumSHA256)
+	}
+
+	return out, nil
+}
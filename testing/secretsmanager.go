@@ -0,0 +1,67 @@
+package ie2testing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	ie2utilities "github.com/insightengine2/ie2-utilities/utils"
+)
+
+var _ ie2utilities.SecretsManagerAPI = (*FakeSecretsManager)(nil)
+
+// FakeSecretsManager stores secret strings by id and version stage
+// and counts GetSecretValue calls so caching can be checked.
+type FakeSecretsManager struct {
+	mu      sync.Mutex
+	Secrets map[string]map[string]string
+	Calls   int
+}
+
+func NewFakeSecretsManager() *FakeSecretsManager {
+	return &FakeSecretsManager{Secrets: map[string]map[string]string{}}
+}
+
+// Put sets the value of a secret at a version stage, e.g. AWSCURRENT.
+func (f *FakeSecretsManager) Put(secretId string, versionStage string, value string) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.Secrets[secretId]; !ok {
+		f.Secrets[secretId] = map[string]string{}
+	}
+
+	f.Secrets[secretId][versionStage] = value
+}
+
+func (f *FakeSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Calls++
+
+	id := aws.ToString(params.SecretId)
+	stage := aws.ToString(params.VersionStage)
+
+	if len(stage) <= 0 {
+		stage = "AWSCURRENT"
+	}
+
+	val, ok := f.Secrets[id][stage]
+
+	if !ok {
+		return nil, fakeError("Secrets Manager", "GetSecretValue", &types.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("Secrets Manager can't find the specified secret %s", id))})
+	}
+
+	return &secretsmanager.GetSecretValueOutput{
+		ARN:           aws.String(fmt.Sprintf("arn:aws:secretsmanager:%s:%s:secret:%s", FAKE_REGION, FAKE_ACCOUNT_ID, id)),
+		Name:          aws.String(id),
+		SecretString:  aws.String(val),
+		VersionStages: []string{stage},
+	}, nil
+}
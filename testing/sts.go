@@ -0,0 +1,29 @@
+package ie2testing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ie2utilities "github.com/insightengine2/ie2-utilities/utils"
+)
+
+var _ ie2utilities.STSAPI = (*FakeSTS)(nil)
+
+type FakeSTS struct {
+	Account string
+}
+
+func NewFakeSTS() *FakeSTS {
+	return &FakeSTS{Account: FAKE_ACCOUNT_ID}
+}
+
+func (f *FakeSTS) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+
+	return &sts.GetCallerIdentityOutput{
+		Account: aws.String(f.Account),
+		Arn:     aws.String(fmt.Sprintf("arn:aws:iam::%s:user/fake", f.Account)),
+		UserId:  aws.String("FAKEUSERID"),
+	}, nil
+}
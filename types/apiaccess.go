@@ -0,0 +1,77 @@
+package ie2datatypes
+
+// UsagePlanThrottle limits requests to Rate per second with bursts of up to Burst.
+type UsagePlanThrottle struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int32   `yaml:"burst"`
+}
+
+// UsagePlanQuota caps the requests each key can make per Period, which is DAY, WEEK or MONTH.
+type UsagePlanQuota struct {
+	Limit  int32  `yaml:"limit"`
+	Period string `yaml:"period"`
+	Offset int32  `yaml:"offset"`
+}
+
+// UsagePlanStage is a stage of a REST api a usage plan applies to, found by ApiId or ApiName.
+// Throttle limits single methods more tightly than the plan, keyed by path and
+// method, e.g. /v1/papers/GET.
+type UsagePlanStage struct {
+	ApiId    string                       `yaml:"apiid"`
+	ApiName  string                       `yaml:"api"`
+	Stage    string                       `yaml:"stage"`
+	Throttle map[string]UsagePlanThrottle `yaml:"throttle"`
+}
+
+// UsagePlanInput is a usage plan, matched by Name when it is updated.
+// A nil Throttle or Quota leaves the plan without that limit.
+type UsagePlanInput struct {
+	Name        string             `yaml:"name"`
+	Description string             `yaml:"description"`
+	Throttle    *UsagePlanThrottle `yaml:"throttle"`
+	Quota       *UsagePlanQuota    `yaml:"quota"`
+	Stages      []UsagePlanStage   `yaml:"stages"`
+}
+
+// ApiKeyConfig is the api key of one partner and the usage plans it is in.
+type ApiKeyConfig struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	UsagePlans  []string `yaml:"usageplans"`
+}
+
+// ApiAccessConfig declares the usage plans of our apis and the partners that get a key for them.
+type ApiAccessConfig struct {
+	UsagePlans []UsagePlanInput `yaml:"usageplans"`
+	Partners   []ApiKeyConfig   `yaml:"partners"`
+}
+
+// ApiKey is an api key. Created is set when the key was made by the call that returned it,
+// so its value can be handed to the partner.
+type ApiKey struct {
+	Id      string
+	Name    string
+	Value   string
+	Enabled bool
+	Created bool
+}
+
+// ApiAccessResult lists the usage plan ids by name and the key of each partner.
+type ApiAccessResult struct {
+	UsagePlans map[string]string
+	Keys       []ApiKey
+}
+
+// ApiKeyDailyUsage is the requests a key made on one day, and what was left of its quota.
+type ApiKeyDailyUsage struct {
+	Date      string
+	Used      int64
+	Remaining int64
+}
+
+// ApiKeyUsage is the usage of one key in one usage plan, a day per entry of Days in date order.
+type ApiKeyUsage struct {
+	KeyId       string
+	UsagePlanId string
+	Days        []ApiKeyDailyUsage
+}
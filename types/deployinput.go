@@ -0,0 +1,48 @@
+package ie2datatypes
+
+// DeployOptions supplies everything AWSDeploy needs that LambdaConfig doesn't carry.
+// Region and AccountId are looked up from the aws.Config and STS when empty,
+// and RoleARN is built from LambdaConfig.RoleName when empty.
+// ZipPath or ZipDir deploy a local build instead of LambdaConfig.Filename,
+// staged to S3Bucket when it is set. Force updates the code even when it matches
+// the deployed CodeSha256. The Req and Res schema files of endpoint methods are
+// read from SchemaDir unless they are s3://bucket/key urls. DryRun plans the deploy
+// instead of running it, see AWSService.PlanDeploy.
+type DeployOptions struct {
+	AccountId string
+	Region    string
+	RoleARN   string
+	S3Bucket  string
+	S3Key     string
+	ZipPath   string
+	ZipDir    string
+	ApiId     string
+	ApiName   string
+	Stage     string
+	SchemaDir string
+	Publish   bool
+	DryRun    bool
+	Force     bool
+}
+
+const (
+	DeployActionCreate    = "create"
+	DeployActionUpdate    = "update"
+	DeployActionExists    = "exists"
+	DeployActionDeploy    = "deploy"
+	DeployActionPublish   = "publish"
+	DeployActionUnchanged = "unchanged"
+)
+
+type DeployStep struct {
+	Name   string
+	Action string
+	Target string
+	Err    error
+}
+
+// DeployResult records the steps a deploy took, or the plan of a dry run.
+type DeployResult struct {
+	Steps []DeployStep
+	Plan  *DeployPlan
+}
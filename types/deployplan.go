@@ -0,0 +1,36 @@
+package ie2datatypes
+
+const (
+	PlanActionAdd    = "add"
+	PlanActionChange = "change"
+	PlanActionRemove = "remove"
+	// PlanActionUnknown is a change that can't be decided without deploying,
+	// e.g. S3 code without a recorded CodeSha256.
+	PlanActionUnknown = "unknown"
+)
+
+const (
+	PlanKindLambda      = "lambda"
+	PlanKindAlias       = "alias"
+	PlanKindResource    = "resource"
+	PlanKindMethod      = "method"
+	PlanKindIntegration = "integration"
+	PlanKindStage       = "stage"
+	PlanKindPermission  = "permission"
+	PlanKindModel       = "model"
+)
+
+// PlanChange is a single difference between what is deployed and what the config asks for.
+// Field, Current and Desired are only set for changes to an existing item.
+type PlanChange struct {
+	Action  string
+	Kind    string
+	Target  string
+	Field   string
+	Current string
+	Desired string
+}
+
+type DeployPlan struct {
+	Changes []PlanChange
+}
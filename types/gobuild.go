@@ -0,0 +1,15 @@
+package ie2datatypes
+
+// GoBuildParams describes a Go main package to build into a lambda bootstrap binary.
+// Dir is the directory go build runs in (usually the module root) and Package
+// the main package relative to it, e.g. ./cmd/papers. Architecture uses the lambda
+// names, arm64 or x86_64, and defaults to x86_64 like lambda does.
+// Files maps extra zip entry names to local files to package next to bootstrap.
+type GoBuildParams struct {
+	Dir          string
+	Package      string
+	Architecture string
+	Tags         []string
+	Env          []string
+	Files        map[string]string
+}
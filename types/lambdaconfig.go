@@ -1,19 +1,41 @@
 package ie2datatypes
 
+type LambdaMethodConfig struct {
+	Name string             `yaml:"name"`
+	Req  string             `yaml:"req"`
+	Res  string             `yaml:"res"`
+	Auth *RESTAuthorization `yaml:"auth"`
+}
+
+type LambdaEndpointConfig struct {
+	Version  int                  `yaml:"version"`
+	Resource string               `yaml:"resource"`
+	Methods  []LambdaMethodConfig `yaml:"methods"`
+	Cors     *RESTCors            `yaml:"cors"`
+}
+
+type LambdaVpcConfig struct {
+	SubnetIds        []string `yaml:"subnetids"`
+	SecurityGroupIds []string `yaml:"securitygroupids"`
+}
+
 type LambdaConfig struct {
-	Name         string `yaml:"name"`
-	RoleName     string `yaml:"rolename"`
-	Architecture string `yaml:"architecture"`
-	Runtime      string `yaml:"runtime"`
-	Handler      string `yaml:"handler"`
-	Filename     string `yaml:"filename"`
-	Endpoint     []struct {
-		Version  int    `yaml:"version"`
-		Resource string `yaml:"resource"`
-		Methods  []struct {
-			Name string `yaml:"name"`
-			Req  string `yaml:"req"`
-			Res  string `yaml:"res"`
-		} `yaml:"methods"`
-	} `yaml:"endpoint"`
+	Name             string                 `yaml:"name"`
+	RoleName         string                 `yaml:"rolename"`
+	Architecture     string                 `yaml:"architecture"`
+	Runtime          string                 `yaml:"runtime"`
+	Handler          string                 `yaml:"handler"`
+	Filename         string                 `yaml:"filename"`
+	ImageUri         string                 `yaml:"imageuri"`
+	MemorySize       int32                  `yaml:"memorysize"`
+	Timeout          int32                  `yaml:"timeout"`
+	Environment      map[string]string      `yaml:"environment"`
+	Vpc              *LambdaVpcConfig       `yaml:"vpc"`
+	Layers           []string               `yaml:"layers"`
+	EphemeralStorage int32                  `yaml:"ephemeralstorage"`
+	TracingMode      string                 `yaml:"tracingmode"`
+	DeadLetterArn    string                 `yaml:"deadletterarn"`
+	Tags             map[string]string      `yaml:"tags"`
+	Alias            string                 `yaml:"alias"`
+	Endpoint         []LambdaEndpointConfig `yaml:"endpoint"`
 }
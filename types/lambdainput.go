@@ -1,13 +1,50 @@
 package ie2datatypes
 
+// LambdaInput describes a lambda function to create or update.
+// Settings left at their zero value (or nil) are not managed, i.e. they
+// use the lambda defaults on create and are left as they are on update.
+// Set Environment, Layers or Tags to an empty, non nil value to clear them.
+//
+// The code comes from the first of ImageUri, ZipPath, ZipDir or S3Bucket/S3Key that is set.
+// Zips from ZipPath and ZipDir are staged to S3Bucket under <Name>/<sha256>.zip when a bucket
+// is set, otherwise they are uploaded directly with the request.
+// Updates skip the code when its CodeSha256 matches the deployed code, unless Force is set.
 type LambdaInput struct {
-	Architecture string
-	DryRun       bool
-	Name         string
-	Handler      string
-	Publish      bool
-	RoleARN      string
-	Runtime      string
-	S3Bucket     string
-	S3Key        string
+	Architecture     string
+	DryRun           bool
+	Force            bool
+	Name             string
+	Handler          string
+	Publish          bool
+	RoleARN          string
+	Runtime          string
+	S3Bucket         string
+	S3Key            string
+	ZipPath          string
+	ZipDir           string
+	ImageUri         string
+	MemorySize       int32
+	Timeout          int32
+	Environment      map[string]string
+	VpcConfig        *LambdaVpcConfig
+	Layers           []string
+	EphemeralStorage int32
+	TracingMode      string
+	DeadLetterArn    string
+	Tags             map[string]string
+}
+
+// LambdaUpdateResult says what an update changed. CodeUnchanged is set when the
+// code matched the deployed CodeSha256 and the code update was skipped.
+// Version is the version published when LambdaInput.Publish is set.
+type LambdaUpdateResult struct {
+	CodeSha256    string
+	CodeUnchanged bool
+	Configuration []PlanChange
+	Version       string
+}
+
+// Unchanged is true when neither the code nor the configuration needed an update.
+func (r *LambdaUpdateResult) Unchanged() bool {
+	return r.CodeUnchanged && len(r.Configuration) <= 0
 }
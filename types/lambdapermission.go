@@ -0,0 +1,22 @@
+package ie2datatypes
+
+// LambdaPermissionMethod is a REST method api gateway may invoke a lambda for.
+// Path is the full resource path, e.g. /v1/papers/{id}, and Method may be ANY.
+type LambdaPermissionMethod struct {
+	Method string
+	Path   string
+}
+
+// LambdaPermissionInput lists every method of ApiId that may invoke LambdaName,
+// or the alias Qualifier of it. Api gateway statements in the lambda policy for
+// ApiId that aren't listed are removed, statements for other apis are left alone.
+// Set Path to only reconcile the statements for that one resource.
+type LambdaPermissionInput struct {
+	LambdaName string
+	Qualifier  string
+	Region     string
+	AccountId  string
+	ApiId      string
+	Path       string
+	Methods    []LambdaPermissionMethod
+}
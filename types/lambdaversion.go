@@ -0,0 +1,13 @@
+package ie2datatypes
+
+import "time"
+
+// LambdaTrafficShift describes how to move an alias to a new version gradually.
+// Each weight (0 to 1) is the share of traffic sent to the new version for Interval
+// before moving on to the next one. Check is called at the end of every step,
+// returning an error stops the shift and sends all traffic back to the old version.
+type LambdaTrafficShift struct {
+	Weights  []float64
+	Interval time.Duration
+	Check    func(weight float64) error
+}
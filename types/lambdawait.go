@@ -0,0 +1,23 @@
+package ie2datatypes
+
+import "time"
+
+// LambdaWaitParams tunes how long and how often to poll a lambda
+// while it is being created or updated.
+// Zero values fall back to the defaults in ie2utilities.
+type LambdaWaitParams struct {
+	Timeout  time.Duration
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	Progress func(LambdaWaitProgress)
+}
+
+// LambdaWaitProgress is passed to LambdaWaitParams.Progress after every poll.
+type LambdaWaitProgress struct {
+	Name             string
+	Attempt          int
+	Elapsed          time.Duration
+	State            string
+	LastUpdateStatus string
+	NextDelay        time.Duration
+}
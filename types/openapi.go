@@ -0,0 +1,132 @@
+package ie2datatypes
+
+import "encoding/json"
+
+const (
+	OpenAPIModeMerge     = "merge"
+	OpenAPIModeOverwrite = "overwrite"
+)
+
+// OpenAPIOptions supplies what a LambdaConfig doesn't carry when it is turned into an OpenAPI document.
+// Region and AccountId are looked up when empty, and Authorizers describes the authorizers
+// methods refer to by name, the same ones passed to PutRESTAuthorizer.
+type OpenAPIOptions struct {
+	Title       string
+	Version     string
+	AccountId   string
+	Region      string
+	SchemaDir   string
+	Authorizers []RESTAuthorizer
+}
+
+// OpenAPIImportInput applies Document, an OpenAPI document in json or yaml, to the api
+// ApiId or ApiName. Mode is merge or overwrite, merge when empty. The api is deployed
+// to Stage when it is set.
+type OpenAPIImportInput struct {
+	ApiId          string
+	ApiName        string
+	Document       []byte
+	Mode           string
+	Stage          string
+	FailOnWarnings bool
+}
+
+/***
+* The parts of OpenAPI 3 used to describe our apis, with the api gateway extensions
+***/
+
+type OpenAPIDocument struct {
+	OpenAPI           string                                  `json:"openapi"`
+	Info              OpenAPIInfo                             `json:"info"`
+	Paths             map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components        OpenAPIComponents                       `json:"components"`
+	RequestValidators map[string]OpenAPIRequestValidator      `json:"x-amazon-apigateway-request-validators,omitempty"`
+	GatewayResponses  map[string]OpenAPIGatewayResponse       `json:"x-amazon-apigateway-gateway-responses,omitempty"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIOperation struct {
+	OperationId      string                     `json:"operationId,omitempty"`
+	Parameters       []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody      *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses        map[string]OpenAPIResponse `json:"responses"`
+	Security         []map[string][]string      `json:"security,omitempty"`
+	RequestValidator string                     `json:"x-amazon-apigateway-request-validator,omitempty"`
+	Integration      *OpenAPIIntegration        `json:"x-amazon-apigateway-integration,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   map[string]any `json:"schema,omitempty"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIMediaType struct {
+	Schema map[string]string `json:"schema"`
+}
+
+type OpenAPIHeader struct {
+	Schema map[string]string `json:"schema"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Headers     map[string]OpenAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas         map[string]json.RawMessage       `json:"schemas,omitempty"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type OpenAPISecurityScheme struct {
+	Type       string                    `json:"type"`
+	Name       string                    `json:"name"`
+	In         string                    `json:"in"`
+	AuthType   string                    `json:"x-amazon-apigateway-authtype,omitempty"`
+	Authorizer *OpenAPIGatewayAuthorizer `json:"x-amazon-apigateway-authorizer,omitempty"`
+}
+
+type OpenAPIGatewayAuthorizer struct {
+	Type                         string   `json:"type"`
+	AuthorizerUri                string   `json:"authorizerUri,omitempty"`
+	AuthorizerCredentials        string   `json:"authorizerCredentials,omitempty"`
+	AuthorizerResultTtlInSeconds int32    `json:"authorizerResultTtlInSeconds,omitempty"`
+	IdentitySource               string   `json:"identitySource,omitempty"`
+	ProviderARNs                 []string `json:"providerARNs,omitempty"`
+}
+
+type OpenAPIIntegration struct {
+	Type                string                                `json:"type"`
+	HttpMethod          string                                `json:"httpMethod,omitempty"`
+	Uri                 string                                `json:"uri,omitempty"`
+	PassthroughBehavior string                                `json:"passthroughBehavior,omitempty"`
+	RequestTemplates    map[string]string                     `json:"requestTemplates,omitempty"`
+	Responses           map[string]OpenAPIIntegrationResponse `json:"responses,omitempty"`
+}
+
+type OpenAPIIntegrationResponse struct {
+	StatusCode         string            `json:"statusCode"`
+	ResponseParameters map[string]string `json:"responseParameters,omitempty"`
+	ResponseTemplates  map[string]string `json:"responseTemplates,omitempty"`
+}
+
+type OpenAPIRequestValidator struct {
+	ValidateRequestBody       bool `json:"validateRequestBody"`
+	ValidateRequestParameters bool `json:"validateRequestParameters"`
+}
+
+type OpenAPIGatewayResponse struct {
+	ResponseParameters map[string]string `json:"responseParameters,omitempty"`
+}
@@ -1,5 +1,7 @@
 package ie2datatypes
 
+import "time"
+
 type RDSParams struct {
 	DBName     string
 	DBHost     string
@@ -7,3 +9,13 @@ type RDSParams struct {
 	DBRegion   string
 	DBUserName string
 }
+
+// RDSPoolParams tunes the pgxpool created by IE2RDSPostgresPool.
+// Zero values fall back to the pgxpool defaults.
+type RDSPoolParams struct {
+	AuthMode          string
+	MaxConns          int32
+	MinConns          int32
+	HealthCheckPeriod time.Duration
+	MaxConnIdleTime   time.Duration
+}
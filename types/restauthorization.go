@@ -0,0 +1,53 @@
+package ie2datatypes
+
+const (
+	RESTAuthorizationNone    = "NONE"
+	RESTAuthorizationIAM     = "AWS_IAM"
+	RESTAuthorizationCognito = "COGNITO_USER_POOLS"
+	RESTAuthorizationCustom  = "CUSTOM"
+)
+
+// RESTAuthorization is how callers of a REST method are authorized.
+// COGNITO_USER_POOLS and CUSTOM methods use the authorizer named Authorizer,
+// or AuthorizerId when the id is known. Scopes only apply to COGNITO_USER_POOLS.
+// Methods without an authorization keep the old default, NONE with an api key required.
+// An api key stays required unless ApiKeyRequired is set to false.
+type RESTAuthorization struct {
+	Type           string   `yaml:"type"`
+	Authorizer     string   `yaml:"authorizer"`
+	AuthorizerId   string   `yaml:"authorizerid"`
+	Scopes         []string `yaml:"scopes"`
+	ApiKeyRequired *bool    `yaml:"apikeyrequired"`
+}
+
+// RequiresApiKey is ApiKeyRequired, true when it or the authorization is not set.
+func (a *RESTAuthorization) RequiresApiKey() bool {
+
+	if a == nil || a.ApiKeyRequired == nil {
+		return true
+	}
+
+	return *a.ApiKeyRequired
+}
+
+const (
+	RESTAuthorizerToken   = "TOKEN"
+	RESTAuthorizerRequest = "REQUEST"
+	RESTAuthorizerCognito = "COGNITO_USER_POOLS"
+)
+
+// RESTAuthorizer is an authorizer on a REST api, matched by Name when it is updated.
+// TOKEN and REQUEST authorizers invoke LambdaName, or the alias Qualifier of it,
+// and COGNITO_USER_POOLS authorizers accept tokens from the user pools in ProviderArns.
+// IdentitySource defaults to the Authorization header for TOKEN and COGNITO_USER_POOLS,
+// and a zero ResultTtl uses the api gateway default.
+type RESTAuthorizer struct {
+	Name           string
+	Type           string
+	LambdaName     string
+	Qualifier      string
+	ProviderArns   []string
+	IdentitySource string
+	ResultTtl      int32
+	CredentialsArn string
+}
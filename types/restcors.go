@@ -0,0 +1,16 @@
+package ie2datatypes
+
+// RESTCors lets browsers on Origins call a REST resource. An OPTIONS method answers
+// preflight requests without calling the lambda, so the lambda still has to send
+// Access-Control-Allow-Origin on its own responses.
+// Methods defaults to the methods of the resource and Headers to the ones api gateway
+// clients send (Content-Type, Authorization, X-Api-Key and the X-Amz ones).
+// The 4XX and 5XX gateway responses are shared by the whole api, so the last
+// endpoint deployed with Cors sets them.
+type RESTCors struct {
+	Origins     []string `yaml:"origins"`
+	Methods     []string `yaml:"methods"`
+	Headers     []string `yaml:"headers"`
+	MaxAge      int32    `yaml:"maxage"`
+	Credentials bool     `yaml:"credentials"`
+}
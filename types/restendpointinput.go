@@ -1,13 +1,23 @@
 package ie2datatypes
 
+// LambdaIntegration is the lambda a REST method invokes.
+// Set Qualifier to an alias (e.g. live) to invoke the alias rather than $LATEST.
 type LambdaIntegration struct {
 	LambdaName string
+	Qualifier  string
 }
 
+// RESTMethod is a method of a REST resource. A nil Authorization means
+// NONE with an api key required, see RESTAuthorization.
+// ReqModel and ResModel map a content type to the name of a model of the api.
+// Request bodies are validated against ReqModel before they reach the integration,
+// and ResModel documents the 200 response.
 type RESTMethod struct {
-	Name      string
-	ReqModel  map[string]string
-	ReqParams map[string]string
+	Name          string
+	ReqModel      map[string]string
+	ResModel      map[string]string
+	ReqParams     map[string]string
+	Authorization *RESTAuthorization
 }
 
 type RESTEndpointInput struct {
@@ -21,4 +31,5 @@ type RESTEndpointInput struct {
 	Stage            string
 	Integration      *LambdaIntegration
 	Methods          []RESTMethod
+	Cors             *RESTCors
 }
@@ -0,0 +1,9 @@
+package ie2datatypes
+
+// RESTModel is a model of a REST api. Schema is a JSON Schema (draft 4) document.
+type RESTModel struct {
+	Name        string
+	ContentType string
+	Description string
+	Schema      string
+}
@@ -2,16 +2,25 @@ package ie2utilities
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
-func AWSGetAccountId(conf *aws.Config, ctx *context.Context) (string, error) {
+func (s *AWSService) GetAccountId(ctx context.Context) (string, error) {
+
+	if ctx == nil {
+		return "", errors.New("context can not be empty")
+	}
+
+	c, err := s.stsClient()
 
-	c := sts.NewFromConfig(*conf)
+	if err != nil {
+		return "", err
+	}
 
-	res, err := c.GetCallerIdentity(*ctx, &sts.GetCallerIdentityInput{})
+	res, err := c.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 
 	if err != nil {
 		return "", err
@@ -19,3 +28,19 @@ func AWSGetAccountId(conf *aws.Config, ctx *context.Context) (string, error) {
 
 	return *res.Account, nil
 }
+
+// AWSGetAccountId is AWSService.GetAccountId using clients built from conf.
+//
+// Deprecated: use AWSService.GetAccountId, which takes a context.Context.
+func AWSGetAccountId(conf *aws.Config, ctx *context.Context) (string, error) {
+
+	if conf == nil {
+		return "", errors.New("aws.config can not be empty")
+	}
+
+	if ctx == nil {
+		return "", errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).GetAccountId(*ctx)
+}
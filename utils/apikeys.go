@@ -0,0 +1,618 @@
+package ie2utilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	api "github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+/***
+* API keys, the usage plans that throttle them and their usage
+***/
+
+// the date format GetUsage takes and returns
+const USAGE_DATE_FORMAT = "2006-01-02"
+
+func apiKey(key *types.ApiKey, created bool) *ie2datatypes.ApiKey {
+
+	return &ie2datatypes.ApiKey{
+		Id:      aws.ToString(key.Id),
+		Name:    aws.ToString(key.Name),
+		Value:   aws.ToString(key.Value),
+		Enabled: key.Enabled,
+		Created: created,
+	}
+}
+
+// getApiKeys lists the keys named name, oldest first. Names don't have to be unique.
+func getApiKeys(c APIGatewayAPI, ctx context.Context, name string) ([]types.ApiKey, error) {
+
+	ret := []types.ApiKey{}
+	pages := api.NewGetApiKeysPaginator(c, &api.GetApiKeysInput{
+		NameQuery:     aws.String(name),
+		IncludeValues: aws.Bool(true),
+		Limit:         aws.Int32(500),
+	})
+
+	for pages.HasMorePages() {
+
+		out, e := pages.NextPage(ctx)
+
+		if e != nil {
+			return nil, e
+		}
+
+		// the name query matches prefixes
+		for _, key := range out.Items {
+			if aws.ToString(key.Name) == name {
+				ret = append(ret, key)
+			}
+		}
+	}
+
+	sort.SliceStable(ret, func(i int, j int) bool {
+		return aws.ToTime(ret[i].CreatedDate).Before(aws.ToTime(ret[j].CreatedDate))
+	})
+
+	return ret, nil
+}
+
+// getUsagePlans lists the usage plans, only those keyid is in when it is set.
+func getUsagePlans(c APIGatewayAPI, ctx context.Context, keyid string) ([]types.UsagePlan, error) {
+
+	req := &api.GetUsagePlansInput{Limit: aws.Int32(500)}
+
+	if len(keyid) > 0 {
+		req.KeyId = aws.String(keyid)
+	}
+
+	ret := []types.UsagePlan{}
+	pages := api.NewGetUsagePlansPaginator(c, req)
+
+	for pages.HasMorePages() {
+
+		out, e := pages.NextPage(ctx)
+
+		if e != nil {
+			return nil, e
+		}
+
+		ret = append(ret, out.Items...)
+	}
+
+	return ret, nil
+}
+
+func createApiKey(c APIGatewayAPI, ctx context.Context, name string, description string) (*ie2datatypes.ApiKey, error) {
+
+	log.Printf("Creating api key %s", name)
+	out, e := c.CreateApiKey(ctx, &api.CreateApiKeyInput{
+		Name:        aws.String(name),
+		Description: aws.String(description),
+		Enabled:     true,
+	})
+
+	if e != nil {
+		return nil, e
+	}
+
+	return apiKey(&types.ApiKey{Id: out.Id, Name: out.Name, Value: out.Value, Enabled: out.Enabled}, true), nil
+}
+
+// PutApiKey returns the newest enabled api key named name, creating one if there is none.
+func (s *AWSService) PutApiKey(ctx context.Context, name string, description string) (*ie2datatypes.ApiKey, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context can not be empty")
+	}
+
+	if len(name) <= 0 {
+		return nil, errors.New("api key name can not be empty")
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return nil, e
+	}
+
+	keys, e := getApiKeys(c, ctx, name)
+
+	if e != nil {
+		return nil, e
+	}
+
+	for i := len(keys) - 1; i >= 0; i-- {
+		if keys[i].Enabled {
+			return apiKey(&keys[i], false), nil
+		}
+	}
+
+	return createApiKey(c, ctx, name, description)
+}
+
+// RotateApiKey replaces the api keys named name with a new key in the same usage plans.
+// The old keys are disabled rather than deleted so a rotation can be undone by enabling them.
+func (s *AWSService) RotateApiKey(ctx context.Context, name string) (*ie2datatypes.ApiKey, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context can not be empty")
+	}
+
+	if len(name) <= 0 {
+		return nil, errors.New("api key name can not be empty")
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return nil, e
+	}
+
+	keys, e := getApiKeys(c, ctx, name)
+
+	if e != nil {
+		return nil, e
+	}
+
+	description := ""
+	plans := map[string]bool{}
+
+	for _, key := range keys {
+
+		if !key.Enabled {
+			continue
+		}
+
+		description = aws.ToString(key.Description)
+		in, e := getUsagePlans(c, ctx, aws.ToString(key.Id))
+
+		if e != nil {
+			return nil, e
+		}
+
+		for _, plan := range in {
+			plans[aws.ToString(plan.Id)] = true
+		}
+	}
+
+	created, e := createApiKey(c, ctx, name, description)
+
+	if e != nil {
+		return nil, e
+	}
+
+	for plan := range plans {
+
+		e = addApiKeyToUsagePlan(c, ctx, plan, created.Id)
+
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	// the new key is in place before the old ones stop working
+	for _, key := range keys {
+
+		if !key.Enabled {
+			continue
+		}
+
+		log.Printf("Disabling api key %s %s", name, aws.ToString(key.Id))
+		_, e = c.UpdateApiKey(ctx, &api.UpdateApiKeyInput{
+			ApiKey: key.Id,
+			PatchOperations: []types.PatchOperation{
+				{Op: types.OpReplace, Path: aws.String("/enabled"), Value: aws.String("false")},
+			},
+		})
+
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	return created, nil
+}
+
+// usagePlanStages resolves the api of each stage to its id.
+func (s *AWSService) usagePlanStages(ctx context.Context, stages []ie2datatypes.UsagePlanStage) ([]types.ApiStage, error) {
+
+	ret := []types.ApiStage{}
+
+	for _, stage := range stages {
+
+		apiid := stage.ApiId
+
+		if len(apiid) <= 0 {
+
+			id, e := s.GetRESTApiIdFromName(ctx, stage.ApiName)
+
+			if e != nil {
+				return nil, e
+			}
+
+			if len(id) <= 0 {
+				return nil, fmt.Errorf("rest api %s does not exist", stage.ApiName)
+			}
+
+			apiid = id
+		}
+
+		if len(stage.Stage) <= 0 {
+			return nil, fmt.Errorf("usage plan stage of api %s has no stage name", apiid)
+		}
+
+		apistage := types.ApiStage{
+			ApiId: aws.String(apiid),
+			Stage: aws.String(stage.Stage),
+		}
+
+		if len(stage.Throttle) > 0 {
+
+			apistage.Throttle = map[string]types.ThrottleSettings{}
+
+			for method, throttle := range stage.Throttle {
+
+				// /path/METHOD, the root resource is //METHOD
+				i := strings.LastIndex(method, "/")
+
+				if !strings.HasPrefix(method, "/") || i <= 0 || i >= len(method)-1 {
+					return nil, fmt.Errorf("usage plan throttle %s of stage %s is not /path/METHOD", method, stage.Stage)
+				}
+
+				apistage.Throttle[method] = types.ThrottleSettings{RateLimit: throttle.Rate, BurstLimit: throttle.Burst}
+			}
+		}
+
+		ret = append(ret, apistage)
+	}
+
+	return ret, nil
+}
+
+// usagePlanThrottlePath is the patch path of a method throttle, keyed like /v1/papers/GET.
+// The resource path is escaped as a json pointer, / becomes ~1.
+func usagePlanThrottlePath(stage string, method string) string {
+
+	i := strings.LastIndex(method, "/")
+
+	return fmt.Sprintf("/apiStages/%s/throttle/%s/%s", stage, restPatchPathEscape(method[:i]), method[i+1:])
+}
+
+// usagePlanPatch returns the operations that change a usage plan to match input and stages.
+func usagePlanPatch(current *types.UsagePlan, input *ie2datatypes.UsagePlanInput, stages []types.ApiStage) []types.PatchOperation {
+
+	ops := []types.PatchOperation{}
+
+	op := func(o types.Op, path string, value string) {
+
+		patch := types.PatchOperation{Op: o, Path: aws.String(path)}
+
+		if o != types.OpRemove || len(value) > 0 {
+			patch.Value = aws.String(value)
+		}
+
+		ops = append(ops, patch)
+	}
+
+	if aws.ToString(current.Description) != input.Description {
+		op(types.OpReplace, "/description", input.Description)
+	}
+
+	if input.Throttle == nil && current.Throttle != nil {
+		op(types.OpRemove, "/throttle", "")
+	}
+
+	if input.Throttle != nil && (current.Throttle == nil || current.Throttle.RateLimit != input.Throttle.Rate || current.Throttle.BurstLimit != input.Throttle.Burst) {
+		op(types.OpReplace, "/throttle/rateLimit", strconv.FormatFloat(input.Throttle.Rate, 'f', -1, 64))
+		op(types.OpReplace, "/throttle/burstLimit", strconv.Itoa(int(input.Throttle.Burst)))
+	}
+
+	if input.Quota == nil && current.Quota != nil {
+		op(types.OpRemove, "/quota", "")
+	}
+
+	if input.Quota != nil && (current.Quota == nil || current.Quota.Limit != input.Quota.Limit || string(current.Quota.Period) != input.Quota.Period || current.Quota.Offset != input.Quota.Offset) {
+		op(types.OpReplace, "/quota/limit", strconv.Itoa(int(input.Quota.Limit)))
+		op(types.OpReplace, "/quota/period", input.Quota.Period)
+		op(types.OpReplace, "/quota/offset", strconv.Itoa(int(input.Quota.Offset)))
+	}
+
+	key := func(stage types.ApiStage) string {
+		return fmt.Sprintf("%s:%s", aws.ToString(stage.ApiId), aws.ToString(stage.Stage))
+	}
+
+	existing := map[string]types.ApiStage{}
+
+	for _, stage := range current.ApiStages {
+		existing[key(stage)] = stage
+	}
+
+	desired := map[string]bool{}
+
+	for _, stage := range stages {
+
+		k := key(stage)
+		desired[k] = true
+		throttle := map[string]types.ThrottleSettings{}
+
+		if cur, ok := existing[k]; ok {
+			throttle = cur.Throttle
+		} else {
+			op(types.OpAdd, "/apiStages", k)
+		}
+
+		methods := []string{}
+
+		for method := range stage.Throttle {
+			methods = append(methods, method)
+		}
+
+		sort.Strings(methods)
+
+		for _, method := range methods {
+
+			want := stage.Throttle[method]
+
+			if have, ok := throttle[method]; !ok || have != want {
+				op(types.OpReplace, usagePlanThrottlePath(k, method)+"/rateLimit", strconv.FormatFloat(want.RateLimit, 'f', -1, 64))
+				op(types.OpReplace, usagePlanThrottlePath(k, method)+"/burstLimit", strconv.Itoa(int(want.BurstLimit)))
+			}
+		}
+
+		for method := range throttle {
+			if _, ok := stage.Throttle[method]; !ok {
+				op(types.OpRemove, usagePlanThrottlePath(k, method), "")
+			}
+		}
+	}
+
+	for k := range existing {
+		if !desired[k] {
+			op(types.OpRemove, "/apiStages", k)
+		}
+	}
+
+	return ops
+}
+
+// PutUsagePlan creates the usage plan described by input, or updates the one with the same name,
+// and returns its id.
+func (s *AWSService) PutUsagePlan(ctx context.Context, input *ie2datatypes.UsagePlanInput) (string, error) {
+
+	if ctx == nil {
+		return "", errors.New("context can not be empty")
+	}
+
+	if input == nil {
+		return "", errors.New("input param can not be null")
+	}
+
+	if len(input.Name) <= 0 {
+		return "", errors.New("usage plan name can not be empty")
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return "", e
+	}
+
+	stages, e := s.usagePlanStages(ctx, input.Stages)
+
+	if e != nil {
+		return "", e
+	}
+
+	plans, e := getUsagePlans(c, ctx, "")
+
+	if e != nil {
+		return "", e
+	}
+
+	for i := range plans {
+
+		if aws.ToString(plans[i].Name) != input.Name {
+			continue
+		}
+
+		id := aws.ToString(plans[i].Id)
+		ops := usagePlanPatch(&plans[i], input, stages)
+
+		if len(ops) <= 0 {
+			log.Printf("Usage plan %s is up to date.", input.Name)
+			return id, nil
+		}
+
+		log.Printf("Updating usage plan %s", input.Name)
+		_, e = c.UpdateUsagePlan(ctx, &api.UpdateUsagePlanInput{
+			UsagePlanId:     aws.String(id),
+			PatchOperations: ops,
+		})
+
+		if e != nil {
+			log.Print(e)
+			return "", e
+		}
+
+		return id, nil
+	}
+
+	req := &api.CreateUsagePlanInput{
+		Name:        aws.String(input.Name),
+		Description: aws.String(input.Description),
+		ApiStages:   stages,
+	}
+
+	if input.Throttle != nil {
+		req.Throttle = &types.ThrottleSettings{RateLimit: input.Throttle.Rate, BurstLimit: input.Throttle.Burst}
+	}
+
+	if input.Quota != nil {
+		req.Quota = &types.QuotaSettings{Limit: input.Quota.Limit, Period: types.QuotaPeriodType(input.Quota.Period), Offset: input.Quota.Offset}
+	}
+
+	log.Printf("Creating usage plan %s", input.Name)
+	out, e := c.CreateUsagePlan(ctx, req)
+
+	if e != nil {
+		log.Print(e)
+		return "", e
+	}
+
+	return aws.ToString(out.Id), nil
+}
+
+func addApiKeyToUsagePlan(c APIGatewayAPI, ctx context.Context, planid string, keyid string) error {
+
+	log.Printf("Adding api key %s to usage plan %s", keyid, planid)
+	_, e := c.CreateUsagePlanKey(ctx, &api.CreateUsagePlanKeyInput{
+		UsagePlanId: aws.String(planid),
+		KeyId:       aws.String(keyid),
+		KeyType:     aws.String("API_KEY"),
+	})
+
+	// the key is already in the plan
+	if e != nil && errors.Is(ClassifyAWSError(e), ErrConflict) {
+		return nil
+	}
+
+	return e
+}
+
+// AddApiKeyToUsagePlan puts an api key in a usage plan. It does nothing if the key is already in it.
+func (s *AWSService) AddApiKeyToUsagePlan(ctx context.Context, planid string, keyid string) error {
+
+	if ctx == nil {
+		return errors.New("context can not be empty")
+	}
+
+	if len(planid) <= 0 || len(keyid) <= 0 {
+		return errors.New("usage plan id and api key id can not be empty")
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return e
+	}
+
+	return addApiKeyToUsagePlan(c, ctx, planid, keyid)
+}
+
+// GetApiKeyUsage returns the requests an api key made in a usage plan on each day from start to end.
+func (s *AWSService) GetApiKeyUsage(ctx context.Context, planid string, keyid string, start time.Time, end time.Time) (*ie2datatypes.ApiKeyUsage, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context can not be empty")
+	}
+
+	if len(planid) <= 0 || len(keyid) <= 0 {
+		return nil, errors.New("usage plan id and api key id can not be empty")
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return nil, e
+	}
+
+	ret := &ie2datatypes.ApiKeyUsage{KeyId: keyid, UsagePlanId: planid}
+	pages := api.NewGetUsagePaginator(c, &api.GetUsageInput{
+		UsagePlanId: aws.String(planid),
+		KeyId:       aws.String(keyid),
+		StartDate:   aws.String(start.Format(USAGE_DATE_FORMAT)),
+		EndDate:     aws.String(end.Format(USAGE_DATE_FORMAT)),
+	})
+
+	logs := [][]int64{}
+
+	for pages.HasMorePages() {
+
+		out, e := pages.NextPage(ctx)
+
+		if e != nil {
+			return nil, e
+		}
+
+		logs = append(logs, out.Items[keyid]...)
+	}
+
+	// one [used, remaining] entry per day from the start date
+	for i, day := range logs {
+
+		usage := ie2datatypes.ApiKeyDailyUsage{Date: start.AddDate(0, 0, i).Format(USAGE_DATE_FORMAT)}
+
+		if len(day) > 0 {
+			usage.Used = day[0]
+		}
+
+		if len(day) > 1 {
+			usage.Remaining = day[1]
+		}
+
+		ret.Days = append(ret.Days, usage)
+	}
+
+	return ret, nil
+}
+
+// ApplyApiAccess creates or updates the usage plans in config and gives each partner
+// a key in their plans. Keys are never removed from plans here, use RotateApiKey to revoke one.
+func (s *AWSService) ApplyApiAccess(ctx context.Context, config ie2datatypes.ApiAccessConfig) (*ie2datatypes.ApiAccessResult, error) {
+
+	res := &ie2datatypes.ApiAccessResult{UsagePlans: map[string]string{}}
+
+	if ctx == nil {
+		return res, errors.New("context can not be empty")
+	}
+
+	for i := range config.UsagePlans {
+
+		id, e := s.PutUsagePlan(ctx, &config.UsagePlans[i])
+
+		if e != nil {
+			return res, e
+		}
+
+		res.UsagePlans[config.UsagePlans[i].Name] = id
+	}
+
+	for _, partner := range config.Partners {
+
+		for _, plan := range partner.UsagePlans {
+			if _, ok := res.UsagePlans[plan]; !ok {
+				return res, fmt.Errorf("partner %s uses usage plan %s which is not in the config", partner.Name, plan)
+			}
+		}
+
+		key, e := s.PutApiKey(ctx, partner.Name, partner.Description)
+
+		if e != nil {
+			return res, e
+		}
+
+		res.Keys = append(res.Keys, *key)
+
+		for _, plan := range partner.UsagePlans {
+
+			e = s.AddApiKeyToUsagePlan(ctx, res.UsagePlans[plan], key.Id)
+
+			if e != nil {
+				return res, e
+			}
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,156 @@
+package ie2utilities
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+// patchStrings renders patch operations as "op path value" so they compare easily.
+func patchStrings(ops []types.PatchOperation) []string {
+
+	ret := []string{}
+
+	for _, op := range ops {
+
+		s := fmt.Sprintf("%s %s", op.Op, aws.ToString(op.Path))
+
+		if op.Value != nil {
+			s += " " + aws.ToString(op.Value)
+		}
+
+		ret = append(ret, s)
+	}
+
+	return ret
+}
+
+func TestUsagePlanPatch(t *testing.T) {
+
+	stage := types.ApiStage{ApiId: aws.String("api"), Stage: aws.String("dev")}
+	throttled := types.ApiStage{
+		ApiId:    aws.String("api"),
+		Stage:    aws.String("dev"),
+		Throttle: map[string]types.ThrottleSettings{"/papers/GET": {RateLimit: 5, BurstLimit: 10}},
+	}
+
+	tests := []struct {
+		name    string
+		current types.UsagePlan
+		input   ie2datatypes.UsagePlanInput
+		stages  []types.ApiStage
+		want    []string
+	}{
+		{
+			name:    "unchanged",
+			current: types.UsagePlan{Description: aws.String("partners"), ApiStages: []types.ApiStage{stage}},
+			input:   ie2datatypes.UsagePlanInput{Description: "partners"},
+			stages:  []types.ApiStage{stage},
+			want:    []string{},
+		},
+		{
+			name:    "description",
+			current: types.UsagePlan{Description: aws.String("old")},
+			input:   ie2datatypes.UsagePlanInput{Description: "new"},
+			want:    []string{"replace /description new"},
+		},
+		{
+			name:    "throttle added",
+			current: types.UsagePlan{},
+			input:   ie2datatypes.UsagePlanInput{Throttle: &ie2datatypes.UsagePlanThrottle{Rate: 2.5, Burst: 5}},
+			want:    []string{"replace /throttle/rateLimit 2.5", "replace /throttle/burstLimit 5"},
+		},
+		{
+			name:    "throttle removed",
+			current: types.UsagePlan{Throttle: &types.ThrottleSettings{RateLimit: 1, BurstLimit: 1}},
+			input:   ie2datatypes.UsagePlanInput{},
+			want:    []string{"remove /throttle"},
+		},
+		{
+			name:    "quota changed",
+			current: types.UsagePlan{Quota: &types.QuotaSettings{Limit: 100, Period: types.QuotaPeriodTypeDay}},
+			input:   ie2datatypes.UsagePlanInput{Quota: &ie2datatypes.UsagePlanQuota{Limit: 1000, Period: "MONTH"}},
+			want:    []string{"replace /quota/limit 1000", "replace /quota/period MONTH", "replace /quota/offset 0"},
+		},
+		{
+			name:    "quota removed",
+			current: types.UsagePlan{Quota: &types.QuotaSettings{Limit: 100, Period: types.QuotaPeriodTypeDay}},
+			input:   ie2datatypes.UsagePlanInput{},
+			want:    []string{"remove /quota"},
+		},
+		{
+			name:    "stage added",
+			current: types.UsagePlan{},
+			stages:  []types.ApiStage{stage},
+			want:    []string{"add /apiStages api:dev"},
+		},
+		{
+			name:    "stage removed",
+			current: types.UsagePlan{ApiStages: []types.ApiStage{stage}},
+			want:    []string{"remove /apiStages api:dev"},
+		},
+		{
+			name:    "method throttle added",
+			current: types.UsagePlan{ApiStages: []types.ApiStage{stage}},
+			stages:  []types.ApiStage{throttled},
+			want: []string{
+				"replace /apiStages/api:dev/throttle/~1papers/GET/rateLimit 5",
+				"replace /apiStages/api:dev/throttle/~1papers/GET/burstLimit 10",
+			},
+		},
+		{
+			name:    "method throttle removed",
+			current: types.UsagePlan{ApiStages: []types.ApiStage{throttled}},
+			stages:  []types.ApiStage{stage},
+			want:    []string{"remove /apiStages/api:dev/throttle/~1papers/GET"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got := patchStrings(usagePlanPatch(&tt.current, &tt.input, tt.stages))
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("usagePlanPatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsagePlanStagesThrottleKeys(t *testing.T) {
+
+	tests := []struct {
+		key     string
+		wantErr bool
+	}{
+		{key: "/papers/GET"},
+		{key: "//GET"},
+		{key: "/v1/papers/{id}/DELETE"},
+		{key: "GET", wantErr: true},
+		{key: "/GET", wantErr: true},
+		{key: "/papers/", wantErr: true},
+	}
+
+	s := &AWSService{}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+
+			_, e := s.usagePlanStages(context.Background(), []ie2datatypes.UsagePlanStage{{
+				ApiId:    "api",
+				Stage:    "dev",
+				Throttle: map[string]ie2datatypes.UsagePlanThrottle{tt.key: {Rate: 1, Burst: 1}},
+			}})
+
+			if (e != nil) != tt.wantErr {
+				t.Errorf("usagePlanStages() error = %v, wantErr %v", e, tt.wantErr)
+			}
+		})
+	}
+}
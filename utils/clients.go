@@ -0,0 +1,161 @@
+package ie2utilities
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	api "github.com/aws/aws-sdk-go-v2/service/apigateway"
+	apitypes "github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+/***
+* Narrow views of the AWS SDK clients. Each interface only lists the calls this module makes,
+* so the SDK clients satisfy them as is and tests can swap in the fakes from ie2testing.
+***/
+
+type LambdaAPI interface {
+	AddPermission(ctx context.Context, params *lambda.AddPermissionInput, optFns ...func(*lambda.Options)) (*lambda.AddPermissionOutput, error)
+	CreateAlias(ctx context.Context, params *lambda.CreateAliasInput, optFns ...func(*lambda.Options)) (*lambda.CreateAliasOutput, error)
+	CreateFunction(ctx context.Context, params *lambda.CreateFunctionInput, optFns ...func(*lambda.Options)) (*lambda.CreateFunctionOutput, error)
+	DeleteFunction(ctx context.Context, params *lambda.DeleteFunctionInput, optFns ...func(*lambda.Options)) (*lambda.DeleteFunctionOutput, error)
+	GetAlias(ctx context.Context, params *lambda.GetAliasInput, optFns ...func(*lambda.Options)) (*lambda.GetAliasOutput, error)
+	GetFunction(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error)
+	GetPolicy(ctx context.Context, params *lambda.GetPolicyInput, optFns ...func(*lambda.Options)) (*lambda.GetPolicyOutput, error)
+	ListVersionsByFunction(ctx context.Context, params *lambda.ListVersionsByFunctionInput, optFns ...func(*lambda.Options)) (*lambda.ListVersionsByFunctionOutput, error)
+	PublishVersion(ctx context.Context, params *lambda.PublishVersionInput, optFns ...func(*lambda.Options)) (*lambda.PublishVersionOutput, error)
+	RemovePermission(ctx context.Context, params *lambda.RemovePermissionInput, optFns ...func(*lambda.Options)) (*lambda.RemovePermissionOutput, error)
+	TagResource(ctx context.Context, params *lambda.TagResourceInput, optFns ...func(*lambda.Options)) (*lambda.TagResourceOutput, error)
+	UntagResource(ctx context.Context, params *lambda.UntagResourceInput, optFns ...func(*lambda.Options)) (*lambda.UntagResourceOutput, error)
+	UpdateAlias(ctx context.Context, params *lambda.UpdateAliasInput, optFns ...func(*lambda.Options)) (*lambda.UpdateAliasOutput, error)
+	UpdateFunctionCode(ctx context.Context, params *lambda.UpdateFunctionCodeInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionCodeOutput, error)
+	UpdateFunctionConfiguration(ctx context.Context, params *lambda.UpdateFunctionConfigurationInput, optFns ...func(*lambda.Options)) (*lambda.UpdateFunctionConfigurationOutput, error)
+}
+
+type APIGatewayAPI interface {
+	CreateApiKey(ctx context.Context, params *api.CreateApiKeyInput, optFns ...func(*api.Options)) (*api.CreateApiKeyOutput, error)
+	CreateAuthorizer(ctx context.Context, params *api.CreateAuthorizerInput, optFns ...func(*api.Options)) (*api.CreateAuthorizerOutput, error)
+	CreateDeployment(ctx context.Context, params *api.CreateDeploymentInput, optFns ...func(*api.Options)) (*api.CreateDeploymentOutput, error)
+	CreateModel(ctx context.Context, params *api.CreateModelInput, optFns ...func(*api.Options)) (*api.CreateModelOutput, error)
+	CreateRequestValidator(ctx context.Context, params *api.CreateRequestValidatorInput, optFns ...func(*api.Options)) (*api.CreateRequestValidatorOutput, error)
+	CreateResource(ctx context.Context, params *api.CreateResourceInput, optFns ...func(*api.Options)) (*api.CreateResourceOutput, error)
+	CreateStage(ctx context.Context, params *api.CreateStageInput, optFns ...func(*api.Options)) (*api.CreateStageOutput, error)
+	CreateUsagePlan(ctx context.Context, params *api.CreateUsagePlanInput, optFns ...func(*api.Options)) (*api.CreateUsagePlanOutput, error)
+	CreateUsagePlanKey(ctx context.Context, params *api.CreateUsagePlanKeyInput, optFns ...func(*api.Options)) (*api.CreateUsagePlanKeyOutput, error)
+	DeleteIntegration(ctx context.Context, params *api.DeleteIntegrationInput, optFns ...func(*api.Options)) (*api.DeleteIntegrationOutput, error)
+	DeleteMethod(ctx context.Context, params *api.DeleteMethodInput, optFns ...func(*api.Options)) (*api.DeleteMethodOutput, error)
+	GetApiKeys(ctx context.Context, params *api.GetApiKeysInput, optFns ...func(*api.Options)) (*api.GetApiKeysOutput, error)
+	GetAuthorizers(ctx context.Context, params *api.GetAuthorizersInput, optFns ...func(*api.Options)) (*api.GetAuthorizersOutput, error)
+	GetIntegration(ctx context.Context, params *api.GetIntegrationInput, optFns ...func(*api.Options)) (*api.GetIntegrationOutput, error)
+	GetMethod(ctx context.Context, params *api.GetMethodInput, optFns ...func(*api.Options)) (*api.GetMethodOutput, error)
+	GetModel(ctx context.Context, params *api.GetModelInput, optFns ...func(*api.Options)) (*api.GetModelOutput, error)
+	GetRequestValidators(ctx context.Context, params *api.GetRequestValidatorsInput, optFns ...func(*api.Options)) (*api.GetRequestValidatorsOutput, error)
+	GetResources(ctx context.Context, params *api.GetResourcesInput, optFns ...func(*api.Options)) (*api.GetResourcesOutput, error)
+	GetRestApi(ctx context.Context, params *api.GetRestApiInput, optFns ...func(*api.Options)) (*api.GetRestApiOutput, error)
+	GetRestApis(ctx context.Context, params *api.GetRestApisInput, optFns ...func(*api.Options)) (*api.GetRestApisOutput, error)
+	GetStage(ctx context.Context, params *api.GetStageInput, optFns ...func(*api.Options)) (*api.GetStageOutput, error)
+	GetUsage(ctx context.Context, params *api.GetUsageInput, optFns ...func(*api.Options)) (*api.GetUsageOutput, error)
+	GetUsagePlans(ctx context.Context, params *api.GetUsagePlansInput, optFns ...func(*api.Options)) (*api.GetUsagePlansOutput, error)
+	PutGatewayResponse(ctx context.Context, params *api.PutGatewayResponseInput, optFns ...func(*api.Options)) (*api.PutGatewayResponseOutput, error)
+	PutIntegration(ctx context.Context, params *api.PutIntegrationInput, optFns ...func(*api.Options)) (*api.PutIntegrationOutput, error)
+	PutIntegrationResponse(ctx context.Context, params *api.PutIntegrationResponseInput, optFns ...func(*api.Options)) (*api.PutIntegrationResponseOutput, error)
+	PutMethod(ctx context.Context, params *api.PutMethodInput, optFns ...func(*api.Options)) (*api.PutMethodOutput, error)
+	PutMethodResponse(ctx context.Context, params *api.PutMethodResponseInput, optFns ...func(*api.Options)) (*api.PutMethodResponseOutput, error)
+	PutRestApi(ctx context.Context, params *api.PutRestApiInput, optFns ...func(*api.Options)) (*api.PutRestApiOutput, error)
+	UpdateApiKey(ctx context.Context, params *api.UpdateApiKeyInput, optFns ...func(*api.Options)) (*api.UpdateApiKeyOutput, error)
+	UpdateAuthorizer(ctx context.Context, params *api.UpdateAuthorizerInput, optFns ...func(*api.Options)) (*api.UpdateAuthorizerOutput, error)
+	UpdateMethod(ctx context.Context, params *api.UpdateMethodInput, optFns ...func(*api.Options)) (*api.UpdateMethodOutput, error)
+	UpdateMethodResponse(ctx context.Context, params *api.UpdateMethodResponseInput, optFns ...func(*api.Options)) (*api.UpdateMethodResponseOutput, error)
+	UpdateModel(ctx context.Context, params *api.UpdateModelInput, optFns ...func(*api.Options)) (*api.UpdateModelOutput, error)
+	UpdateStage(ctx context.Context, params *api.UpdateStageInput, optFns ...func(*api.Options)) (*api.UpdateStageOutput, error)
+	UpdateUsagePlan(ctx context.Context, params *api.UpdateUsagePlanInput, optFns ...func(*api.Options)) (*api.UpdateUsagePlanOutput, error)
+}
+
+type S3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+type STSAPI interface {
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+type SecretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// AWSService holds the clients used by the AWS helpers in this package.
+// Build one with NewAWSService for real AWS, or fill in the fields directly
+// (e.g. with the ie2testing fakes) to run the same logic offline.
+// Its methods take a context.Context and stop waiting once it is cancelled.
+type AWSService struct {
+	Region         string
+	Lambda         LambdaAPI
+	APIGateway     APIGatewayAPI
+	S3             S3API
+	STS            STSAPI
+	SecretsManager SecretsManagerAPI
+
+	// LambdaWait controls how long lambda creates and updates are waited on,
+	// nil uses the defaults.
+	LambdaWait *ie2datatypes.LambdaWaitParams
+
+	restCacheMu sync.Mutex
+	restCache   map[string]map[string]apitypes.Resource
+}
+
+// NewAWSService creates SDK clients for every service from conf.
+func NewAWSService(conf aws.Config) *AWSService {
+
+	return &AWSService{
+		Region:         conf.Region,
+		Lambda:         lambda.NewFromConfig(conf),
+		APIGateway:     api.NewFromConfig(conf),
+		S3:             s3.NewFromConfig(conf),
+		STS:            sts.NewFromConfig(conf),
+		SecretsManager: secretsmanager.NewFromConfig(conf),
+	}
+}
+
+func (s *AWSService) lambdaClient() (LambdaAPI, error) {
+
+	if s == nil || s.Lambda == nil {
+		return nil, errors.New("lambda client can not be empty")
+	}
+
+	return s.Lambda, nil
+}
+
+func (s *AWSService) apiGatewayClient() (APIGatewayAPI, error) {
+
+	if s == nil || s.APIGateway == nil {
+		return nil, errors.New("apigateway client can not be empty")
+	}
+
+	return s.APIGateway, nil
+}
+
+func (s *AWSService) s3Client() (S3API, error) {
+
+	if s == nil || s.S3 == nil {
+		return nil, errors.New("s3 client can not be empty")
+	}
+
+	return s.S3, nil
+}
+
+func (s *AWSService) stsClient() (STSAPI, error) {
+
+	if s == nil || s.STS == nil {
+		return nil, errors.New("sts client can not be empty")
+	}
+
+	return s.STS, nil
+}
@@ -1,75 +1,53 @@
 package ie2utilities
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"log"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
-	"gopkg.in/yaml.v3"
 )
 
-func ConfigParser(conf *aws.Config, ctx *context.Context, bucket string, key string) (ie2datatypes.LambdaConfig, error) {
-
-	t := ie2datatypes.LambdaConfig{}
-
-	log.Printf("Create S3 client from config.")
-	client := s3.NewFromConfig(*conf)
+func (s *AWSService) ConfigParser(ctx context.Context, bucket string, key string) (ie2datatypes.LambdaConfig, error) {
 
 	log.Printf("Attempting to parse config file: %s", bucket+"/"+key)
-	log.Printf("Retrieving object from S3...")
 
-	res, err := client.GetObject(*ctx, &s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-	})
+	c, err := s.s3Client()
 
 	if err != nil {
-		log.Printf("Error reading from S3.")
-		log.Printf("Bucket: %s", bucket)
-		log.Printf("Key: %s", key)
-		return t, err
+		return ie2datatypes.LambdaConfig{}, err
 	}
 
-	log.Print("GetObject result...")
-	log.Print(*res)
-	log.Printf("Unstarred val: %d", res.ContentLength)
-	log.Printf("File content length: %d", *res.ContentLength)
-
-	if *res.ContentLength > 0 {
+	return LoadS3DocumentContext[ie2datatypes.LambdaConfig](ctx, c, bucket, key)
+}
 
-		log.Printf("File found! Attempting to parse...")
+// ApiAccessConfigParser reads the usage plans and partner api keys to pass to ApplyApiAccess.
+func (s *AWSService) ApiAccessConfigParser(ctx context.Context, bucket string, key string) (ie2datatypes.ApiAccessConfig, error) {
 
-		readBytes := int64(0)
-		buffer := new(bytes.Buffer)
-		readBytes, err = buffer.ReadFrom(res.Body)
+	log.Printf("Attempting to parse api access config file: %s", bucket+"/"+key)
 
-		if err != nil {
-			log.Printf("Error reading file!")
-			return t, err
-		}
+	c, err := s.s3Client()
 
-		if readBytes > 0 {
+	if err != nil {
+		return ie2datatypes.ApiAccessConfig{}, err
+	}
 
-			log.Printf("Successfully read %d bytes.", readBytes)
-			log.Printf("%s", buffer.String())
-			log.Printf("Preparing to unmarshal YAML.")
-			err = yaml.Unmarshal(buffer.Bytes(), &t)
+	return LoadS3DocumentContext[ie2datatypes.ApiAccessConfig](ctx, c, bucket, key)
+}
 
-			if err != nil {
-				log.Printf("Error Unmarshalling config file to JSON.")
-				return t, err
-			}
+// ConfigParser is AWSService.ConfigParser using clients built from conf.
+//
+// Deprecated: use AWSService.ConfigParser, which takes a context.Context.
+func ConfigParser(conf *aws.Config, ctx *context.Context, bucket string, key string) (ie2datatypes.LambdaConfig, error) {
 
-			log.Printf("Successfully unmarshalled file!")
-			log.Print(&t)
-		}
+	if conf == nil {
+		return ie2datatypes.LambdaConfig{}, errors.New("aws.config can not be empty")
+	}
 
-	} else {
-		log.Printf("Unable to read file contents!")
+	if ctx == nil {
+		return ie2datatypes.LambdaConfig{}, errors.New("context can not be empty")
 	}
 
-	return t, nil
+	return NewAWSService(*conf).ConfigParser(*ctx, bucket, key)
 }
@@ -0,0 +1,393 @@
+package ie2utilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+// endpointPath returns the full resource path for an endpoint, e.g. /v1/papers.
+// Endpoints without a version are created directly under the root.
+func endpointPath(endpoint *ie2datatypes.LambdaEndpointConfig) string {
+
+	resource := strings.Trim(endpoint.Resource, "/")
+
+	if endpoint.Version > 0 {
+		return fmt.Sprintf("/v%d/%s", endpoint.Version, resource)
+	}
+
+	return "/" + resource
+}
+
+// roleARN builds the role arn from a role name unless it already is one.
+func roleARN(accountid string, rolename string) string {
+
+	if strings.HasPrefix(rolename, "arn:") {
+		return rolename
+	}
+
+	return fmt.Sprintf("arn:aws:iam::%s:role/%s", accountid, rolename)
+}
+
+// deployLambdaInput translates a LambdaConfig into the LambdaInput used by AWSCreateLambda and AWSUpdateLambda.
+func deployLambdaInput(config *ie2datatypes.LambdaConfig, opts *ie2datatypes.DeployOptions, accountid string) *ie2datatypes.LambdaInput {
+
+	role := opts.RoleARN
+
+	if len(role) <= 0 && len(config.RoleName) > 0 {
+		role = roleARN(accountid, config.RoleName)
+	}
+
+	key := opts.S3Key
+
+	if len(key) <= 0 {
+		key = config.Filename
+	}
+
+	// with an alias Deploy publishes the version itself so it knows which one to point the alias at
+	publish := opts.Publish && len(config.Alias) <= 0
+
+	return &ie2datatypes.LambdaInput{
+		Architecture:     config.Architecture,
+		Name:             config.Name,
+		Handler:          config.Handler,
+		Publish:          publish,
+		DryRun:           opts.DryRun,
+		Force:            opts.Force,
+		RoleARN:          role,
+		Runtime:          config.Runtime,
+		S3Bucket:         opts.S3Bucket,
+		S3Key:            key,
+		ZipPath:          opts.ZipPath,
+		ZipDir:           opts.ZipDir,
+		ImageUri:         config.ImageUri,
+		MemorySize:       config.MemorySize,
+		Timeout:          config.Timeout,
+		Environment:      config.Environment,
+		VpcConfig:        config.Vpc,
+		Layers:           config.Layers,
+		EphemeralStorage: config.EphemeralStorage,
+		TracingMode:      config.TracingMode,
+		DeadLetterArn:    config.DeadLetterArn,
+		Tags:             config.Tags,
+	}
+}
+
+// deployPermissions lists every endpoint method of config that may invoke its lambda,
+// so statements for endpoints removed from config are cleaned up.
+func deployPermissions(config *ie2datatypes.LambdaConfig, accountid string, region string, apiid string) *ie2datatypes.LambdaPermissionInput {
+
+	ret := &ie2datatypes.LambdaPermissionInput{
+		LambdaName: config.Name,
+		Qualifier:  config.Alias,
+		Region:     region,
+		AccountId:  accountid,
+		ApiId:      apiid,
+	}
+
+	for i := range config.Endpoint {
+		for _, m := range config.Endpoint[i].Methods {
+			ret.Methods = append(ret.Methods, ie2datatypes.LambdaPermissionMethod{
+				Method: strings.ToUpper(m.Name),
+				Path:   endpointPath(&config.Endpoint[i]),
+			})
+		}
+	}
+
+	return ret
+}
+
+// endpointInput translates one LambdaConfig endpoint into the RESTEndpointInput used by AWSCreateLambdaIntegrations.
+func endpointInput(config *ie2datatypes.LambdaConfig, endpoint *ie2datatypes.LambdaEndpointConfig, opts *ie2datatypes.DeployOptions, accountid string, region string, apiid string, resourceid string) *ie2datatypes.RESTEndpointInput {
+
+	path := endpointPath(endpoint)
+	methods := []ie2datatypes.RESTMethod{}
+
+	for _, m := range endpoint.Methods {
+
+		method := ie2datatypes.RESTMethod{
+			Name:          strings.ToUpper(m.Name),
+			Authorization: m.Auth,
+		}
+
+		if len(m.Req) > 0 {
+			method.ReqModel = map[string]string{REST_MODEL_CONTENT_TYPE: restModelName(m.Req)}
+		}
+
+		if len(m.Res) > 0 {
+			method.ResModel = map[string]string{REST_MODEL_CONTENT_TYPE: restModelName(m.Res)}
+		}
+
+		methods = append(methods, method)
+	}
+
+	return &ie2datatypes.RESTEndpointInput{
+		AccountId:    accountid,
+		Region:       region,
+		ApiId:        apiid,
+		ResourceId:   resourceid,
+		ResourceName: strings.TrimPrefix(path, "/"),
+		Route:        path,
+		Stage:        opts.Stage,
+		Integration: &ie2datatypes.LambdaIntegration{
+			LambdaName: config.Name,
+			Qualifier:  config.Alias,
+		},
+		Methods: methods,
+		Cors:    endpoint.Cors,
+	}
+}
+
+// Deploy deploys a LambdaConfig end to end. It creates or updates the lambda,
+// publishes a version and points config.Alias at it when an alias is set,
+// registers the Req and Res schemas of endpoint methods as models, creates the versioned
+// REST resources for each endpoint (e.g. /v1/papers), integrates their methods with the
+// lambda, validating request bodies against Req, and deploys the api to opts.Stage.
+// The result records every step taken, including the one that failed.
+// With opts.DryRun nothing is changed and the result carries the plan instead.
+func (s *AWSService) Deploy(ctx context.Context, config ie2datatypes.LambdaConfig, opts ie2datatypes.DeployOptions) (*ie2datatypes.DeployResult, error) {
+
+	res := &ie2datatypes.DeployResult{}
+
+	if ctx == nil {
+		return res, errors.New("context can not be empty")
+	}
+
+	if len(config.Name) <= 0 {
+		return res, errors.New("lambda name can not be empty")
+	}
+
+	step := func(name string, action string, target string, err error) {
+		res.Steps = append(res.Steps, ie2datatypes.DeployStep{
+			Name:   name,
+			Action: action,
+			Target: target,
+			Err:    err,
+		})
+	}
+
+	region := opts.Region
+
+	if len(region) <= 0 {
+		region = s.Region
+	}
+
+	accountid := opts.AccountId
+
+	if len(accountid) <= 0 {
+
+		id, e := s.GetAccountId(ctx)
+
+		if e != nil {
+			log.Print(e)
+			return res, e
+		}
+
+		accountid = id
+	}
+
+	if opts.DryRun {
+
+		opts.AccountId = accountid
+		opts.Region = region
+
+		log.Printf("Dry run, planning the deploy of lambda %s", config.Name)
+		plan, e := s.PlanDeploy(ctx, config, opts)
+		res.Plan = plan
+
+		return res, e
+	}
+
+	// lambda
+	input := deployLambdaInput(&config, &opts, accountid)
+
+	log.Printf("Deploying lambda %s", input.Name)
+	exists, e := s.LambdaExists(ctx, input.Name)
+
+	if e != nil {
+		step("lambda", "", input.Name, e)
+		return res, e
+	}
+
+	if exists {
+
+		var updated *ie2datatypes.LambdaUpdateResult
+		updated, e = s.UpdateLambda(ctx, input)
+		action := ie2datatypes.DeployActionUpdate
+
+		if updated != nil && updated.Unchanged() {
+			action = ie2datatypes.DeployActionUnchanged
+		}
+
+		step("lambda", action, input.Name, e)
+
+	} else {
+		e = s.CreateLambda(ctx, input)
+		step("lambda", ie2datatypes.DeployActionCreate, input.Name, e)
+	}
+
+	if e != nil {
+		log.Print(e)
+		return res, e
+	}
+
+	// alias, publish what was just deployed and point the alias at it
+	if len(config.Alias) > 0 {
+
+		version, e := s.PublishLambdaVersion(ctx, input.Name, "")
+		step("version", ie2datatypes.DeployActionPublish, fmt.Sprintf("%s:%s", input.Name, version), e)
+
+		if e != nil {
+			log.Print(e)
+			return res, e
+		}
+
+		created, e := s.SetLambdaAlias(ctx, input.Name, config.Alias, version)
+		action := ie2datatypes.DeployActionUpdate
+
+		if created {
+			action = ie2datatypes.DeployActionCreate
+		}
+
+		step("alias", action, fmt.Sprintf("%s:%s@%s", input.Name, config.Alias, version), e)
+
+		if e != nil {
+			log.Print(e)
+			return res, e
+		}
+	}
+
+	if len(config.Endpoint) <= 0 {
+		log.Printf("Lambda %s has no endpoints, nothing else to deploy.", input.Name)
+		return res, nil
+	}
+
+	if len(opts.Stage) <= 0 {
+		return res, errors.New("stage can not be empty when deploying endpoints")
+	}
+
+	// rest api
+	apiid := opts.ApiId
+
+	if len(apiid) <= 0 {
+
+		if len(opts.ApiName) <= 0 {
+			return res, errors.New("either an api id or an api name is required when deploying endpoints")
+		}
+
+		apiid, e = s.GetRESTApiIdFromName(ctx, opts.ApiName)
+
+		if e != nil {
+			return res, e
+		}
+
+		if len(apiid) <= 0 {
+			e = fmt.Errorf("rest api %s does not exist", opts.ApiName)
+			log.Print(e)
+			return res, e
+		}
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return res, e
+	}
+
+	// models, methods can't refer to them until they exist
+	models, e := s.deployModels(ctx, &config, &opts)
+
+	if e != nil {
+		step("model", "", config.Name, e)
+		return res, e
+	}
+
+	for i := range models {
+
+		created, e := s.PutRESTModel(ctx, apiid, &models[i])
+		action := ie2datatypes.DeployActionUpdate
+
+		if created {
+			action = ie2datatypes.DeployActionCreate
+		}
+
+		step("model", action, fmt.Sprintf("%s/%s", apiid, models[i].Name), e)
+
+		if e != nil {
+			log.Print(e)
+			return res, e
+		}
+	}
+
+	for i := range config.Endpoint {
+
+		endpoint := &config.Endpoint[i]
+		path := endpointPath(endpoint)
+
+		log.Printf("Deploying endpoint %s", path)
+		resourceid, created, e := s.createRESTResourcePath(c, ctx, apiid, path)
+
+		action := ie2datatypes.DeployActionExists
+
+		if created {
+			action = ie2datatypes.DeployActionCreate
+		}
+
+		step("resource", action, path, e)
+
+		if e != nil {
+			log.Print(e)
+			return res, e
+		}
+
+		e = s.createLambdaIntegrations(ctx, endpointInput(&config, endpoint, &opts, accountid, region, apiid, resourceid))
+		step("integration", ie2datatypes.DeployActionUpdate, path, e)
+
+		if e != nil {
+			log.Print(e)
+			return res, e
+		}
+	}
+
+	// permissions, drop the ones left behind by endpoints that are gone
+	_, e = s.ReconcileApiGatewayPermissions(ctx, deployPermissions(&config, accountid, region, apiid))
+	step("permission", ie2datatypes.DeployActionUpdate, config.Name, e)
+
+	if e != nil {
+		log.Print(e)
+		return res, e
+	}
+
+	deploymentid, e := deployRESTStage(c, ctx, apiid, opts.Stage)
+	step("stage", ie2datatypes.DeployActionDeploy, fmt.Sprintf("%s/%s@%s", apiid, opts.Stage, deploymentid), e)
+
+	if e != nil {
+		log.Print(e)
+		return res, e
+	}
+
+	log.Printf("Successfully deployed lambda %s", input.Name)
+
+	return res, nil
+}
+
+// AWSDeploy is AWSService.Deploy using clients built from conf.
+//
+// Deprecated: use AWSService.Deploy, which takes a context.Context.
+func AWSDeploy(conf *aws.Config, ctx *context.Context, config ie2datatypes.LambdaConfig, opts ie2datatypes.DeployOptions) (*ie2datatypes.DeployResult, error) {
+
+	if conf == nil {
+		return &ie2datatypes.DeployResult{}, errors.New("aws.config can not be empty")
+	}
+
+	if ctx == nil {
+		return &ie2datatypes.DeployResult{}, errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).Deploy(*ctx, config, opts)
+}
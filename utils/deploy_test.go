@@ -0,0 +1,325 @@
+package ie2utilities_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	s3api "github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	ie2testing "github.com/insightengine2/ie2-utilities/testing"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+	ie2utilities "github.com/insightengine2/ie2-utilities/utils"
+)
+
+// apiChanges drops the plan changes to the lambda and its alias, leaving those to the api.
+func apiChanges(plan *ie2datatypes.DeployPlan) []ie2datatypes.PlanChange {
+
+	ret := []ie2datatypes.PlanChange{}
+
+	for _, change := range plan.Changes {
+		if change.Kind != ie2datatypes.PlanKindLambda && change.Kind != ie2datatypes.PlanKindAlias {
+			ret = append(ret, change)
+		}
+	}
+
+	return ret
+}
+
+func TestDeploy(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		alias        string
+		publish      bool
+		noChecksum   bool
+		endpoints    []ie2datatypes.LambdaEndpointConfig
+		wantPath     string
+		wantMethods  []string
+		wantVersions int
+		wantUnknown  []string
+	}{
+		{
+			name: "lambda only",
+		},
+		{
+			name:         "code without a checksum",
+			alias:        "live",
+			noChecksum:   true,
+			wantVersions: 1,
+			wantUnknown:  []string{ie2datatypes.PlanKindLambda, ie2datatypes.PlanKindAlias},
+		},
+		{
+			name:         "alias",
+			alias:        "live",
+			publish:      true,
+			endpoints:    []ie2datatypes.LambdaEndpointConfig{{Version: 1, Resource: "papers", Methods: []ie2datatypes.LambdaMethodConfig{{Name: "get"}}}},
+			wantPath:     "/v1/papers",
+			wantMethods:  []string{"GET"},
+			wantVersions: 1,
+		},
+		{
+			name:        "endpoint",
+			endpoints:   []ie2datatypes.LambdaEndpointConfig{{Version: 1, Resource: "papers", Methods: []ie2datatypes.LambdaMethodConfig{{Name: "get"}, {Name: "post"}}}},
+			wantPath:    "/v1/papers",
+			wantMethods: []string{"GET", "POST"},
+		},
+		{
+			name:        "nested endpoint",
+			endpoints:   []ie2datatypes.LambdaEndpointConfig{{Version: 2, Resource: "papers/{id}", Methods: []ie2datatypes.LambdaMethodConfig{{Name: "delete"}}}},
+			wantPath:    "/v2/papers/{id}",
+			wantMethods: []string{"DELETE"},
+		},
+		{
+			name:        "cors",
+			endpoints:   []ie2datatypes.LambdaEndpointConfig{{Version: 1, Resource: "papers", Methods: []ie2datatypes.LambdaMethodConfig{{Name: "get"}}, Cors: &ie2datatypes.RESTCors{Origins: []string{"https://app.example.com"}}}},
+			wantPath:    "/v1/papers",
+			wantMethods: []string{"GET", "OPTIONS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx := context.Background()
+			fl := ie2testing.NewFakeLambda()
+			fa := ie2testing.NewFakeAPIGateway()
+			apiid := fa.AddRestApi("papers")
+
+			s3 := ie2testing.NewFakeS3()
+			upload := &s3api.PutObjectInput{Bucket: aws.String("artifacts"), Key: aws.String("papers.zip"), Body: strings.NewReader("bootstrap")}
+
+			// without a checksum the plan can't tell whether the code changed
+			if !tt.noChecksum {
+				upload.ChecksumAlgorithm = s3types.ChecksumAlgorithmSha256
+			}
+
+			if _, e := s3.PutObject(ctx, upload); e != nil {
+				t.Fatal(e)
+			}
+
+			fl.S3 = s3
+
+			s := &ie2utilities.AWSService{Region: "us-east-1", Lambda: fl, APIGateway: fa, S3: s3, STS: ie2testing.NewFakeSTS()}
+			config := ie2datatypes.LambdaConfig{Name: "papers", Handler: "bootstrap", Runtime: "provided.al2023", Alias: tt.alias, Endpoint: tt.endpoints}
+			opts := ie2datatypes.DeployOptions{RoleARN: "arn:aws:iam::123456789012:role/papers", S3Bucket: "artifacts", S3Key: "papers.zip", ApiId: apiid, Stage: "dev", Publish: tt.publish}
+
+			res, e := s.Deploy(ctx, config, opts)
+
+			if e != nil {
+				t.Fatalf("Deploy() error = %v", e)
+			}
+
+			for _, step := range res.Steps {
+				if step.Err != nil {
+					t.Errorf("step %s %s failed: %v", step.Name, step.Target, step.Err)
+				}
+			}
+
+			if _, ok := fl.Functions["papers"]; !ok {
+				t.Fatal("lambda papers was not created")
+			}
+
+			if len(tt.wantPath) > 0 {
+
+				methods := map[string]bool{}
+
+				for _, r := range fa.Apis[apiid].Resources {
+					if aws.ToString(r.Path) == tt.wantPath {
+						for name := range r.ResourceMethods {
+							methods[name] = true
+						}
+					}
+				}
+
+				for _, name := range tt.wantMethods {
+					if !methods[name] {
+						t.Errorf("method %s %s was not created", name, tt.wantPath)
+					}
+				}
+			}
+
+			plan, e := s.PlanDeploy(ctx, config, opts)
+
+			if e != nil {
+				t.Fatalf("PlanDeploy() error = %v", e)
+			}
+
+			unknown := []string{}
+
+			for _, change := range plan.Changes {
+
+				if change.Action != ie2datatypes.PlanActionUnknown {
+					t.Errorf("PlanDeploy() after Deploy has change %+v, want none", change)
+				}
+
+				unknown = append(unknown, change.Kind)
+			}
+
+			if !slices.Equal(unknown, tt.wantUnknown) {
+				t.Errorf("PlanDeploy() after Deploy is unknown for %v, want %v", unknown, tt.wantUnknown)
+			}
+
+			// deploying again updates the lambda and leaves the api as it is
+			_, e = s.Deploy(ctx, config, opts)
+
+			if e != nil {
+				t.Fatalf("second Deploy() error = %v", e)
+			}
+
+			// the alias gets one version, and unchanged code doesn't publish another
+			if len(fl.Versions["papers"]) != tt.wantVersions {
+				t.Errorf("published %d versions, want %d", len(fl.Versions["papers"]), tt.wantVersions)
+			}
+
+			if _, ok := fl.Aliases["papers"][tt.alias]; len(tt.alias) > 0 && !ok {
+				t.Errorf("alias %s was not created", tt.alias)
+			}
+		})
+	}
+}
+
+func TestDeployDryRun(t *testing.T) {
+
+	ctx := context.Background()
+	fl := ie2testing.NewFakeLambda()
+	fa := ie2testing.NewFakeAPIGateway()
+	apiid := fa.AddRestApi("papers")
+
+	s := &ie2utilities.AWSService{Region: "us-east-1", Lambda: fl, APIGateway: fa, S3: ie2testing.NewFakeS3(), STS: ie2testing.NewFakeSTS()}
+	config := ie2datatypes.LambdaConfig{
+		Name:     "papers",
+		Handler:  "bootstrap",
+		Runtime:  "provided.al2023",
+		Endpoint: []ie2datatypes.LambdaEndpointConfig{{Version: 1, Resource: "papers", Methods: []ie2datatypes.LambdaMethodConfig{{Name: "get"}}}},
+	}
+	opts := ie2datatypes.DeployOptions{RoleARN: "arn:aws:iam::123456789012:role/papers", S3Bucket: "artifacts", S3Key: "papers.zip", ApiId: apiid, Stage: "dev", DryRun: true}
+
+	res, e := s.Deploy(ctx, config, opts)
+
+	if e != nil {
+		t.Fatalf("Deploy() error = %v", e)
+	}
+
+	if len(res.Steps) > 0 || res.Plan == nil || len(res.Plan.Changes) <= 0 {
+		t.Errorf("dry run result = %+v, want only a plan", res)
+	}
+
+	if _, ok := fl.Functions["papers"]; ok {
+		t.Error("dry run created the lambda")
+	}
+
+	if len(fa.Apis[apiid].Resources) != 1 {
+		t.Errorf("dry run created %d resources", len(fa.Apis[apiid].Resources)-1)
+	}
+}
+
+func TestImportOpenAPI(t *testing.T) {
+
+	dir := t.TempDir()
+	e := os.WriteFile(filepath.Join(dir, "paper-request.json"), []byte(`{"type":"object","required":["title"]}`), 0o644)
+
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	config := ie2datatypes.LambdaConfig{
+		Name:    "papers",
+		Handler: "bootstrap",
+		Runtime: "provided.al2023",
+		Alias:   "live",
+		Endpoint: []ie2datatypes.LambdaEndpointConfig{{
+			Version:  1,
+			Resource: "papers",
+			Methods:  []ie2datatypes.LambdaMethodConfig{{Name: "get"}, {Name: "post", Req: "paper-request.json"}},
+			Cors:     &ie2datatypes.RESTCors{Origins: []string{"https://app.example.com"}},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		mode     string
+		document string
+		twice    bool
+		wantErr  bool
+	}{
+		{name: "overwrite", mode: ie2datatypes.OpenAPIModeOverwrite},
+		{name: "merge", mode: ie2datatypes.OpenAPIModeMerge},
+		{name: "overwrite again", mode: ie2datatypes.OpenAPIModeOverwrite, twice: true},
+		{name: "merge again", mode: ie2datatypes.OpenAPIModeMerge, twice: true},
+		{name: "unknown mode", mode: "replace", wantErr: true},
+		{name: "swagger 2", document: "swagger: '2.0'\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx := context.Background()
+			fa := ie2testing.NewFakeAPIGateway()
+			apiid := fa.AddRestApi("papers")
+
+			s := &ie2utilities.AWSService{Region: "us-east-1", Lambda: ie2testing.NewFakeLambda(), APIGateway: fa, STS: ie2testing.NewFakeSTS()}
+			opts := ie2datatypes.DeployOptions{RoleARN: "arn:aws:iam::123456789012:role/papers", S3Bucket: "artifacts", S3Key: "papers.zip", ApiId: apiid, Stage: "dev", SchemaDir: dir}
+
+			body := []byte(tt.document)
+
+			if len(body) <= 0 {
+
+				doc, e := s.GenerateOpenAPI(ctx, []ie2datatypes.LambdaConfig{config}, ie2datatypes.OpenAPIOptions{SchemaDir: dir})
+
+				if e != nil {
+					t.Fatalf("GenerateOpenAPI() error = %v", e)
+				}
+
+				body, e = json.Marshal(doc)
+
+				if e != nil {
+					t.Fatal(e)
+				}
+			}
+
+			input := &ie2datatypes.OpenAPIImportInput{ApiName: "papers", Document: body, Mode: tt.mode, Stage: "dev"}
+
+			if tt.twice {
+				if e := s.ImportOpenAPI(ctx, input); e != nil {
+					t.Fatalf("first ImportOpenAPI() error = %v", e)
+				}
+			}
+
+			e := s.ImportOpenAPI(ctx, input)
+
+			if (e != nil) != tt.wantErr {
+				t.Fatalf("ImportOpenAPI() error = %v, wantErr %v", e, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			// the imported api is what Deploy would have built
+			plan, e := s.PlanDeploy(ctx, config, opts)
+
+			if e != nil {
+				t.Fatalf("PlanDeploy() error = %v", e)
+			}
+
+			changes := []ie2datatypes.PlanChange{}
+
+			// permissions are not part of the document
+			for _, change := range apiChanges(plan) {
+				if change.Kind != ie2datatypes.PlanKindPermission {
+					changes = append(changes, change)
+				}
+			}
+
+			if len(changes) > 0 {
+				t.Errorf("PlanDeploy() after ImportOpenAPI = %+v, want no changes", changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package ie2utilities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+type DocumentFormat string
+
+const (
+	DocumentFormatUnknown DocumentFormat = ""
+	DocumentFormatYAML    DocumentFormat = "yaml"
+	DocumentFormatJSON    DocumentFormat = "json"
+	DocumentFormatTOML    DocumentFormat = "toml"
+)
+
+var ErrDocumentNotFound = errors.New("document not found")
+var ErrDocumentEmpty = errors.New("document is empty")
+
+// DocumentDecodeError is returned when a document was read successfully
+// but could not be decoded into the requested type.
+type DocumentDecodeError struct {
+	Bucket string
+	Key    string
+	Format DocumentFormat
+	Err    error
+}
+
+func (e *DocumentDecodeError) Error() string {
+
+	if len(e.Key) > 0 {
+		return fmt.Sprintf("failed to decode %s document %s/%s: %v", e.Format, e.Bucket, e.Key, e.Err)
+	}
+
+	return fmt.Sprintf("failed to decode %s document: %v", e.Format, e.Err)
+}
+
+func (e *DocumentDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DetectDocumentFormat works out the format of a document using its content type first
+// and falling back to the extension of the key.
+func DetectDocumentFormat(contentType string, key string) DocumentFormat {
+
+	if len(contentType) > 0 {
+
+		mediatype, _, err := mime.ParseMediaType(contentType)
+
+		if err == nil {
+			switch {
+			case mediatype == "application/json" || strings.HasSuffix(mediatype, "+json"):
+				return DocumentFormatJSON
+			case mediatype == "application/yaml" || mediatype == "application/x-yaml" || mediatype == "text/yaml" || mediatype == "text/x-yaml":
+				return DocumentFormatYAML
+			case mediatype == "application/toml" || mediatype == "text/toml":
+				return DocumentFormatTOML
+			}
+		}
+	}
+
+	switch strings.ToLower(path.Ext(key)) {
+	case ".json":
+		return DocumentFormatJSON
+	case ".yaml", ".yml":
+		return DocumentFormatYAML
+	case ".toml":
+		return DocumentFormatTOML
+	}
+
+	return DocumentFormatUnknown
+}
+
+// sniffDocumentFormat is used when neither the content type nor the key tell us anything.
+// JSON documents start with an object or an array, everything else is treated as YAML.
+func sniffDocumentFormat(data []byte) DocumentFormat {
+
+	trimmed := bytes.TrimSpace(data)
+
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return DocumentFormatJSON
+	}
+
+	return DocumentFormatYAML
+}
+
+// DecodeDocument decodes data into a T, rejecting any fields T does not define.
+// If format is DocumentFormatUnknown the format is guessed from the content.
+func DecodeDocument[T any](data []byte, format DocumentFormat) (T, error) {
+
+	var ret T
+
+	if len(bytes.TrimSpace(data)) <= 0 {
+		return ret, ErrDocumentEmpty
+	}
+
+	if format == DocumentFormatUnknown {
+		format = sniffDocumentFormat(data)
+	}
+
+	var err error
+
+	switch format {
+	case DocumentFormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&ret)
+
+		// make sure there is nothing but whitespace after the first value
+		if err == nil {
+			if _, e := dec.Token(); e != io.EOF {
+				err = errors.New("unexpected data after top-level json value")
+			}
+		}
+
+	case DocumentFormatYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&ret)
+
+	case DocumentFormatTOML:
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&ret)
+
+	default:
+		err = fmt.Errorf("unsupported document format %s", format)
+	}
+
+	if err != nil {
+		return ret, &DocumentDecodeError{Format: format, Err: err}
+	}
+
+	return ret, nil
+}
+
+// LoadS3Document retrieves bucket/key from S3 and decodes it into a T.
+//
+// Deprecated: use LoadS3DocumentContext, which takes a context.Context.
+func LoadS3Document[T any](conf *aws.Config, ctx *context.Context, bucket string, key string) (T, error) {
+
+	var ret T
+
+	if conf == nil {
+		return ret, errors.New("aws.config can not be empty")
+	}
+
+	if ctx == nil {
+		return ret, errors.New("context can not be empty")
+	}
+
+	log.Printf("Create S3 client from config.")
+
+	return LoadS3DocumentContext[T](*ctx, s3.NewFromConfig(*conf), bucket, key)
+}
+
+// LoadS3DocumentContext retrieves bucket/key from S3 using client and decodes it into a T.
+// Missing objects return ErrDocumentNotFound, empty objects ErrDocumentEmpty
+// and anything that fails to decode a *DocumentDecodeError.
+func LoadS3DocumentContext[T any](ctx context.Context, client S3API, bucket string, key string) (T, error) {
+
+	var ret T
+
+	if client == nil {
+		return ret, errors.New("s3 client can not be empty")
+	}
+
+	if ctx == nil {
+		return ret, errors.New("context can not be empty")
+	}
+
+	log.Printf("Attempting to load document: %s", bucket+"/"+key)
+
+	res, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+
+		if IsAWSNotFound(err) {
+			log.Printf("Document %s/%s does not exist.", bucket, key)
+			return ret, fmt.Errorf("%w: %s/%s", ErrDocumentNotFound, bucket, key)
+		}
+
+		log.Printf("Error reading from S3.")
+		log.Printf("Bucket: %s", bucket)
+		log.Printf("Key: %s", key)
+		return ret, err
+	}
+
+	defer res.Body.Close()
+
+	buffer := new(bytes.Buffer)
+	readBytes, err := buffer.ReadFrom(res.Body)
+
+	if err != nil {
+		log.Printf("Error reading file!")
+		return ret, err
+	}
+
+	if readBytes <= 0 {
+		log.Printf("Document %s/%s is empty.", bucket, key)
+		return ret, fmt.Errorf("%w: %s/%s", ErrDocumentEmpty, bucket, key)
+	}
+
+	log.Printf("Successfully read %d bytes.", readBytes)
+
+	format := DetectDocumentFormat(aws.ToString(res.ContentType), key)
+
+	if format == DocumentFormatUnknown {
+		format = sniffDocumentFormat(buffer.Bytes())
+	}
+
+	log.Printf("Preparing to decode %s document.", format)
+	ret, err = DecodeDocument[T](buffer.Bytes(), format)
+
+	if err != nil {
+
+		var de *DocumentDecodeError
+
+		if errors.As(err, &de) {
+			de.Bucket = bucket
+			de.Key = key
+		} else if errors.Is(err, ErrDocumentEmpty) {
+			return ret, fmt.Errorf("%w: %s/%s", ErrDocumentEmpty, bucket, key)
+		}
+
+		log.Print(err)
+		return ret, err
+	}
+
+	log.Printf("Successfully decoded document!")
+
+	return ret, nil
+}
@@ -0,0 +1,122 @@
+package ie2utilities
+
+import (
+	"errors"
+	"testing"
+)
+
+type testDocument struct {
+	Name  string   `json:"name" yaml:"name" toml:"name"`
+	Count int      `json:"count" yaml:"count" toml:"count"`
+	Tags  []string `json:"tags" yaml:"tags" toml:"tags"`
+}
+
+func TestDetectDocumentFormat(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		contentType string
+		key         string
+		want        DocumentFormat
+	}{
+		{name: "json content type", contentType: "application/json; charset=utf-8", key: "config", want: DocumentFormatJSON},
+		{name: "json suffix content type", contentType: "application/vnd.ie2+json", key: "config", want: DocumentFormatJSON},
+		{name: "yaml content type", contentType: "application/x-yaml", key: "config", want: DocumentFormatYAML},
+		{name: "toml content type", contentType: "application/toml", key: "config", want: DocumentFormatTOML},
+		{name: "content type wins over extension", contentType: "application/json", key: "config.yaml", want: DocumentFormatJSON},
+		{name: "generic content type falls back to extension", contentType: "binary/octet-stream", key: "config.yml", want: DocumentFormatYAML},
+		{name: "invalid content type falls back to extension", contentType: ";;", key: "config.TOML", want: DocumentFormatTOML},
+		{name: "json extension", key: "lambdas/papers.json", want: DocumentFormatJSON},
+		{name: "unknown", key: "config.txt", want: DocumentFormatUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if got := DetectDocumentFormat(tt.contentType, tt.key); got != tt.want {
+				t.Errorf("DetectDocumentFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSniffDocumentFormat(t *testing.T) {
+
+	tests := []struct {
+		name string
+		data string
+		want DocumentFormat
+	}{
+		{name: "object", data: `{"name":"papers"}`, want: DocumentFormatJSON},
+		{name: "array", data: `[1, 2]`, want: DocumentFormatJSON},
+		{name: "leading whitespace", data: "\n\t  {\"name\":\"papers\"}", want: DocumentFormatJSON},
+		{name: "yaml", data: "name: papers\n", want: DocumentFormatYAML},
+		{name: "toml is read as yaml", data: "name = \"papers\"\n", want: DocumentFormatYAML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if got := sniffDocumentFormat([]byte(tt.data)); got != tt.want {
+				t.Errorf("sniffDocumentFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeDocument(t *testing.T) {
+
+	papers := testDocument{Name: "papers", Count: 2, Tags: []string{"a", "b"}}
+
+	tests := []struct {
+		name       string
+		data       string
+		format     DocumentFormat
+		want       testDocument
+		wantEmpty  bool
+		wantDecode bool
+	}{
+		{name: "json", data: `{"name":"papers","count":2,"tags":["a","b"]}`, format: DocumentFormatJSON, want: papers},
+		{name: "yaml", data: "name: papers\ncount: 2\ntags: [a, b]\n", format: DocumentFormatYAML, want: papers},
+		{name: "toml", data: "name = \"papers\"\ncount = 2\ntags = [\"a\", \"b\"]\n", format: DocumentFormatTOML, want: papers},
+		{name: "sniffed json", data: `{"name":"papers","count":2,"tags":["a","b"]}`, want: papers},
+		{name: "sniffed yaml", data: "name: papers\ncount: 2\ntags: [a, b]\n", want: papers},
+		{name: "unknown json field", data: `{"name":"papers","size":3}`, format: DocumentFormatJSON, wantDecode: true},
+		{name: "unknown yaml field", data: "name: papers\nsize: 3\n", format: DocumentFormatYAML, wantDecode: true},
+		{name: "unknown toml field", data: "name = \"papers\"\nsize = 3\n", format: DocumentFormatTOML, wantDecode: true},
+		{name: "trailing json", data: `{"name":"papers"} {"name":"other"}`, format: DocumentFormatJSON, wantDecode: true},
+		{name: "wrong type", data: `{"count":"two"}`, format: DocumentFormatJSON, wantDecode: true},
+		{name: "unsupported format", data: "name: papers", format: DocumentFormat("xml"), wantDecode: true},
+		{name: "empty", data: "", format: DocumentFormatJSON, wantEmpty: true},
+		{name: "whitespace", data: " \n\t ", wantEmpty: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got, e := DecodeDocument[testDocument]([]byte(tt.data), tt.format)
+
+			if errors.Is(e, ErrDocumentEmpty) != tt.wantEmpty {
+				t.Fatalf("DecodeDocument() error = %v, want ErrDocumentEmpty %v", e, tt.wantEmpty)
+			}
+
+			var de *DocumentDecodeError
+
+			if errors.As(e, &de) != tt.wantDecode {
+				t.Fatalf("DecodeDocument() error = %v, want *DocumentDecodeError %v", e, tt.wantDecode)
+			}
+
+			if tt.wantDecode && (de.Err == nil || errors.Unwrap(e) != de.Err) {
+				t.Errorf("DocumentDecodeError does not unwrap to its cause: %v", e)
+			}
+
+			if e != nil {
+				return
+			}
+
+			if got.Name != tt.want.Name || got.Count != tt.want.Count || len(got.Tags) != len(tt.want.Tags) {
+				t.Errorf("DecodeDocument() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package ie2utilities
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+var ErrNotFound = errors.New("aws resource not found")
+var ErrThrottled = errors.New("aws request throttled")
+var ErrAccessDenied = errors.New("aws access denied")
+var ErrConflict = errors.New("aws resource conflict")
+
+// AWSError is an error returned by an AWS call that has been classified
+// as one of ErrNotFound, ErrThrottled, ErrAccessDenied or ErrConflict.
+// Both the sentinel and the original SDK error can be matched with errors.Is and errors.As.
+type AWSError struct {
+	Kind error
+	Err  error
+}
+
+func (e *AWSError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AWSError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// awsErrorKinds maps the API error codes returned by the services we call to a sentinel.
+var awsErrorKinds = map[string]error{
+	"NotFoundException":         ErrNotFound,
+	"ResourceNotFoundException": ErrNotFound,
+	"NoSuchKey":                 ErrNotFound,
+	"NoSuchBucket":              ErrNotFound,
+	"NotFound":                  ErrNotFound,
+	"TooManyRequestsException":  ErrThrottled,
+	"ThrottlingException":       ErrThrottled,
+	"Throttling":                ErrThrottled,
+	"RequestLimitExceeded":      ErrThrottled,
+	"SlowDown":                  ErrThrottled,
+	"AccessDenied":              ErrAccessDenied,
+	"AccessDeniedException":     ErrAccessDenied,
+	"UnauthorizedException":     ErrAccessDenied,
+	"ConflictException":         ErrConflict,
+	"ResourceConflictException": ErrConflict,
+}
+
+// awsStatusKinds is used when the error code isn't one we know about.
+var awsStatusKinds = map[int]error{
+	http.StatusNotFound:        ErrNotFound,
+	http.StatusTooManyRequests: ErrThrottled,
+	http.StatusForbidden:       ErrAccessDenied,
+	http.StatusConflict:        ErrConflict,
+}
+
+// ClassifyAWSError wraps err in an *AWSError when its API error code (or failing that
+// its HTTP status) tells us what went wrong. Anything else is returned unchanged.
+func ClassifyAWSError(err error) error {
+
+	if err == nil {
+		return nil
+	}
+
+	var classified *AWSError
+
+	if errors.As(err, &classified) {
+		return err
+	}
+
+	var apiErr smithy.APIError
+
+	if errors.As(err, &apiErr) {
+		if kind, ok := awsErrorKinds[apiErr.ErrorCode()]; ok {
+			return &AWSError{Kind: kind, Err: err}
+		}
+	}
+
+	var resErr *smithyhttp.ResponseError
+
+	if errors.As(err, &resErr) {
+		if kind, ok := awsStatusKinds[resErr.HTTPStatusCode()]; ok {
+			return &AWSError{Kind: kind, Err: err}
+		}
+	}
+
+	return err
+}
+
+// IsAWSNotFound reports whether err is an AWS not found error.
+func IsAWSNotFound(err error) bool {
+	return errors.Is(ClassifyAWSError(err), ErrNotFound)
+}
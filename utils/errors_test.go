@@ -0,0 +1,119 @@
+package ie2utilities
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// sdkError wraps err the way the SDK does, in an operation error around an http response error.
+func sdkError(status int, err error) error {
+
+	return &smithy.OperationError{
+		ServiceID:     "Test",
+		OperationName: "Call",
+		Err: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      err,
+		},
+	}
+}
+
+func TestClassifyAWSError(t *testing.T) {
+
+	classified := &AWSError{Kind: ErrConflict, Err: errors.New("already classified")}
+
+	tests := []struct {
+		name         string
+		err          error
+		want         error
+		wantNotFound bool
+	}{
+		{name: "nil", err: nil},
+		{name: "ResourceNotFoundException", err: sdkError(404, &lambdatypes.ResourceNotFoundException{Message: aws.String("Function not found")}), want: ErrNotFound, wantNotFound: true},
+		{name: "NotFoundException", err: sdkError(404, &smithy.GenericAPIError{Code: "NotFoundException"}), want: ErrNotFound, wantNotFound: true},
+		{name: "NoSuchKey", err: sdkError(404, &s3types.NoSuchKey{}), want: ErrNotFound, wantNotFound: true},
+		{name: "TooManyRequestsException", err: sdkError(429, &smithy.GenericAPIError{Code: "TooManyRequestsException"}), want: ErrThrottled},
+		{name: "ThrottlingException", err: sdkError(400, &smithy.GenericAPIError{Code: "ThrottlingException"}), want: ErrThrottled},
+		{name: "AccessDenied", err: sdkError(403, &smithy.GenericAPIError{Code: "AccessDenied"}), want: ErrAccessDenied},
+		{name: "ConflictException", err: sdkError(409, &smithy.GenericAPIError{Code: "ConflictException"}), want: ErrConflict},
+		{name: "ResourceConflictException", err: sdkError(409, &lambdatypes.ResourceConflictException{}), want: ErrConflict},
+		{name: "code without a response", err: &smithy.GenericAPIError{Code: "ThrottlingException"}, want: ErrThrottled},
+		{name: "unknown code falls back to status", err: sdkError(404, &smithy.GenericAPIError{Code: "NotFound404"}), want: ErrNotFound, wantNotFound: true},
+		{name: "unknown code and status", err: sdkError(400, &smithy.GenericAPIError{Code: "ValidationException"})},
+		{name: "already classified", err: classified, want: ErrConflict},
+		{name: "wrapped by caller", err: fmt.Errorf("deploying: %w", sdkError(404, &smithy.GenericAPIError{Code: "NotFoundException"})), want: ErrNotFound, wantNotFound: true},
+		{name: "not an aws error", err: errors.New("connection reset")},
+		{name: "not found outside aws", err: fs.ErrNotExist},
+	}
+
+	sentinels := []error{ErrNotFound, ErrThrottled, ErrAccessDenied, ErrConflict}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got := ClassifyAWSError(tt.err)
+
+			if IsAWSNotFound(tt.err) != tt.wantNotFound {
+				t.Errorf("IsAWSNotFound() = %v, want %v", !tt.wantNotFound, tt.wantNotFound)
+			}
+
+			if tt.err == nil {
+
+				if got != nil {
+					t.Errorf("ClassifyAWSError(nil) = %v", got)
+				}
+
+				return
+			}
+
+			for _, sentinel := range sentinels {
+				if errors.Is(got, sentinel) != (sentinel == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", got, sentinel, !(sentinel == tt.want))
+				}
+			}
+
+			// the original error is still there to be matched and read
+			if !errors.Is(got, tt.err) {
+				t.Errorf("ClassifyAWSError() = %v, does not wrap %v", got, tt.err)
+			}
+
+			if got.Error() != tt.err.Error() {
+				t.Errorf("ClassifyAWSError().Error() = %q, want %q", got.Error(), tt.err.Error())
+			}
+
+			var awsErr *AWSError
+
+			if errors.As(got, &awsErr) != (tt.want != nil) {
+				t.Errorf("ClassifyAWSError() = %T, want an *AWSError %v", got, tt.want != nil)
+			}
+
+			if awsErr != nil && got != tt.err {
+
+				unwrapped := awsErr.Unwrap()
+
+				if len(unwrapped) != 2 || unwrapped[0] != tt.want || unwrapped[1] != tt.err {
+					t.Errorf("AWSError.Unwrap() = %v, want [%v %v]", unwrapped, tt.want, tt.err)
+				}
+			}
+
+			if tt.want == nil && got != tt.err {
+				t.Errorf("ClassifyAWSError() = %v, want the error unchanged", got)
+			}
+
+			var apiErr smithy.APIError
+
+			if errors.As(tt.err, &apiErr) && !errors.As(got, &apiErr) {
+				t.Errorf("ClassifyAWSError() = %v, lost the smithy.APIError", got)
+			}
+		})
+	}
+}
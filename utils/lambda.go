@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
@@ -13,49 +12,44 @@ import (
 	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
 )
 
-func AWSLambdaExists(conf *aws.Config, ctx *context.Context, name string) (bool, error) {
+func (s *AWSService) LambdaExists(ctx context.Context, name string) (bool, error) {
 
 	if len(name) <= 0 {
 		e := errors.New("function name can not be empty")
 		return false, e
 	}
 
-	if conf == nil {
-		e := errors.New("aws.config can not be empty")
-		return false, e
-	}
-
 	if ctx == nil {
 		e := errors.New("context can not be empty")
 		return false, e
 	}
 
-	c := lambda.NewFromConfig(*conf)
+	c, e := s.lambdaClient()
 
-	if c == nil {
-		e := errors.New("failed to create lambda client using provided config")
+	if e != nil {
 		return false, e
 	}
 
-	_, err := c.GetFunction(*ctx, &lambda.GetFunctionInput{
+	_, err := c.GetFunction(ctx, &lambda.GetFunctionInput{
 		FunctionName: aws.String(name),
 	})
 
 	if err != nil {
-		return false, nil
+
+		err = ClassifyAWSError(err)
+
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+
+		log.Print(err)
+		return false, err
 	}
 
 	return true, nil
 }
 
-func AWSCreateLambda(
-	conf *aws.Config,
-	ctx *context.Context,
-	input *ie2datatypes.LambdaInput) error {
-
-	if conf == nil {
-		return errors.New("aws.config can not be empty")
-	}
+func (s *AWSService) CreateLambda(ctx context.Context, input *ie2datatypes.LambdaInput) error {
 
 	if ctx == nil {
 		return errors.New("context can not be empty")
@@ -65,136 +59,187 @@ func AWSCreateLambda(
 		return errors.New("lambdaconfig can not be empty")
 	}
 
-	c := lambda.NewFromConfig(*conf)
+	c, e := s.lambdaClient()
 
-	_, e := c.CreateFunction(*ctx, &lambda.CreateFunctionInput{
-		Architectures: []types.Architecture{types.Architecture(input.Architecture)},
-		Code: &types.FunctionCode{
-			S3Bucket: aws.String(input.S3Bucket),
-			S3Key:    aws.String(input.S3Key),
-		},
-		FunctionName: aws.String(input.Name),
-		Handler:      aws.String(input.Handler),
-		Publish:      *aws.Bool(input.Publish),
-		Role:         aws.String(input.RoleARN),
-		Runtime:      types.Runtime(*aws.String(input.Runtime)),
-	})
+	if e != nil {
+		return e
+	}
+
+	code, e := s.resolveLambdaCode(ctx, input)
 
 	if e != nil {
 		return e
 	}
 
-	return nil
-}
+	req := &lambda.CreateFunctionInput{
+		Architectures: []types.Architecture{types.Architecture(input.Architecture)},
+		Code:          code.functionCode(),
+		FunctionName:  aws.String(input.Name),
+		Publish:       *aws.Bool(input.Publish),
+		Role:          aws.String(input.RoleARN),
+	}
 
-func AWSUpdateLambda(
-	conf *aws.Config,
-	ctx *context.Context,
-	input *ie2datatypes.LambdaInput) error {
+	// images bring their own entrypoint, lambda rejects a handler or runtime for them
+	if len(code.ImageUri) > 0 {
+		req.PackageType = types.PackageTypeImage
+	} else {
+		req.PackageType = types.PackageTypeZip
+		req.Handler = aws.String(input.Handler)
+		req.Runtime = types.Runtime(*aws.String(input.Runtime))
+	}
 
-	if conf == nil {
-		return errors.New("aws.config can not be empty")
+	applyLambdaSettings(input, req)
+
+	_, e = c.CreateFunction(ctx, req)
+
+	if e != nil {
+		return e
 	}
 
+	// new functions are Pending until lambda has finished setting them up
+	_, e = waitForLambda(c, ctx, input.Name, s.LambdaWait)
+
+	return e
+}
+
+// UpdateLambda updates the code, configuration and tags of a lambda to match input.
+// The code update is skipped when the code's CodeSha256 matches the deployed code
+// and input.Force is not set, see ie2datatypes.LambdaUpdateResult.
+// When input.Publish is set a version is published once everything has been applied,
+// so it has the new configuration as well as the new code.
+func (s *AWSService) UpdateLambda(ctx context.Context, input *ie2datatypes.LambdaInput) (*ie2datatypes.LambdaUpdateResult, error) {
+
 	if ctx == nil {
-		return errors.New("context can not be empty")
+		return nil, errors.New("context can not be empty")
 	}
 
 	if input == nil {
-		return errors.New("lambdaconfig can not be empty")
+		return nil, errors.New("lambdaconfig can not be empty")
 	}
 
-	c := lambda.NewFromConfig(*conf)
+	c, e := s.lambdaClient()
 
-	_, e := c.UpdateFunctionCode(*ctx, &lambda.UpdateFunctionCodeInput{
-		Architectures: []types.Architecture{types.Architecture(input.Architecture)},
-		DryRun:        *aws.Bool(input.DryRun),
-		FunctionName:  aws.String(input.Name),
-		Publish:       *aws.Bool(input.Publish),
-		S3Bucket:      aws.String(input.S3Bucket),
-		S3Key:         aws.String(input.S3Key),
-	})
+	if e != nil {
+		return nil, e
+	}
+
+	code, e := s.readLambdaCode(ctx, input)
 
 	if e != nil {
-		return e
+		return nil, e
 	}
 
-	// the lastupdatestatus is NOT returned by the call to UpdateFunctionCodeInput
-	// so we need to retrieve it...
-	o, e := c.GetFunction(*ctx, &lambda.GetFunctionInput{
+	res := &ie2datatypes.LambdaUpdateResult{CodeSha256: code.CodeSha256}
+
+	o, e := c.GetFunction(ctx, &lambda.GetFunctionInput{
 		FunctionName: aws.String(input.Name),
 	})
 
 	if e != nil {
-		return e
+		return nil, e
 	}
 
-	log.Printf("Submitted lambda %s code update. Current status is %s", input.Name, o.Configuration.LastUpdateStatus)
+	current := o.Configuration
 
-	var status types.LastUpdateStatus = o.Configuration.LastUpdateStatus
-	maxWait := 60000 // ms (i.e in seconds: maxWait / 1000)
-	waitStep := 5
-	curWait := 0
+	if !input.Force && lambdaCodeUnchanged(current, input, code) {
 
-	for status == types.LastUpdateStatusInProgress && (curWait < maxWait) {
+		log.Printf("Lambda %s code is unchanged (%s), skipping the code update.", input.Name, code.CodeSha256)
+		res.CodeUnchanged = true
 
-		log.Printf("Lambda code update is still in progress. Waiting...")
-		time.Sleep(time.Duration(waitStep) * time.Second)
-		curWait += waitStep
+		if input.DryRun {
+			return res, nil
+		}
+
+		// an earlier update may still be in progress
+		current, e = waitForLambda(c, ctx, input.Name, s.LambdaWait)
+
+		if e != nil {
+			return nil, e
+		}
 
-		log.Printf("Retrieving status for lambda %s after %d seconds.", input.Name, waitStep)
+	} else {
 
-		// retrieve and log current status
-		o, e := c.GetFunction(*ctx, &lambda.GetFunctionInput{
-			FunctionName: aws.String(input.Name),
-		})
+		e = s.stageLambdaCode(ctx, input, code)
 
 		if e != nil {
-			return e
+			return nil, e
 		}
 
-		log.Printf("Status retrieved. Lambda %s is currently %s", input.Name, o.Configuration.LastUpdateStatus)
-		status = o.Configuration.LastUpdateStatus
-	}
+		codeReq := &lambda.UpdateFunctionCodeInput{
+			Architectures: []types.Architecture{types.Architecture(input.Architecture)},
+			DryRun:        *aws.Bool(input.DryRun),
+			FunctionName:  aws.String(input.Name),
+		}
 
-	if status == types.LastUpdateStatusFailed {
-		msg := fmt.Sprintf("Lambda %s code failed to update.", input.Name)
-		log.Print(msg)
-		return errors.New(msg)
-	}
+		code.updateFunctionCode(codeReq)
 
-	if status == types.LastUpdateStatusInProgress {
-		msg := fmt.Sprintf("Lambda %s code is still updating after %d seconds...consider increasing the timeout.", input.Name, (maxWait / 1000))
-		log.Print(msg)
-		return errors.New(msg)
+		_, e = c.UpdateFunctionCode(ctx, codeReq)
+
+		if e != nil {
+			return nil, e
+		}
+
+		// a dry run only validates the request, nothing changed
+		if input.DryRun {
+			return res, nil
+		}
+
+		// the configuration can't be updated while the code update is in progress
+		log.Printf("Submitted lambda %s code update. Waiting for it to finish.", input.Name)
+		current, e = waitForLambda(c, ctx, input.Name, s.LambdaWait)
+
+		if e != nil {
+			return nil, e
+		}
 	}
 
-	// if the code update was successful
+	changes, req := lambdaConfigurationChanges(current, input)
+	res.Configuration = changes
+
+	if req == nil {
 
-	if status == types.LastUpdateStatusSuccessful {
+		log.Printf("Lambda %s configuration is up to date.", input.Name)
 
-		_, e = c.UpdateFunctionConfiguration(*ctx, &lambda.UpdateFunctionConfigurationInput{
-			FunctionName: aws.String(input.Name),
-			Role:         aws.String(input.RoleARN),
-		})
+	} else {
+
+		for _, change := range changes {
+			log.Printf("Updating lambda %s %s", input.Name, change.Field)
+		}
+
+		_, e = c.UpdateFunctionConfiguration(ctx, req)
 
 		if e != nil {
-			return e
+			return nil, e
+		}
+
+		log.Printf("Submitted lambda %s configuration update. Waiting for it to finish.", input.Name)
+		_, e = waitForLambda(c, ctx, input.Name, s.LambdaWait)
+
+		if e != nil {
+			return nil, e
 		}
 	}
 
-	return nil
-}
+	e = updateLambdaTags(c, ctx, input)
 
-func AWSDeleteLambda(
-	conf *aws.Config,
-	ctx *context.Context,
-	name string) error {
+	if e != nil {
+		return nil, e
+	}
 
-	if conf == nil {
-		return errors.New("aws.config can not be empty")
+	if input.Publish {
+
+		res.Version, e = s.PublishLambdaVersion(ctx, input.Name, "")
+
+		if e != nil {
+			return nil, e
+		}
 	}
 
+	return res, nil
+}
+
+func (s *AWSService) DeleteLambda(ctx context.Context, name string) error {
+
 	if ctx == nil {
 		return errors.New("context can not be empty")
 	}
@@ -203,9 +248,13 @@ func AWSDeleteLambda(
 		return errors.New("lambda name can not be empty")
 	}
 
-	c := lambda.NewFromConfig(*conf)
+	c, e := s.lambdaClient()
 
-	_, e := c.DeleteFunction(*ctx, &lambda.DeleteFunctionInput{
+	if e != nil {
+		return e
+	}
+
+	_, e = c.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
 		FunctionName: aws.String(name),
 	})
 
@@ -216,17 +265,28 @@ func AWSDeleteLambda(
 	return nil
 }
 
-func AWSAddApiGatewayPermission(
-	conf *aws.Config,
-	ctx *context.Context,
+func (s *AWSService) AddApiGatewayPermission(
+	ctx context.Context,
 	method string,
 	sourcearn string,
 	lambdaname string) error {
 
-	if conf == nil {
-		return errors.New("aws.config can not be empty")
+	if len(method) <= 0 {
+		return errors.New("method can not be empty")
 	}
 
+	return s.addApiGatewayPermission(ctx, fmt.Sprintf("AllowAPIMethod%sOnFunction%s", method, lambdaname), sourcearn, lambdaname, "")
+}
+
+// addApiGatewayPermission allows api gateway to invoke lambdaname, or the alias
+// qualifier of it when set, from sourcearn under the statement id sid.
+func (s *AWSService) addApiGatewayPermission(
+	ctx context.Context,
+	sid string,
+	sourcearn string,
+	lambdaname string,
+	qualifier string) error {
+
 	if ctx == nil {
 		return errors.New("context can not be empty")
 	}
@@ -235,19 +295,26 @@ func AWSAddApiGatewayPermission(
 		return errors.New("lambdaname can not be empty")
 	}
 
-	if len(method) <= 0 {
-		return errors.New("method can not be empty")
-	}
+	c, e := s.lambdaClient()
 
-	c := lambda.NewFromConfig(*conf)
+	if e != nil {
+		return e
+	}
 
-	_, e := c.AddPermission(*ctx, &lambda.AddPermissionInput{
+	input := &lambda.AddPermissionInput{
 		Action:       aws.String("lambda:InvokeFunction"),
 		FunctionName: aws.String(lambdaname),
 		Principal:    aws.String("apigateway.amazonaws.com"),
-		StatementId:  aws.String(fmt.Sprintf("AllowAPIMethod%sOnFunction%s", method, lambdaname)),
+		StatementId:  aws.String(sid),
 		SourceArn:    aws.String(sourcearn),
-	})
+	}
+
+	// each alias has its own policy
+	if len(qualifier) > 0 {
+		input.Qualifier = aws.String(qualifier)
+	}
+
+	_, e = c.AddPermission(ctx, input)
 
 	if e != nil {
 		return e
@@ -255,3 +322,105 @@ func AWSAddApiGatewayPermission(
 
 	return nil
 }
+
+/***
+* Package level helpers that build their clients from an aws.Config
+***/
+
+// AWSLambdaExists is AWSService.LambdaExists using clients built from conf.
+//
+// Deprecated: use AWSService.LambdaExists, which takes a context.Context.
+func AWSLambdaExists(conf *aws.Config, ctx *context.Context, name string) (bool, error) {
+
+	if conf == nil {
+		e := errors.New("aws.config can not be empty")
+		return false, e
+	}
+
+	if ctx == nil {
+		e := errors.New("context can not be empty")
+		return false, e
+	}
+
+	return NewAWSService(*conf).LambdaExists(*ctx, name)
+}
+
+// AWSCreateLambda is AWSService.CreateLambda using clients built from conf.
+//
+// Deprecated: use AWSService.CreateLambda, which takes a context.Context.
+func AWSCreateLambda(
+	conf *aws.Config,
+	ctx *context.Context,
+	input *ie2datatypes.LambdaInput) error {
+
+	if conf == nil {
+		return errors.New("aws.config can not be empty")
+	}
+
+	if ctx == nil {
+		return errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).CreateLambda(*ctx, input)
+}
+
+// AWSUpdateLambda is AWSService.UpdateLambda using clients built from conf.
+//
+// Deprecated: use AWSService.UpdateLambda, which takes a context.Context.
+func AWSUpdateLambda(
+	conf *aws.Config,
+	ctx *context.Context,
+	input *ie2datatypes.LambdaInput) error {
+
+	if conf == nil {
+		return errors.New("aws.config can not be empty")
+	}
+
+	if ctx == nil {
+		return errors.New("context can not be empty")
+	}
+
+	_, e := NewAWSService(*conf).UpdateLambda(*ctx, input)
+
+	return e
+}
+
+// AWSDeleteLambda is AWSService.DeleteLambda using clients built from conf.
+//
+// Deprecated: use AWSService.DeleteLambda, which takes a context.Context.
+func AWSDeleteLambda(
+	conf *aws.Config,
+	ctx *context.Context,
+	name string) error {
+
+	if conf == nil {
+		return errors.New("aws.config can not be empty")
+	}
+
+	if ctx == nil {
+		return errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).DeleteLambda(*ctx, name)
+}
+
+// AWSAddApiGatewayPermission is AWSService.AddApiGatewayPermission using clients built from conf.
+//
+// Deprecated: use AWSService.AddApiGatewayPermission, which takes a context.Context.
+func AWSAddApiGatewayPermission(
+	conf *aws.Config,
+	ctx *context.Context,
+	method string,
+	sourcearn string,
+	lambdaname string) error {
+
+	if conf == nil {
+		return errors.New("aws.config can not be empty")
+	}
+
+	if ctx == nil {
+		return errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).AddApiGatewayPermission(*ctx, method, sourcearn, lambdaname)
+}
@@ -0,0 +1,119 @@
+package ie2utilities_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ie2testing "github.com/insightengine2/ie2-utilities/testing"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+	ie2utilities "github.com/insightengine2/ie2-utilities/utils"
+)
+
+func TestUpdateLambdaPublish(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		update      func(input *ie2datatypes.LambdaInput)
+		publish     bool
+		wantVersion string
+		wantHandler string
+		wantMemory  int32
+	}{
+		{
+			name:        "unchanged",
+			update:      func(input *ie2datatypes.LambdaInput) {},
+			publish:     true,
+			wantVersion: "1",
+			wantHandler: "bootstrap",
+			wantMemory:  128,
+		},
+		{
+			name:        "configuration only",
+			update:      func(input *ie2datatypes.LambdaInput) { input.MemorySize = 512 },
+			publish:     true,
+			wantVersion: "2",
+			wantHandler: "bootstrap",
+			wantMemory:  512,
+		},
+		{
+			name: "code and configuration",
+			update: func(input *ie2datatypes.LambdaInput) {
+				input.S3Key = "papers-2.zip"
+				input.Handler = "main"
+			},
+			publish:     true,
+			wantVersion: "2",
+			wantHandler: "main",
+			wantMemory:  128,
+		},
+		{
+			name:        "not published",
+			update:      func(input *ie2datatypes.LambdaInput) { input.MemorySize = 512 },
+			wantHandler: "bootstrap",
+			wantMemory:  128,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx := context.Background()
+
+			s3 := ie2testing.NewFakeS3()
+			s3.Put("artifacts", "papers.zip", []byte("bootstrap"), "")
+			s3.Put("artifacts", "papers-2.zip", []byte("main"), "")
+
+			fl := ie2testing.NewFakeLambda()
+			fl.S3 = s3
+
+			s := &ie2utilities.AWSService{Lambda: fl, S3: s3}
+			input := ie2datatypes.LambdaInput{
+				Name:       "papers",
+				Handler:    "bootstrap",
+				Runtime:    "provided.al2023",
+				RoleARN:    "arn:aws:iam::123456789012:role/papers",
+				S3Bucket:   "artifacts",
+				S3Key:      "papers.zip",
+				MemorySize: 128,
+			}
+
+			e := s.CreateLambda(ctx, &input)
+
+			if e != nil {
+				t.Fatal(e)
+			}
+
+			_, e = s.PublishLambdaVersion(ctx, "papers", "")
+
+			if e != nil {
+				t.Fatal(e)
+			}
+
+			tt.update(&input)
+			input.Publish = tt.publish
+
+			res, e := s.UpdateLambda(ctx, &input)
+
+			if e != nil {
+				t.Fatalf("UpdateLambda() error = %v", e)
+			}
+
+			if res.Version != tt.wantVersion {
+				t.Errorf("UpdateLambda() version = %q, want %q", res.Version, tt.wantVersion)
+			}
+
+			// the last version has everything the update applied
+			versions := fl.Versions["papers"]
+			latest := versions[len(versions)-1]
+
+			if aws.ToString(latest.Handler) != tt.wantHandler || aws.ToInt32(latest.MemorySize) != tt.wantMemory {
+				t.Errorf("version %s has handler %s and memory %d, want %s and %d", aws.ToString(latest.Version), aws.ToString(latest.Handler), aws.ToInt32(latest.MemorySize), tt.wantHandler, tt.wantMemory)
+			}
+
+			if tt.publish && aws.ToString(latest.CodeSha256) != aws.ToString(fl.Functions["papers"].CodeSha256) {
+				t.Errorf("version %s code = %s, want the deployed code %s", aws.ToString(latest.Version), aws.ToString(latest.CodeSha256), aws.ToString(fl.Functions["papers"].CodeSha256))
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package ie2utilities
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	ie2packaging "github.com/insightengine2/ie2-utilities/packaging"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+// lambda rejects zips larger than this when they are sent with the request
+const LAMBDA_DIRECT_ZIP_LIMIT = 50 * 1024 * 1024
+
+// the metadata key staged zips carry their base64 SHA-256 under, in the same form as CodeSha256
+const LAMBDA_CODE_SHA_METADATA = "codesha256"
+
+// lambdaCode is where the code of a lambda comes from once local zips have been read or staged.
+// CodeSha256 is the base64 SHA-256 of the zip, empty when it isn't known.
+type lambdaCode struct {
+	S3Bucket   string
+	S3Key      string
+	ZipFile    []byte
+	ImageUri   string
+	CodeSha256 string
+}
+
+func (l *lambdaCode) functionCode() *types.FunctionCode {
+
+	if len(l.ImageUri) > 0 {
+		return &types.FunctionCode{ImageUri: aws.String(l.ImageUri)}
+	}
+
+	if l.ZipFile != nil {
+		return &types.FunctionCode{ZipFile: l.ZipFile}
+	}
+
+	return &types.FunctionCode{
+		S3Bucket: aws.String(l.S3Bucket),
+		S3Key:    aws.String(l.S3Key),
+	}
+}
+
+func (l *lambdaCode) updateFunctionCode(req *lambda.UpdateFunctionCodeInput) {
+
+	if len(l.ImageUri) > 0 {
+		req.ImageUri = aws.String(l.ImageUri)
+	} else if l.ZipFile != nil {
+		req.ZipFile = l.ZipFile
+	} else {
+		req.S3Bucket = aws.String(l.S3Bucket)
+		req.S3Key = aws.String(l.S3Key)
+	}
+}
+
+// readLambdaZip returns the zip for ZipPath or ZipDir, or nil when neither is set.
+func readLambdaZip(input *ie2datatypes.LambdaInput) ([]byte, error) {
+
+	if len(input.ZipPath) > 0 {
+		log.Printf("Reading lambda %s code from %s", input.Name, input.ZipPath)
+		return os.ReadFile(input.ZipPath)
+	}
+
+	if len(input.ZipDir) > 0 {
+		log.Printf("Zipping lambda %s code from %s", input.Name, input.ZipDir)
+		pkg, e := ie2packaging.ZipDirectory(input.ZipDir)
+
+		if e != nil {
+			return nil, e
+		}
+
+		return pkg.Zip, nil
+	}
+
+	return nil, nil
+}
+
+// stageLambdaZip uploads data to bucket under a key derived from its hash,
+// skipping the upload when that key already exists.
+func (s *AWSService) stageLambdaZip(ctx context.Context, bucket string, name string, data []byte) (string, error) {
+
+	c, e := s.s3Client()
+
+	if e != nil {
+		return "", e
+	}
+
+	sum := sha256.Sum256(data)
+	key := fmt.Sprintf("%s/%s.zip", name, hex.EncodeToString(sum[:]))
+
+	_, e = c.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+
+	if e == nil {
+		log.Printf("Lambda code s3://%s/%s is already staged", bucket, key)
+		return key, nil
+	}
+
+	if !IsAWSNotFound(e) {
+		return "", e
+	}
+
+	log.Printf("Staging %d bytes of lambda code to s3://%s/%s", len(data), bucket, key)
+	_, e = c.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:            aws.String(bucket),
+		Key:               aws.String(key),
+		Body:              bytes.NewReader(data),
+		ContentType:       aws.String("application/zip"),
+		ChecksumAlgorithm: s3types.ChecksumAlgorithmSha256,
+		Metadata:          map[string]string{LAMBDA_CODE_SHA_METADATA: ie2packaging.CodeSha256(data)},
+	})
+
+	if e != nil {
+		return "", e
+	}
+
+	return key, nil
+}
+
+// s3CodeSha256 reads the SHA-256 of a zip in S3 from the metadata staged zips carry,
+// or from the object's SHA-256 checksum. It returns an empty string when neither is there.
+func (s *AWSService) s3CodeSha256(ctx context.Context, bucket string, key string) (string, error) {
+
+	c, e := s.s3Client()
+
+	if e != nil {
+		return "", e
+	}
+
+	out, e := c.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(key),
+		ChecksumMode: s3types.ChecksumModeEnabled,
+	})
+
+	if e != nil {
+
+		// lambda reports a missing object better than we can
+		if IsAWSNotFound(e) {
+			return "", nil
+		}
+
+		return "", e
+	}
+
+	if sha, ok := out.Metadata[LAMBDA_CODE_SHA_METADATA]; ok {
+		return sha, nil
+	}
+
+	// checksums of multipart uploads are a hash of the part hashes, suffixed with the part count
+	sha := aws.ToString(out.ChecksumSHA256)
+
+	if strings.Contains(sha, "-") {
+		return "", nil
+	}
+
+	return sha, nil
+}
+
+// readLambdaCode works out where the code for input comes from and its CodeSha256 without staging anything.
+func (s *AWSService) readLambdaCode(ctx context.Context, input *ie2datatypes.LambdaInput) (*lambdaCode, error) {
+
+	if len(input.ImageUri) > 0 {
+		return &lambdaCode{ImageUri: input.ImageUri}, nil
+	}
+
+	data, e := readLambdaZip(input)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if data != nil {
+		return &lambdaCode{ZipFile: data, CodeSha256: ie2packaging.CodeSha256(data)}, nil
+	}
+
+	if len(input.S3Bucket) <= 0 || len(input.S3Key) <= 0 {
+		return nil, errors.New("lambda code requires an image uri, a zip path, a zip directory or an s3 bucket and key")
+	}
+
+	sha, e := s.s3CodeSha256(ctx, input.S3Bucket, input.S3Key)
+
+	if e != nil {
+		return nil, e
+	}
+
+	return &lambdaCode{S3Bucket: input.S3Bucket, S3Key: input.S3Key, CodeSha256: sha}, nil
+}
+
+// stageLambdaCode stages a local zip to S3 when input has a bucket,
+// otherwise it makes sure the zip is small enough to send with the request.
+func (s *AWSService) stageLambdaCode(ctx context.Context, input *ie2datatypes.LambdaInput, code *lambdaCode) error {
+
+	if code.ZipFile == nil {
+		return nil
+	}
+
+	if len(input.S3Bucket) > 0 {
+
+		key, e := s.stageLambdaZip(ctx, input.S3Bucket, input.Name, code.ZipFile)
+
+		if e != nil {
+			return e
+		}
+
+		code.S3Bucket = input.S3Bucket
+		code.S3Key = key
+		code.ZipFile = nil
+
+		return nil
+	}
+
+	if len(code.ZipFile) > LAMBDA_DIRECT_ZIP_LIMIT {
+		return fmt.Errorf("lambda %s zip is %d bytes, more than can be uploaded directly, set an s3 bucket to stage it", input.Name, len(code.ZipFile))
+	}
+
+	return nil
+}
+
+// resolveLambdaCode works out where the code for input comes from, staging local zips to S3 when a bucket is set.
+func (s *AWSService) resolveLambdaCode(ctx context.Context, input *ie2datatypes.LambdaInput) (*lambdaCode, error) {
+
+	code, e := s.readLambdaCode(ctx, input)
+
+	if e != nil {
+		return nil, e
+	}
+
+	e = s.stageLambdaCode(ctx, input, code)
+
+	if e != nil {
+		return nil, e
+	}
+
+	return code, nil
+}
+
+// lambdaCodeUnchanged is true when code is known to be what current already runs.
+func lambdaCodeUnchanged(current *types.FunctionConfiguration, input *ie2datatypes.LambdaInput, code *lambdaCode) bool {
+
+	if len(code.CodeSha256) <= 0 || code.CodeSha256 != aws.ToString(current.CodeSha256) {
+		return false
+	}
+
+	// the architecture can only be changed along with the code
+	if len(input.Architecture) > 0 && (len(current.Architectures) <= 0 || string(current.Architectures[0]) != input.Architecture) {
+		return false
+	}
+
+	return true
+}
+
+// describeLambdaCode says where code comes from.
+// Code with a known CodeSha256 is described by it so it can be compared with the deployed code.
+func describeLambdaCode(code *lambdaCode) string {
+
+	if len(code.CodeSha256) > 0 {
+		return code.CodeSha256
+	}
+
+	if len(code.ImageUri) > 0 {
+		return code.ImageUri
+	}
+
+	return fmt.Sprintf("s3://%s/%s", code.S3Bucket, code.S3Key)
+}
@@ -0,0 +1,121 @@
+package ie2utilities
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+// headObjectS3 answers HeadObject with out, or err, and nothing else.
+type headObjectS3 struct {
+	S3API
+	out *s3.HeadObjectOutput
+	err error
+}
+
+func (h *headObjectS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	return h.out, h.err
+}
+
+func TestS3CodeSha256(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		out     *s3.HeadObjectOutput
+		err     error
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "staged metadata",
+			out:  &s3.HeadObjectOutput{Metadata: map[string]string{LAMBDA_CODE_SHA_METADATA: "staged="}, ChecksumSHA256: aws.String("checksum=")},
+			want: "staged=",
+		},
+		{
+			name: "checksum",
+			out:  &s3.HeadObjectOutput{ChecksumSHA256: aws.String("checksum=")},
+			want: "checksum=",
+		},
+		{
+			name: "multipart checksum",
+			out:  &s3.HeadObjectOutput{ChecksumSHA256: aws.String("parts=-3")},
+			want: "",
+		},
+		{
+			name: "no checksum",
+			out:  &s3.HeadObjectOutput{},
+			want: "",
+		},
+		{
+			name: "missing object",
+			err:  &s3types.NotFound{},
+			want: "",
+		},
+		{
+			name:    "other error",
+			err:     &s3types.InvalidObjectState{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := &AWSService{S3: &headObjectS3{out: tt.out, err: tt.err}}
+			got, e := s.s3CodeSha256(context.Background(), "artifacts", "papers.zip")
+
+			if (e != nil) != tt.wantErr {
+				t.Fatalf("s3CodeSha256() error = %v, wantErr %v", e, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("s3CodeSha256() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLambdaCodeUnchanged(t *testing.T) {
+
+	current := &types.FunctionConfiguration{
+		CodeSha256:    aws.String("deployed="),
+		Architectures: []types.Architecture{types.ArchitectureX8664},
+	}
+
+	tests := []struct {
+		name         string
+		sha          string
+		architecture string
+		current      *types.FunctionConfiguration
+		want         bool
+	}{
+		{name: "same code", sha: "deployed=", want: true},
+		{name: "same code and architecture", sha: "deployed=", architecture: "x86_64", want: true},
+		{name: "different code", sha: "changed="},
+		{name: "unknown code", sha: ""},
+		{name: "architecture changed", sha: "deployed=", architecture: "arm64"},
+		{name: "architecture not reported", sha: "deployed=", architecture: "arm64", current: &types.FunctionConfiguration{CodeSha256: aws.String("deployed=")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			conf := current
+
+			if tt.current != nil {
+				conf = tt.current
+			}
+
+			input := &ie2datatypes.LambdaInput{Name: "papers", Architecture: tt.architecture}
+
+			if got := lambdaCodeUnchanged(conf, input, &lambdaCode{CodeSha256: tt.sha}); got != tt.want {
+				t.Errorf("lambdaCodeUnchanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package ie2utilities
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+/***
+* Lambda settings beyond the code. Zero values in LambdaInput are not managed,
+* see ie2datatypes.LambdaInput.
+***/
+
+// applyLambdaSettings copies the optional settings of input onto a create request.
+func applyLambdaSettings(input *ie2datatypes.LambdaInput, req *lambda.CreateFunctionInput) {
+
+	if input.MemorySize > 0 {
+		req.MemorySize = aws.Int32(input.MemorySize)
+	}
+
+	if input.Timeout > 0 {
+		req.Timeout = aws.Int32(input.Timeout)
+	}
+
+	if input.Environment != nil {
+		req.Environment = &types.Environment{Variables: input.Environment}
+	}
+
+	if input.VpcConfig != nil {
+		req.VpcConfig = &types.VpcConfig{
+			SubnetIds:        input.VpcConfig.SubnetIds,
+			SecurityGroupIds: input.VpcConfig.SecurityGroupIds,
+		}
+	}
+
+	if input.Layers != nil {
+		req.Layers = input.Layers
+	}
+
+	if input.EphemeralStorage > 0 {
+		req.EphemeralStorage = &types.EphemeralStorage{Size: aws.Int32(input.EphemeralStorage)}
+	}
+
+	if len(input.TracingMode) > 0 {
+		req.TracingConfig = &types.TracingConfig{Mode: types.TracingMode(input.TracingMode)}
+	}
+
+	if len(input.DeadLetterArn) > 0 {
+		req.DeadLetterConfig = &types.DeadLetterConfig{TargetArn: aws.String(input.DeadLetterArn)}
+	}
+
+	if len(input.Tags) > 0 {
+		req.Tags = input.Tags
+	}
+}
+
+// sortedList is used to compare and display lists where the order doesn't matter.
+func sortedList(values []string) string {
+
+	ret := slices.Clone(values)
+	sort.Strings(ret)
+
+	return strings.Join(ret, ",")
+}
+
+// environmentKeys lists the variable names only, values may hold secrets.
+func environmentKeys(env map[string]string) string {
+
+	keys := []string{}
+
+	for k := range env {
+		keys = append(keys, k)
+	}
+
+	return sortedList(keys)
+}
+
+// lambdaConfigurationChanges compares the configuration of a deployed lambda with input.
+// It returns the differences, and the request that applies them or nil if there are none.
+func lambdaConfigurationChanges(current *types.FunctionConfiguration, input *ie2datatypes.LambdaInput) ([]ie2datatypes.PlanChange, *lambda.UpdateFunctionConfigurationInput) {
+
+	changes := []ie2datatypes.PlanChange{}
+	req := &lambda.UpdateFunctionConfigurationInput{
+		FunctionName: aws.String(input.Name),
+	}
+
+	change := func(field string, from string, to string) {
+		changes = append(changes, planChange(ie2datatypes.PlanKindLambda, input.Name, field, from, to))
+	}
+
+	// images bring their own entrypoint, lambda has no handler or runtime for them
+	image := len(input.ImageUri) > 0
+
+	if !image && len(input.Handler) > 0 && aws.ToString(current.Handler) != input.Handler {
+		change("handler", aws.ToString(current.Handler), input.Handler)
+		req.Handler = aws.String(input.Handler)
+	}
+
+	if !image && len(input.Runtime) > 0 && string(current.Runtime) != input.Runtime {
+		change("runtime", string(current.Runtime), input.Runtime)
+		req.Runtime = types.Runtime(input.Runtime)
+	}
+
+	if len(input.RoleARN) > 0 && aws.ToString(current.Role) != input.RoleARN {
+		change("role", aws.ToString(current.Role), input.RoleARN)
+		req.Role = aws.String(input.RoleARN)
+	}
+
+	if input.MemorySize > 0 && aws.ToInt32(current.MemorySize) != input.MemorySize {
+		change("memorysize", fmt.Sprint(aws.ToInt32(current.MemorySize)), fmt.Sprint(input.MemorySize))
+		req.MemorySize = aws.Int32(input.MemorySize)
+	}
+
+	if input.Timeout > 0 && aws.ToInt32(current.Timeout) != input.Timeout {
+		change("timeout", fmt.Sprint(aws.ToInt32(current.Timeout)), fmt.Sprint(input.Timeout))
+		req.Timeout = aws.Int32(input.Timeout)
+	}
+
+	if input.Environment != nil {
+
+		env := map[string]string{}
+
+		if current.Environment != nil && current.Environment.Variables != nil {
+			env = current.Environment.Variables
+		}
+
+		if !maps.Equal(env, input.Environment) {
+			change("environment", environmentKeys(env), environmentKeys(input.Environment))
+			req.Environment = &types.Environment{Variables: input.Environment}
+		}
+	}
+
+	if input.VpcConfig != nil {
+
+		subnets := []string{}
+		groups := []string{}
+
+		if current.VpcConfig != nil {
+			subnets = current.VpcConfig.SubnetIds
+			groups = current.VpcConfig.SecurityGroupIds
+		}
+
+		if sortedList(subnets) != sortedList(input.VpcConfig.SubnetIds) || sortedList(groups) != sortedList(input.VpcConfig.SecurityGroupIds) {
+
+			change("vpc",
+				fmt.Sprintf("subnets=%s groups=%s", sortedList(subnets), sortedList(groups)),
+				fmt.Sprintf("subnets=%s groups=%s", sortedList(input.VpcConfig.SubnetIds), sortedList(input.VpcConfig.SecurityGroupIds)))
+
+			req.VpcConfig = &types.VpcConfig{
+				SubnetIds:        input.VpcConfig.SubnetIds,
+				SecurityGroupIds: input.VpcConfig.SecurityGroupIds,
+			}
+		}
+	}
+
+	if input.Layers != nil {
+
+		// layers are applied in order so the order matters here
+		layers := []string{}
+
+		for _, l := range current.Layers {
+			layers = append(layers, aws.ToString(l.Arn))
+		}
+
+		if !slices.Equal(layers, input.Layers) {
+			change("layers", strings.Join(layers, ","), strings.Join(input.Layers, ","))
+			req.Layers = input.Layers
+		}
+	}
+
+	if input.EphemeralStorage > 0 {
+
+		size := int32(0)
+
+		if current.EphemeralStorage != nil {
+			size = aws.ToInt32(current.EphemeralStorage.Size)
+		}
+
+		if size != input.EphemeralStorage {
+			change("ephemeralstorage", fmt.Sprint(size), fmt.Sprint(input.EphemeralStorage))
+			req.EphemeralStorage = &types.EphemeralStorage{Size: aws.Int32(input.EphemeralStorage)}
+		}
+	}
+
+	if len(input.TracingMode) > 0 {
+
+		mode := ""
+
+		if current.TracingConfig != nil {
+			mode = string(current.TracingConfig.Mode)
+		}
+
+		if mode != input.TracingMode {
+			change("tracingmode", mode, input.TracingMode)
+			req.TracingConfig = &types.TracingConfig{Mode: types.TracingMode(input.TracingMode)}
+		}
+	}
+
+	if len(input.DeadLetterArn) > 0 {
+
+		arn := ""
+
+		if current.DeadLetterConfig != nil {
+			arn = aws.ToString(current.DeadLetterConfig.TargetArn)
+		}
+
+		if arn != input.DeadLetterArn {
+			change("deadletterarn", arn, input.DeadLetterArn)
+			req.DeadLetterConfig = &types.DeadLetterConfig{TargetArn: aws.String(input.DeadLetterArn)}
+		}
+	}
+
+	if len(changes) <= 0 {
+		return changes, nil
+	}
+
+	return changes, req
+}
+
+// lambdaTagChanges compares the tags on a lambda with the desired ones.
+// It returns the tags to set and the keys to remove. Reserved aws: tags, e.g. the ones
+// CloudFormation adds, can't be removed and are left alone.
+func lambdaTagChanges(current map[string]string, desired map[string]string) (map[string]string, []string) {
+
+	set := map[string]string{}
+	remove := []string{}
+
+	for k, v := range desired {
+		if cur, ok := current[k]; !ok || cur != v {
+			set[k] = v
+		}
+	}
+
+	for k := range current {
+
+		if strings.HasPrefix(k, "aws:") {
+			continue
+		}
+
+		if _, ok := desired[k]; !ok {
+			remove = append(remove, k)
+		}
+	}
+
+	sort.Strings(remove)
+
+	return set, remove
+}
+
+// lambdaTagPlan reports the tag differences as plan changes.
+func lambdaTagPlan(name string, current map[string]string, desired map[string]string) []ie2datatypes.PlanChange {
+
+	ret := []ie2datatypes.PlanChange{}
+	set, remove := lambdaTagChanges(current, desired)
+	keys := []string{}
+
+	for k := range set {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+
+		change := planChange(ie2datatypes.PlanKindLambda, name, "tag:"+k, current[k], set[k])
+
+		if _, ok := current[k]; !ok {
+			change.Action = ie2datatypes.PlanActionAdd
+		}
+
+		ret = append(ret, change)
+	}
+
+	for _, k := range remove {
+		ret = append(ret, ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionRemove, Kind: ie2datatypes.PlanKindLambda, Target: name, Field: "tag:" + k, Current: current[k]})
+	}
+
+	return ret
+}
+
+// updateLambdaTags makes the tags on a lambda match input.Tags. Nil tags are not managed.
+func updateLambdaTags(c LambdaAPI, ctx context.Context, input *ie2datatypes.LambdaInput) error {
+
+	if input.Tags == nil {
+		return nil
+	}
+
+	o, e := c.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String(input.Name),
+	})
+
+	if e != nil {
+		return e
+	}
+
+	arn := aws.ToString(o.Configuration.FunctionArn)
+	set, remove := lambdaTagChanges(o.Tags, input.Tags)
+
+	if len(set) > 0 {
+
+		log.Printf("Setting %d tags on lambda %s", len(set), input.Name)
+		_, e = c.TagResource(ctx, &lambda.TagResourceInput{
+			Resource: aws.String(arn),
+			Tags:     set,
+		})
+
+		if e != nil {
+			return e
+		}
+	}
+
+	if len(remove) > 0 {
+
+		log.Printf("Removing tags %s from lambda %s", strings.Join(remove, ","), input.Name)
+		_, e = c.UntagResource(ctx, &lambda.UntagResourceInput{
+			Resource: aws.String(arn),
+			TagKeys:  remove,
+		})
+
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
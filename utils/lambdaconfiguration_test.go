@@ -0,0 +1,157 @@
+package ie2utilities
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+func TestLambdaConfigurationChanges(t *testing.T) {
+
+	current := types.FunctionConfiguration{
+		Handler:     aws.String("bootstrap"),
+		Runtime:     types.RuntimeProvidedal2023,
+		Role:        aws.String("arn:aws:iam::123456789012:role/papers"),
+		MemorySize:  aws.Int32(128),
+		Timeout:     aws.Int32(3),
+		Environment: &types.EnvironmentResponse{Variables: map[string]string{"STAGE": "dev"}},
+		VpcConfig:   &types.VpcConfigResponse{SubnetIds: []string{"subnet-b", "subnet-a"}, SecurityGroupIds: []string{"sg-1"}},
+		Layers:      []types.Layer{{Arn: aws.String("layer:1")}, {Arn: aws.String("layer:2")}},
+	}
+
+	tests := []struct {
+		name  string
+		input ie2datatypes.LambdaInput
+		want  []string
+	}{
+		{
+			name: "nothing managed",
+			want: []string{},
+		},
+		{
+			name: "unchanged",
+			input: ie2datatypes.LambdaInput{
+				Handler:     "bootstrap",
+				Runtime:     "provided.al2023",
+				RoleARN:     "arn:aws:iam::123456789012:role/papers",
+				MemorySize:  128,
+				Timeout:     3,
+				Environment: map[string]string{"STAGE": "dev"},
+				VpcConfig:   &ie2datatypes.LambdaVpcConfig{SubnetIds: []string{"subnet-a", "subnet-b"}, SecurityGroupIds: []string{"sg-1"}},
+				Layers:      []string{"layer:1", "layer:2"},
+			},
+			want: []string{},
+		},
+		{
+			name:  "settings changed",
+			input: ie2datatypes.LambdaInput{Handler: "main", Runtime: "go1.x", MemorySize: 512, Timeout: 30},
+			want:  []string{"handler", "runtime", "memorysize", "timeout"},
+		},
+		{
+			name:  "image ignores handler and runtime",
+			input: ie2datatypes.LambdaInput{ImageUri: "123456789012.dkr.ecr.us-east-1.amazonaws.com/papers:1", Handler: "main", Runtime: "go1.x", MemorySize: 512},
+			want:  []string{"memorysize"},
+		},
+		{
+			name:  "environment cleared",
+			input: ie2datatypes.LambdaInput{Environment: map[string]string{}},
+			want:  []string{"environment"},
+		},
+		{
+			name:  "layers reordered",
+			input: ie2datatypes.LambdaInput{Layers: []string{"layer:2", "layer:1"}},
+			want:  []string{"layers"},
+		},
+		{
+			name:  "vpc changed",
+			input: ie2datatypes.LambdaInput{VpcConfig: &ie2datatypes.LambdaVpcConfig{SubnetIds: []string{"subnet-a"}, SecurityGroupIds: []string{"sg-1"}}},
+			want:  []string{"vpc"},
+		},
+		{
+			name:  "settings not set before",
+			input: ie2datatypes.LambdaInput{EphemeralStorage: 1024, TracingMode: "Active", DeadLetterArn: "arn:aws:sqs:us-east-1:123456789012:dlq"},
+			want:  []string{"ephemeralstorage", "tracingmode", "deadletterarn"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			tt.input.Name = "papers"
+			changes, req := lambdaConfigurationChanges(&current, &tt.input)
+
+			got := []string{}
+
+			for _, change := range changes {
+				got = append(got, change.Field)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("lambdaConfigurationChanges() fields = %v, want %v", got, tt.want)
+			}
+
+			if (req != nil) != (len(tt.want) > 0) {
+				t.Errorf("lambdaConfigurationChanges() request = %+v, want one %v", req, len(tt.want) > 0)
+			}
+		})
+	}
+}
+
+func TestLambdaTagChanges(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		current    map[string]string
+		desired    map[string]string
+		wantSet    map[string]string
+		wantRemove []string
+	}{
+		{
+			name:       "unchanged",
+			current:    map[string]string{"team": "ie2"},
+			desired:    map[string]string{"team": "ie2"},
+			wantSet:    map[string]string{},
+			wantRemove: []string{},
+		},
+		{
+			name:       "added and changed",
+			current:    map[string]string{"team": "ie2", "env": "dev"},
+			desired:    map[string]string{"team": "ie2", "env": "prod", "owner": "gb"},
+			wantSet:    map[string]string{"env": "prod", "owner": "gb"},
+			wantRemove: []string{},
+		},
+		{
+			name:       "removed",
+			current:    map[string]string{"team": "ie2", "env": "dev", "old": "x"},
+			desired:    map[string]string{"team": "ie2"},
+			wantSet:    map[string]string{},
+			wantRemove: []string{"env", "old"},
+		},
+		{
+			name:       "reserved tags are kept",
+			current:    map[string]string{"aws:cloudformation:stack-name": "papers", "env": "dev"},
+			desired:    map[string]string{},
+			wantSet:    map[string]string{},
+			wantRemove: []string{"env"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			set, remove := lambdaTagChanges(tt.current, tt.desired)
+
+			if !maps.Equal(set, tt.wantSet) {
+				t.Errorf("lambdaTagChanges() set = %v, want %v", set, tt.wantSet)
+			}
+
+			if !slices.Equal(remove, tt.wantRemove) {
+				t.Errorf("lambdaTagChanges() remove = %v, want %v", remove, tt.wantRemove)
+			}
+		})
+	}
+}
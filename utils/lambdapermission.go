@@ -0,0 +1,332 @@
+package ie2utilities
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+/***
+* Invoke permissions for api gateway in the resource policy of a lambda
+***/
+
+const LAMBDA_PERMISSION_PRINCIPAL = "apigateway.amazonaws.com"
+
+// lambdaPolicyStatement is the part of a resource policy statement the reconciler reads.
+// Principal is either "*" or an object, and condition values may be strings or lists.
+type lambdaPolicyStatement struct {
+	Sid       string
+	Principal json.RawMessage
+	Condition map[string]map[string]json.RawMessage
+}
+
+type lambdaPolicy struct {
+	Statement []lambdaPolicyStatement
+}
+
+// apiGateway is true for statements that let api gateway invoke the lambda.
+func (l *lambdaPolicyStatement) apiGateway() bool {
+
+	principal := struct{ Service json.RawMessage }{}
+
+	if json.Unmarshal(l.Principal, &principal) != nil {
+		return false
+	}
+
+	service := ""
+
+	return json.Unmarshal(principal.Service, &service) == nil && service == LAMBDA_PERMISSION_PRINCIPAL
+}
+
+// sourceArn returns the AWS:SourceArn the statement is conditioned on, or an empty string.
+func (l *lambdaPolicyStatement) sourceArn() string {
+
+	for _, condition := range l.Condition {
+		for k, v := range condition {
+
+			if !strings.EqualFold(k, "AWS:SourceArn") {
+				continue
+			}
+
+			arn := ""
+
+			if json.Unmarshal(v, &arn) == nil {
+				return arn
+			}
+		}
+	}
+
+	return ""
+}
+
+// apiGatewayArnPath turns a resource path into the form it takes in an execute-api arn.
+// Path parameters like {id} and {proxy+} match any value so they become *.
+func apiGatewayArnPath(path string) string {
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = "*"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// apiGatewaySourceArn is the arn of a method of an api in every stage. ANY methods match every method.
+func apiGatewaySourceArn(region string, accountid string, apiid string, method string, path string) string {
+
+	method = strings.ToUpper(method)
+
+	if method == "ANY" {
+		method = "*"
+	}
+
+	return fmt.Sprintf("arn:aws:execute-api:%s:%s:%s/*/%s/%s", region, accountid, apiid, method, apiGatewayArnPath(path))
+}
+
+// apiGatewayStatementId names the statement for a method of an api. Resource paths can be longer
+// than a statement id allows and use characters it doesn't, so the method and path are hashed.
+func apiGatewayStatementId(apiid string, method string, path string) string {
+
+	sum := sha256.Sum256([]byte(strings.ToUpper(method) + " " + apiGatewayArnPath(path)))
+
+	return fmt.Sprintf("apigateway-%s-%s", apiid, hex.EncodeToString(sum[:8]))
+}
+
+// lambdaPermissionTarget is the lambda, or lambda:alias, a permission applies to.
+func lambdaPermissionTarget(input *ie2datatypes.LambdaPermissionInput) string {
+
+	if len(input.Qualifier) > 0 {
+		return fmt.Sprintf("%s:%s", input.LambdaName, input.Qualifier)
+	}
+
+	return input.LambdaName
+}
+
+// getLambdaPolicy returns the statements in the resource policy of a lambda, or none if it has no policy.
+func getLambdaPolicy(c LambdaAPI, ctx context.Context, name string, qualifier string) ([]lambdaPolicyStatement, error) {
+
+	req := &lambda.GetPolicyInput{
+		FunctionName: aws.String(name),
+	}
+
+	if len(qualifier) > 0 {
+		req.Qualifier = aws.String(qualifier)
+	}
+
+	out, e := c.GetPolicy(ctx, req)
+
+	if e != nil {
+
+		if IsAWSNotFound(e) {
+			return nil, nil
+		}
+
+		return nil, e
+	}
+
+	policy := lambdaPolicy{}
+	e = json.Unmarshal([]byte(aws.ToString(out.Policy)), &policy)
+
+	if e != nil {
+		return nil, fmt.Errorf("can not read the policy of lambda %s: %w", name, e)
+	}
+
+	return policy.Statement, nil
+}
+
+// lambdaPermissionChanges compares the statements in a lambda policy with input.
+// It returns the statement ids to remove and the source arns to add, keyed by statement id.
+// Statements that already allow a desired source arn are kept whatever their id,
+// so permissions added by hand or by older versions of this module aren't churned.
+func lambdaPermissionChanges(statements []lambdaPolicyStatement, input *ie2datatypes.LambdaPermissionInput) ([]string, map[string]string) {
+
+	desired := map[string]string{}
+
+	for _, m := range input.Methods {
+		desired[apiGatewaySourceArn(input.Region, input.AccountId, input.ApiId, m.Method, m.Path)] = apiGatewayStatementId(input.ApiId, m.Method, m.Path)
+	}
+
+	prefix := fmt.Sprintf("arn:aws:execute-api:%s:%s:%s/", input.Region, input.AccountId, input.ApiId)
+	scope := apiGatewayArnPath(input.Path)
+
+	remove := []string{}
+	kept := map[string]bool{}
+	sids := map[string]string{}
+
+	for _, statement := range statements {
+
+		arn := statement.sourceArn()
+		sids[statement.Sid] = arn
+
+		if !statement.apiGateway() || !strings.HasPrefix(arn, prefix) {
+			continue
+		}
+
+		// <stage>/<method>/<path>, authorizers/<id> belongs to PutRESTAuthorizer
+		parts := strings.SplitN(strings.TrimPrefix(arn, prefix), "/", 3)
+
+		if parts[0] == "authorizers" {
+			continue
+		}
+
+		if len(input.Path) > 0 && (len(parts) < 3 || apiGatewayArnPath(parts[2]) != scope) {
+			continue
+		}
+
+		if _, ok := desired[arn]; ok && !kept[arn] {
+			kept[arn] = true
+			continue
+		}
+
+		remove = append(remove, statement.Sid)
+	}
+
+	add := map[string]string{}
+
+	for arn, sid := range desired {
+
+		if kept[arn] {
+			continue
+		}
+
+		// a statement with this id for another source arn has to go first
+		if current, ok := sids[sid]; ok && current != arn && !slices.Contains(remove, sid) {
+			remove = append(remove, sid)
+		}
+
+		add[sid] = arn
+	}
+
+	sort.Strings(remove)
+
+	return remove, add
+}
+
+// planLambdaPermissions reports the statements ReconcileApiGatewayPermissions would add and remove.
+func (s *AWSService) planLambdaPermissions(ctx context.Context, input *ie2datatypes.LambdaPermissionInput) ([]ie2datatypes.PlanChange, error) {
+
+	c, e := s.lambdaClient()
+
+	if e != nil {
+		return nil, e
+	}
+
+	statements, e := getLambdaPolicy(c, ctx, input.LambdaName, input.Qualifier)
+
+	if e != nil {
+		return nil, e
+	}
+
+	return lambdaPermissionPlan(statements, input), nil
+}
+
+func lambdaPermissionPlan(statements []lambdaPolicyStatement, input *ie2datatypes.LambdaPermissionInput) []ie2datatypes.PlanChange {
+
+	ret := []ie2datatypes.PlanChange{}
+	target := lambdaPermissionTarget(input)
+	remove, add := lambdaPermissionChanges(statements, input)
+
+	for _, sid := range remove {
+		ret = append(ret, ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionRemove, Kind: ie2datatypes.PlanKindPermission, Target: target, Field: sid})
+	}
+
+	sids := []string{}
+
+	for sid := range add {
+		sids = append(sids, sid)
+	}
+
+	sort.Strings(sids)
+
+	for _, sid := range sids {
+		ret = append(ret, ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindPermission, Target: target, Field: sid, Desired: add[sid]})
+	}
+
+	return ret
+}
+
+// ReconcileApiGatewayPermissions makes the api gateway statements in the policy of a lambda
+// match input, see ie2datatypes.LambdaPermissionInput. It is safe to run on every deploy.
+// It returns the statements it added and removed.
+func (s *AWSService) ReconcileApiGatewayPermissions(ctx context.Context, input *ie2datatypes.LambdaPermissionInput) ([]ie2datatypes.PlanChange, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context can not be empty")
+	}
+
+	if input == nil {
+		return nil, errors.New("input param can not be null")
+	}
+
+	if len(input.LambdaName) <= 0 || len(input.ApiId) <= 0 {
+		return nil, errors.New("lambda name and api id can not be empty")
+	}
+
+	c, e := s.lambdaClient()
+
+	if e != nil {
+		return nil, e
+	}
+
+	statements, e := getLambdaPolicy(c, ctx, input.LambdaName, input.Qualifier)
+
+	if e != nil {
+		return nil, e
+	}
+
+	changes := lambdaPermissionPlan(statements, input)
+
+	for _, change := range changes {
+
+		if change.Action == ie2datatypes.PlanActionRemove {
+
+			log.Printf("Removing permission %s from lambda %s", change.Field, change.Target)
+			req := &lambda.RemovePermissionInput{
+				FunctionName: aws.String(input.LambdaName),
+				StatementId:  aws.String(change.Field),
+			}
+
+			if len(input.Qualifier) > 0 {
+				req.Qualifier = aws.String(input.Qualifier)
+			}
+
+			_, e = c.RemovePermission(ctx, req)
+
+			// someone else removing it first is fine
+			if e != nil && !IsAWSNotFound(e) {
+				log.Print(e)
+				return nil, e
+			}
+
+			continue
+		}
+
+		log.Printf("Allowing %s to invoke lambda %s", change.Desired, change.Target)
+		e = s.addApiGatewayPermission(ctx, change.Field, change.Desired, input.LambdaName, input.Qualifier)
+
+		if e != nil {
+			log.Print(e)
+			return nil, e
+		}
+	}
+
+	if len(changes) <= 0 {
+		log.Printf("Lambda %s permissions for api %s are up to date.", lambdaPermissionTarget(input), input.ApiId)
+	}
+
+	return changes, nil
+}
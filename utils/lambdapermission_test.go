@@ -0,0 +1,193 @@
+package ie2utilities
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+const testArnPrefix = "arn:aws:execute-api:us-east-1:123456789012:"
+
+// policyStatement builds a statement letting principal invoke the lambda from arn.
+func policyStatement(sid string, principal string, arn string) lambdaPolicyStatement {
+
+	value, _ := json.Marshal(arn)
+
+	return lambdaPolicyStatement{
+		Sid:       sid,
+		Principal: json.RawMessage(`{"Service":"` + principal + `"}`),
+		Condition: map[string]map[string]json.RawMessage{"ArnLike": {"AWS:SourceArn": value}},
+	}
+}
+
+func TestApiGatewaySourceArn(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   string
+	}{
+		{name: "root", method: "GET", path: "/", want: testArnPrefix + "api/*/GET/"},
+		{name: "path", method: "post", path: "/v1/papers", want: testArnPrefix + "api/*/POST/v1/papers"},
+		{name: "parameter", method: "GET", path: "/v1/papers/{id}", want: testArnPrefix + "api/*/GET/v1/papers/*"},
+		{name: "greedy parameter", method: "GET", path: "/v1/{proxy+}", want: testArnPrefix + "api/*/GET/v1/*"},
+		{name: "any", method: "ANY", path: "/v1/papers/{id}", want: testArnPrefix + "api/*/*/v1/papers/*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if got := apiGatewaySourceArn("us-east-1", "123456789012", "api", tt.method, tt.path); got != tt.want {
+				t.Errorf("apiGatewaySourceArn() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApiGatewayStatementId(t *testing.T) {
+
+	id := apiGatewayStatementId("api", "GET", "/v1/papers/{id}")
+
+	tests := []struct {
+		name   string
+		apiid  string
+		method string
+		path   string
+		same   bool
+	}{
+		{name: "same method and path", apiid: "api", method: "GET", path: "/v1/papers/{id}", same: true},
+		{name: "method case", apiid: "api", method: "get", path: "/v1/papers/{id}", same: true},
+		{name: "parameter name", apiid: "api", method: "GET", path: "/v1/papers/{paperId}", same: true},
+		{name: "other method", apiid: "api", method: "POST", path: "/v1/papers/{id}"},
+		{name: "other path", apiid: "api", method: "GET", path: "/v1/papers"},
+		{name: "other api", apiid: "other", method: "GET", path: "/v1/papers/{id}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got := apiGatewayStatementId(tt.apiid, tt.method, tt.path)
+
+			if (got == id) != tt.same {
+				t.Errorf("apiGatewayStatementId() = %s, same as %s %v, want %v", got, id, got == id, tt.same)
+			}
+
+			// statement ids are at most 100 characters
+			if len(got) > 100 {
+				t.Errorf("apiGatewayStatementId() = %s is too long", got)
+			}
+		})
+	}
+}
+
+func TestLambdaPermissionChanges(t *testing.T) {
+
+	getPaper := ie2datatypes.LambdaPermissionMethod{Method: "GET", Path: "/v1/papers/{id}"}
+	anyProxy := ie2datatypes.LambdaPermissionMethod{Method: "ANY", Path: "/v1/{proxy+}"}
+
+	getPaperSid := apiGatewayStatementId("api", "GET", "/v1/papers/{id}")
+	getPaperArn := testArnPrefix + "api/*/GET/v1/papers/*"
+	anyProxySid := apiGatewayStatementId("api", "ANY", "/v1/{proxy+}")
+
+	tests := []struct {
+		name       string
+		statements []lambdaPolicyStatement
+		path       string
+		methods    []ie2datatypes.LambdaPermissionMethod
+		wantRemove []string
+		wantAdd    map[string]string
+	}{
+		{
+			name:       "no policy",
+			methods:    []ie2datatypes.LambdaPermissionMethod{getPaper, anyProxy},
+			wantRemove: []string{},
+			wantAdd:    map[string]string{getPaperSid: getPaperArn, anyProxySid: testArnPrefix + "api/*/*/v1/*"},
+		},
+		{
+			name:       "already allowed",
+			statements: []lambdaPolicyStatement{policyStatement(getPaperSid, LAMBDA_PERMISSION_PRINCIPAL, getPaperArn)},
+			methods:    []ie2datatypes.LambdaPermissionMethod{getPaper},
+			wantRemove: []string{},
+			wantAdd:    map[string]string{},
+		},
+		{
+			name:       "allowed by hand under another id",
+			statements: []lambdaPolicyStatement{policyStatement("manual", LAMBDA_PERMISSION_PRINCIPAL, getPaperArn)},
+			methods:    []ie2datatypes.LambdaPermissionMethod{getPaper},
+			wantRemove: []string{},
+			wantAdd:    map[string]string{},
+		},
+		{
+			name: "duplicate statement",
+			statements: []lambdaPolicyStatement{
+				policyStatement("a", LAMBDA_PERMISSION_PRINCIPAL, getPaperArn),
+				policyStatement("b", LAMBDA_PERMISSION_PRINCIPAL, getPaperArn),
+			},
+			methods:    []ie2datatypes.LambdaPermissionMethod{getPaper},
+			wantRemove: []string{"b"},
+			wantAdd:    map[string]string{},
+		},
+		{
+			name:       "method no longer deployed",
+			statements: []lambdaPolicyStatement{policyStatement("old", LAMBDA_PERMISSION_PRINCIPAL, testArnPrefix+"api/*/DELETE/v1/papers/*")},
+			methods:    []ie2datatypes.LambdaPermissionMethod{getPaper},
+			wantRemove: []string{"old"},
+			wantAdd:    map[string]string{getPaperSid: getPaperArn},
+		},
+		{
+			name: "other apis and principals are left alone",
+			statements: []lambdaPolicyStatement{
+				policyStatement("other-api", LAMBDA_PERMISSION_PRINCIPAL, testArnPrefix+"other/*/GET/v1/papers/*"),
+				policyStatement("events", "events.amazonaws.com", testArnPrefix+"api/*/GET/v1/authors"),
+			},
+			wantRemove: []string{},
+			wantAdd:    map[string]string{},
+		},
+		{
+			name:       "id used for another source arn",
+			statements: []lambdaPolicyStatement{policyStatement(getPaperSid, LAMBDA_PERMISSION_PRINCIPAL, testArnPrefix+"other/*/GET/v1/papers/*")},
+			methods:    []ie2datatypes.LambdaPermissionMethod{getPaper},
+			wantRemove: []string{getPaperSid},
+			wantAdd:    map[string]string{getPaperSid: getPaperArn},
+		},
+		{
+			name: "path scoped",
+			statements: []lambdaPolicyStatement{
+				policyStatement("old", LAMBDA_PERMISSION_PRINCIPAL, testArnPrefix+"api/*/DELETE/v1/papers/*"),
+				policyStatement("authors", LAMBDA_PERMISSION_PRINCIPAL, testArnPrefix+"api/*/GET/v1/authors"),
+			},
+			path:       "/v1/papers/{paperId}",
+			methods:    []ie2datatypes.LambdaPermissionMethod{getPaper},
+			wantRemove: []string{"old"},
+			wantAdd:    map[string]string{getPaperSid: getPaperArn},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			input := &ie2datatypes.LambdaPermissionInput{
+				LambdaName: "papers",
+				Region:     "us-east-1",
+				AccountId:  "123456789012",
+				ApiId:      "api",
+				Path:       tt.path,
+				Methods:    tt.methods,
+			}
+
+			remove, add := lambdaPermissionChanges(tt.statements, input)
+
+			if !slices.Equal(remove, tt.wantRemove) {
+				t.Errorf("lambdaPermissionChanges() remove = %v, want %v", remove, tt.wantRemove)
+			}
+
+			if !maps.Equal(add, tt.wantAdd) {
+				t.Errorf("lambdaPermissionChanges() add = %v, want %v", add, tt.wantAdd)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package ie2utilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+/***
+* Published versions and the aliases pointing at them
+***/
+
+// getLambdaAlias returns the alias, or nil if it doesn't exist.
+func getLambdaAlias(c LambdaAPI, ctx context.Context, name string, alias string) (*lambda.GetAliasOutput, error) {
+
+	out, e := c.GetAlias(ctx, &lambda.GetAliasInput{
+		FunctionName: aws.String(name),
+		Name:         aws.String(alias),
+	})
+
+	if e != nil {
+
+		e = ClassifyAWSError(e)
+
+		if errors.Is(e, ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, e
+	}
+
+	return out, nil
+}
+
+// lambdaVersions lists the published versions of a lambda in ascending order, without $LATEST.
+func lambdaVersions(c LambdaAPI, ctx context.Context, name string) ([]int64, error) {
+
+	ret := []int64{}
+	pages := lambda.NewListVersionsByFunctionPaginator(c, &lambda.ListVersionsByFunctionInput{
+		FunctionName: aws.String(name),
+	})
+
+	for pages.HasMorePages() {
+
+		out, e := pages.NextPage(ctx)
+
+		if e != nil {
+			return nil, e
+		}
+
+		for _, v := range out.Versions {
+
+			n, e := strconv.ParseInt(aws.ToString(v.Version), 10, 64)
+
+			if e == nil {
+				ret = append(ret, n)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// PublishLambdaVersion publishes the current code and configuration of a lambda
+// and returns the new version. If nothing changed since the last version, lambda
+// returns that version instead of publishing a new one.
+func (s *AWSService) PublishLambdaVersion(ctx context.Context, name string, description string) (string, error) {
+
+	if ctx == nil {
+		return "", errors.New("context can not be empty")
+	}
+
+	if len(name) <= 0 {
+		return "", errors.New("lambda name can not be empty")
+	}
+
+	c, e := s.lambdaClient()
+
+	if e != nil {
+		return "", e
+	}
+
+	log.Printf("Publishing a new version of lambda %s", name)
+	out, e := c.PublishVersion(ctx, &lambda.PublishVersionInput{
+		FunctionName: aws.String(name),
+		Description:  aws.String(description),
+	})
+
+	if e != nil {
+		log.Print(e)
+		return "", e
+	}
+
+	version := aws.ToString(out.Version)
+	log.Printf("Published lambda %s version %s", name, version)
+
+	return version, nil
+}
+
+// SetLambdaAlias points alias at version, creating the alias if needed.
+// Any weighted routing on the alias is removed, so it sends all traffic to version.
+// It returns true if the alias was created.
+func (s *AWSService) SetLambdaAlias(ctx context.Context, name string, alias string, version string) (bool, error) {
+
+	return s.routeLambdaAlias(ctx, name, alias, version, "", 0)
+}
+
+// ShiftLambdaAlias sends weight (0 to 1) of the traffic for alias to version
+// and the rest to the version the alias already points at.
+// A weight of 1 or more points the alias at version outright.
+func (s *AWSService) ShiftLambdaAlias(ctx context.Context, name string, alias string, version string, weight float64) error {
+
+	if weight >= 1 {
+		_, e := s.SetLambdaAlias(ctx, name, alias, version)
+		return e
+	}
+
+	c, e := s.lambdaClient()
+
+	if e != nil {
+		return e
+	}
+
+	current, e := getLambdaAlias(c, ctx, name, alias)
+
+	if e != nil {
+		return e
+	}
+
+	if current == nil {
+		return fmt.Errorf("alias %s of lambda %s does not exist", alias, name)
+	}
+
+	_, e = s.routeLambdaAlias(ctx, name, alias, aws.ToString(current.FunctionVersion), version, weight)
+
+	return e
+}
+
+// routeLambdaAlias points alias at version, sending weight of its traffic to extra when extra is set.
+func (s *AWSService) routeLambdaAlias(ctx context.Context, name string, alias string, version string, extra string, weight float64) (bool, error) {
+
+	if ctx == nil {
+		return false, errors.New("context can not be empty")
+	}
+
+	if len(name) <= 0 || len(alias) <= 0 || len(version) <= 0 {
+		return false, errors.New("lambda name, alias and version can not be empty")
+	}
+
+	c, e := s.lambdaClient()
+
+	if e != nil {
+		return false, e
+	}
+
+	// an empty weights map clears any routing left from an earlier shift
+	routing := &types.AliasRoutingConfiguration{AdditionalVersionWeights: map[string]float64{}}
+
+	if len(extra) > 0 && extra != version && weight > 0 {
+		routing.AdditionalVersionWeights[extra] = weight
+	}
+
+	current, e := getLambdaAlias(c, ctx, name, alias)
+
+	if e != nil {
+		return false, e
+	}
+
+	if current == nil {
+
+		log.Printf("Creating alias %s of lambda %s for version %s", alias, name, version)
+		_, e = c.CreateAlias(ctx, &lambda.CreateAliasInput{
+			FunctionName:    aws.String(name),
+			FunctionVersion: aws.String(version),
+			Name:            aws.String(alias),
+			RoutingConfig:   routing,
+		})
+
+		return true, e
+	}
+
+	log.Printf("Updating alias %s of lambda %s to version %s with %v additional routing", alias, name, version, routing.AdditionalVersionWeights)
+	_, e = c.UpdateAlias(ctx, &lambda.UpdateAliasInput{
+		FunctionName:    aws.String(name),
+		FunctionVersion: aws.String(version),
+		Name:            aws.String(alias),
+		RevisionId:      current.RevisionId,
+		RoutingConfig:   routing,
+	})
+
+	return false, e
+}
+
+// ShiftLambdaTraffic moves alias to version one weight at a time as described by shift,
+// then points the alias at version. If a step fails or shift.Check returns an error
+// all traffic goes back to the version the alias pointed at before.
+func (s *AWSService) ShiftLambdaTraffic(ctx context.Context, name string, alias string, version string, shift ie2datatypes.LambdaTrafficShift) error {
+
+	if ctx == nil {
+		return errors.New("context can not be empty")
+	}
+
+	c, e := s.lambdaClient()
+
+	if e != nil {
+		return e
+	}
+
+	current, e := getLambdaAlias(c, ctx, name, alias)
+
+	if e != nil {
+		return e
+	}
+
+	if current == nil {
+		return fmt.Errorf("alias %s of lambda %s does not exist", alias, name)
+	}
+
+	previous := aws.ToString(current.FunctionVersion)
+
+	rollback := func(cause error) error {
+
+		log.Printf("Shifting alias %s of lambda %s failed, sending all traffic back to version %s: %v", alias, name, previous, cause)
+
+		// the shift may have been stopped by ctx, the rollback still has to happen
+		_, e := s.SetLambdaAlias(context.WithoutCancel(ctx), name, alias, previous)
+
+		return errors.Join(cause, e)
+	}
+
+	for _, weight := range shift.Weights {
+
+		if weight >= 1 {
+			break
+		}
+
+		log.Printf("Sending %.0f%% of alias %s traffic to lambda %s version %s", weight*100, alias, name, version)
+		e = s.ShiftLambdaAlias(ctx, name, alias, version, weight)
+
+		if e != nil {
+			return rollback(e)
+		}
+
+		select {
+		case <-ctx.Done():
+			return rollback(ctx.Err())
+		case <-time.After(shift.Interval):
+		}
+
+		if shift.Check != nil {
+
+			e = shift.Check(weight)
+
+			if e != nil {
+				return rollback(e)
+			}
+		}
+	}
+
+	_, e = s.SetLambdaAlias(ctx, name, alias, version)
+
+	if e != nil {
+		return rollback(e)
+	}
+
+	log.Printf("Alias %s of lambda %s now points at version %s", alias, name, version)
+
+	return nil
+}
+
+// RollbackLambdaAlias undoes the last change to an alias. A shift in progress is
+// cancelled by sending all traffic to the version the alias points at, otherwise
+// the alias is pointed at the version published before its current one.
+// It returns the version the alias points at afterwards.
+func (s *AWSService) RollbackLambdaAlias(ctx context.Context, name string, alias string) (string, error) {
+
+	if ctx == nil {
+		return "", errors.New("context can not be empty")
+	}
+
+	c, e := s.lambdaClient()
+
+	if e != nil {
+		return "", e
+	}
+
+	current, e := getLambdaAlias(c, ctx, name, alias)
+
+	if e != nil {
+		return "", e
+	}
+
+	if current == nil {
+		return "", fmt.Errorf("alias %s of lambda %s does not exist", alias, name)
+	}
+
+	version := aws.ToString(current.FunctionVersion)
+
+	if current.RoutingConfig != nil && len(current.RoutingConfig.AdditionalVersionWeights) > 0 {
+
+		log.Printf("Cancelling traffic shift on alias %s of lambda %s", alias, name)
+		_, e = s.SetLambdaAlias(ctx, name, alias, version)
+
+		return version, e
+	}
+
+	n, e := strconv.ParseInt(version, 10, 64)
+
+	if e != nil {
+		return "", fmt.Errorf("alias %s of lambda %s points at %s, not a published version", alias, name, version)
+	}
+
+	versions, e := lambdaVersions(c, ctx, name)
+
+	if e != nil {
+		return "", e
+	}
+
+	previous := int64(0)
+
+	for _, v := range versions {
+		if v < n && v > previous {
+			previous = v
+		}
+	}
+
+	if previous <= 0 {
+		return "", fmt.Errorf("lambda %s has no version before %s to roll alias %s back to", name, version, alias)
+	}
+
+	version = strconv.FormatInt(previous, 10)
+	log.Printf("Rolling alias %s of lambda %s back to version %s", alias, name, version)
+	_, e = s.SetLambdaAlias(ctx, name, alias, version)
+
+	return version, e
+}
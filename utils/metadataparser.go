@@ -7,92 +7,45 @@ import (
 	"log"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
-	"gopkg.in/yaml.v3"
 )
 
-// TODO: This function is so similar to configparser, can we abstract and consolidate the functionality? -gb 8.21.24
-func MetaDataParser(conf *aws.Config, ctx *context.Context, bucket string, key string) (ie2datatypes.FileMetaData, error) {
-
-	ret := ie2datatypes.FileMetaData{}
-
-	log.Printf("Create S3 client from config.")
-	client := s3.NewFromConfig(*conf)
+func (s *AWSService) MetaDataParser(ctx context.Context, bucket string, key string) (ie2datatypes.FileMetaData, error) {
 
 	log.Printf("Attempting to parse metadata file: %s", bucket+"/"+key)
-	log.Printf("Retrieving object from S3...")
 
-	res, err := client.GetObject(*ctx, &s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-	})
+	c, err := s.s3Client()
 
 	if err != nil {
-		log.Printf("Error reading from S3.")
-		log.Printf("Bucket: %s", bucket)
-		log.Printf("Key: %s", key)
-		return ret, err
+		return ie2datatypes.FileMetaData{}, err
 	}
 
-	log.Print("GetObject result...")
-	log.Print(*res)
-	log.Printf("Unstarred val: %d", res.ContentLength)
-	log.Printf("File content length: %d", *res.ContentLength)
-
-	if *res.ContentLength > 0 {
-
-		log.Printf("File found! Attempting to parse...")
-
-		readBytes := int64(0)
-		buffer := new(bytes.Buffer)
-		readBytes, err = buffer.ReadFrom(res.Body)
-
-		if err != nil {
-			log.Printf("Error reading file!")
-			return ret, err
-		}
-
-		if readBytes > 0 {
-
-			log.Printf("Successfully read %d bytes.", readBytes)
-			log.Printf("%s", buffer.String())
-			log.Printf("Preparing to unmarshal JSON.")
-			err = yaml.Unmarshal(buffer.Bytes(), &ret)
+	return LoadS3DocumentContext[ie2datatypes.FileMetaData](ctx, c, bucket, key)
+}
 
-			if err != nil {
-				log.Printf("Error Unmarshalling config file to JSON.")
-				return ret, err
-			}
+// MetaDataParser is AWSService.MetaDataParser using clients built from conf.
+//
+// Deprecated: use AWSService.MetaDataParser, which takes a context.Context.
+func MetaDataParser(conf *aws.Config, ctx *context.Context, bucket string, key string) (ie2datatypes.FileMetaData, error) {
 
-			log.Printf("Successfully unmarshalled file!")
-			log.Print(&ret)
-		}
+	if conf == nil {
+		return ie2datatypes.FileMetaData{}, errors.New("aws.config can not be empty")
+	}
 
-	} else {
-		log.Printf("Unable to read file contents!")
+	if ctx == nil {
+		return ie2datatypes.FileMetaData{}, errors.New("context can not be empty")
 	}
 
-	return ret, nil
+	return NewAWSService(*conf).MetaDataParser(*ctx, bucket, key)
 }
 
 func AgenticMetaDataParser(buffer *bytes.Buffer) (ie2datatypes.AgenticFileMetaData, error) {
 
-	ret := ie2datatypes.AgenticFileMetaData{}
-
 	if buffer == nil || buffer.Len() <= 0 {
-		return ret, errors.New("can not parse agenticmetadata, the buffer is null or contains no unread data")
+		return ie2datatypes.AgenticFileMetaData{}, errors.Join(ErrDocumentEmpty, errors.New("can not parse agenticmetadata, the buffer is null or contains no unread data"))
 	}
 
-	log.Print("Buffer Contents:")
-	log.Printf("%s", buffer.String())
-	log.Printf("Preparing to unmarshal agenticmetadata json.")
-	err := yaml.Unmarshal(buffer.Bytes(), &ret)
-
-	if err != nil {
-		log.Printf("Error unmarshalling agentic metadata file to json.")
-		return ret, err
-	}
+	log.Printf("Preparing to decode agenticmetadata.")
 
-	return ret, nil
+	return DecodeDocument[ie2datatypes.AgenticFileMetaData](buffer.Bytes(), DocumentFormatUnknown)
 }
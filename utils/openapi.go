@@ -0,0 +1,502 @@
+package ie2utilities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	api "github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+/***
+* OpenAPI documents of REST apis built from LambdaConfigs
+***/
+
+const OPENAPI_VERSION = "3.0.1"
+
+// the security schemes of api keys and IAM authorization, authorizers use their own name
+const OPENAPI_API_KEY_SCHEME = "api_key"
+const OPENAPI_SIGV4_SCHEME = "sigv4"
+
+// openAPIRef points at a model in the components of a document.
+func openAPIRef(name string) map[string]string {
+	return map[string]string{"$ref": "#/components/schemas/" + name}
+}
+
+// openAPIContent is the content of a request or response body with models.
+func openAPIContent(models map[string]string) map[string]ie2datatypes.OpenAPIMediaType {
+
+	ret := map[string]ie2datatypes.OpenAPIMediaType{}
+
+	for contentType, model := range models {
+		ret[contentType] = ie2datatypes.OpenAPIMediaType{Schema: openAPIRef(model)}
+	}
+
+	return ret
+}
+
+// openAPIPathParameters lists the path parameters of a resource path. {proxy+} is named proxy.
+func openAPIPathParameters(path string) []ie2datatypes.OpenAPIParameter {
+
+	ret := []ie2datatypes.OpenAPIParameter{}
+
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+
+		if !restPathParameter(part) {
+			continue
+		}
+
+		ret = append(ret, ie2datatypes.OpenAPIParameter{
+			Name:     strings.TrimSuffix(strings.Trim(part, "{}"), "+"),
+			In:       "path",
+			Required: true,
+			Schema:   map[string]any{"type": "string"},
+		})
+	}
+
+	return ret
+}
+
+// openAPIMethod is the key of a method in a path item, ANY has an api gateway extension of its own.
+func openAPIMethod(method string) string {
+
+	if strings.EqualFold(method, "ANY") {
+		return "x-amazon-apigateway-any-method"
+	}
+
+	return strings.ToLower(method)
+}
+
+// openAPIAuthorizerScheme describes an authorizer as a security scheme.
+func openAPIAuthorizerScheme(authorizer *ie2datatypes.RESTAuthorizer, region string, accountid string) (ie2datatypes.OpenAPISecurityScheme, error) {
+
+	identity := authorizer.IdentitySource
+	ret := ie2datatypes.OpenAPISecurityScheme{Type: "apiKey", In: "header"}
+	gateway := &ie2datatypes.OpenAPIGatewayAuthorizer{
+		Type:                         strings.ToLower(authorizer.Type),
+		AuthorizerCredentials:        authorizer.CredentialsArn,
+		AuthorizerResultTtlInSeconds: authorizer.ResultTtl,
+	}
+
+	switch authorizer.Type {
+	case ie2datatypes.RESTAuthorizerToken, ie2datatypes.RESTAuthorizerRequest:
+
+		if len(authorizer.LambdaName) <= 0 {
+			return ret, fmt.Errorf("%s authorizer %s requires a lambda", authorizer.Type, authorizer.Name)
+		}
+
+		ret.AuthType = "custom"
+		gateway.AuthorizerUri = lambdaIntegrationUri(region, accountid, authorizer.LambdaName, authorizer.Qualifier)
+
+		if len(identity) <= 0 && authorizer.Type == ie2datatypes.RESTAuthorizerToken {
+			identity = REST_AUTHORIZER_IDENTITY_SOURCE
+		}
+
+	case ie2datatypes.RESTAuthorizerCognito:
+
+		if len(authorizer.ProviderArns) <= 0 {
+			return ret, fmt.Errorf("%s authorizer %s requires user pool arns", authorizer.Type, authorizer.Name)
+		}
+
+		ret.AuthType = "cognito_user_pools"
+		gateway.ProviderARNs = authorizer.ProviderArns
+
+		if len(identity) <= 0 {
+			identity = REST_AUTHORIZER_IDENTITY_SOURCE
+		}
+
+	default:
+		return ret, fmt.Errorf("unsupported authorizer type %s", authorizer.Type)
+	}
+
+	gateway.IdentitySource = identity
+	ret.Authorizer = gateway
+
+	// the scheme names the header the token is read from, REQUEST authorizers may read several
+	ret.Name = "Unused"
+
+	if header, ok := strings.CutPrefix(identity, "method.request.header."); ok && !strings.Contains(header, ",") {
+		ret.Name = header
+	}
+
+	return ret, nil
+}
+
+// openAPISecurity returns the security requirement of a method and adds the schemes it uses to doc.
+// Authorizers are referred to by name, they have no id until the document is imported.
+func openAPISecurity(doc *ie2datatypes.OpenAPIDocument, auth *ie2datatypes.RESTAuthorization, authorizers map[string]*ie2datatypes.RESTAuthorizer, region string, accountid string) ([]map[string][]string, error) {
+
+	if auth == nil {
+		auth = &ie2datatypes.RESTAuthorization{Type: ie2datatypes.RESTAuthorizationNone}
+	}
+
+	authtype := auth.Type
+
+	if len(authtype) <= 0 {
+		authtype = ie2datatypes.RESTAuthorizationNone
+	}
+
+	if len(auth.Scopes) > 0 && authtype != ie2datatypes.RESTAuthorizationCognito {
+		return nil, fmt.Errorf("scopes only apply to %s authorization", ie2datatypes.RESTAuthorizationCognito)
+	}
+
+	schemes := doc.Components.SecuritySchemes
+	requirement := map[string][]string{}
+
+	switch authtype {
+	case ie2datatypes.RESTAuthorizationNone:
+
+	case ie2datatypes.RESTAuthorizationIAM:
+
+		schemes[OPENAPI_SIGV4_SCHEME] = ie2datatypes.OpenAPISecurityScheme{Type: "apiKey", Name: "Authorization", In: "header", AuthType: "awsSigv4"}
+		requirement[OPENAPI_SIGV4_SCHEME] = []string{}
+
+	case ie2datatypes.RESTAuthorizationCognito, ie2datatypes.RESTAuthorizationCustom:
+
+		if len(auth.Authorizer) <= 0 {
+			return nil, fmt.Errorf("%s authorization needs an authorizer name to be described in openapi", authtype)
+		}
+
+		authorizer, ok := authorizers[auth.Authorizer]
+
+		if !ok {
+			return nil, fmt.Errorf("authorizer %s is not in the openapi options", auth.Authorizer)
+		}
+
+		scheme, e := openAPIAuthorizerScheme(authorizer, region, accountid)
+
+		if e != nil {
+			return nil, e
+		}
+
+		schemes[authorizer.Name] = scheme
+		requirement[authorizer.Name] = []string{}
+
+		if len(auth.Scopes) > 0 {
+			requirement[authorizer.Name] = auth.Scopes
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported authorization type %s", authtype)
+	}
+
+	if auth.RequiresApiKey() {
+		schemes[OPENAPI_API_KEY_SCHEME] = ie2datatypes.OpenAPISecurityScheme{Type: "apiKey", Name: "x-api-key", In: "header"}
+		requirement[OPENAPI_API_KEY_SCHEME] = []string{}
+	}
+
+	if len(requirement) <= 0 {
+		return nil, nil
+	}
+
+	return []map[string][]string{requirement}, nil
+}
+
+// openAPICorsOperation is the OPTIONS method putRESTCors creates, as a MOCK integration.
+func openAPICorsOperation(path string, cors *ie2datatypes.RESTCors, methods []string) *ie2datatypes.OpenAPIOperation {
+
+	headers := restCorsHeaders(cors, methods)
+	declared := map[string]ie2datatypes.OpenAPIHeader{}
+
+	for name := range headers {
+		declared[name] = ie2datatypes.OpenAPIHeader{Schema: map[string]string{"type": "string"}}
+	}
+
+	response := ie2datatypes.OpenAPIIntegrationResponse{
+		StatusCode:         "200",
+		ResponseParameters: restCorsParameters("method.response.header", headers),
+	}
+
+	if template := restCorsTemplate(cors); len(template) > 0 {
+		response.ResponseTemplates = map[string]string{REST_MODEL_CONTENT_TYPE: template}
+	}
+
+	return &ie2datatypes.OpenAPIOperation{
+		Parameters: openAPIPathParameters(path),
+		Responses: map[string]ie2datatypes.OpenAPIResponse{
+			"200": {Description: "200 response", Headers: declared},
+		},
+		Integration: &ie2datatypes.OpenAPIIntegration{
+			Type:                "mock",
+			PassthroughBehavior: "when_no_match",
+			RequestTemplates:    map[string]string{REST_MODEL_CONTENT_TYPE: `{"statusCode": 200}`},
+			Responses:           map[string]ie2datatypes.OpenAPIIntegrationResponse{"default": response},
+		},
+	}
+}
+
+// openAPIDocument describes the endpoints of configs, which must not share a method of a path.
+func openAPIDocument(configs []ie2datatypes.LambdaConfig, models []ie2datatypes.RESTModel, opts *ie2datatypes.OpenAPIOptions) (*ie2datatypes.OpenAPIDocument, error) {
+
+	doc := &ie2datatypes.OpenAPIDocument{
+		OpenAPI: OPENAPI_VERSION,
+		Info:    ie2datatypes.OpenAPIInfo{Title: opts.Title, Version: opts.Version},
+		Paths:   map[string]map[string]*ie2datatypes.OpenAPIOperation{},
+		Components: ie2datatypes.OpenAPIComponents{
+			Schemas:         map[string]json.RawMessage{},
+			SecuritySchemes: map[string]ie2datatypes.OpenAPISecurityScheme{},
+		},
+	}
+
+	for _, model := range models {
+		doc.Components.Schemas[model.Name] = json.RawMessage(model.Schema)
+	}
+
+	authorizers := map[string]*ie2datatypes.RESTAuthorizer{}
+
+	for i := range opts.Authorizers {
+		authorizers[opts.Authorizers[i].Name] = &opts.Authorizers[i]
+	}
+
+	for i := range configs {
+
+		config := &configs[i]
+		uri := lambdaIntegrationUri(opts.Region, opts.AccountId, config.Name, config.Alias)
+
+		for j := range config.Endpoint {
+
+			endpoint := &config.Endpoint[j]
+			path := endpointPath(endpoint)
+
+			e := validateRESTResourcePath(path)
+
+			if e != nil {
+				return nil, e
+			}
+
+			input := endpointInput(config, endpoint, &ie2datatypes.DeployOptions{}, opts.AccountId, opts.Region, "", "")
+
+			if endpoint.Cors != nil {
+
+				e = validateRESTCors(endpoint.Cors, input.Methods)
+
+				if e != nil {
+					return nil, e
+				}
+			}
+
+			item, ok := doc.Paths[path]
+
+			if !ok {
+				item = map[string]*ie2datatypes.OpenAPIOperation{}
+				doc.Paths[path] = item
+			}
+
+			names := []string{}
+
+			for _, method := range input.Methods {
+
+				key := openAPIMethod(method.Name)
+
+				if _, ok := item[key]; ok {
+					return nil, fmt.Errorf("%s %s is declared more than once", method.Name, path)
+				}
+
+				security, e := openAPISecurity(doc, method.Authorization, authorizers, opts.Region, opts.AccountId)
+
+				if e != nil {
+					return nil, fmt.Errorf("%s %s: %w", method.Name, path, e)
+				}
+
+				operation := &ie2datatypes.OpenAPIOperation{
+					Parameters: openAPIPathParameters(path),
+					Responses: map[string]ie2datatypes.OpenAPIResponse{
+						"200": {Description: "200 response", Content: openAPIContent(method.ResModel)},
+					},
+					Security: security,
+					Integration: &ie2datatypes.OpenAPIIntegration{
+						Type:                "aws_proxy",
+						HttpMethod:          "POST",
+						Uri:                 uri,
+						PassthroughBehavior: "when_no_match",
+					},
+				}
+
+				if len(method.ReqModel) > 0 {
+
+					operation.RequestBody = &ie2datatypes.OpenAPIRequestBody{Required: true, Content: openAPIContent(method.ReqModel)}
+					operation.RequestValidator = REST_REQUEST_VALIDATOR
+
+					doc.RequestValidators = map[string]ie2datatypes.OpenAPIRequestValidator{
+						REST_REQUEST_VALIDATOR: {ValidateRequestBody: true},
+					}
+				}
+
+				item[key] = operation
+				names = append(names, method.Name)
+			}
+
+			if endpoint.Cors == nil {
+				continue
+			}
+
+			if _, ok := item["options"]; ok {
+				return nil, fmt.Errorf("OPTIONS %s is declared more than once", path)
+			}
+
+			item["options"] = openAPICorsOperation(path, endpoint.Cors, names)
+
+			// shared by the whole api, like putRESTCorsGatewayResponses the last endpoint wins
+			params := restCorsGatewayParameters(endpoint.Cors, names)
+			doc.GatewayResponses = map[string]ie2datatypes.OpenAPIGatewayResponse{}
+
+			for _, response := range REST_CORS_GATEWAY_RESPONSES {
+				doc.GatewayResponses[string(response)] = ie2datatypes.OpenAPIGatewayResponse{ResponseParameters: params}
+			}
+		}
+	}
+
+	return doc, nil
+}
+
+// GenerateOpenAPI describes the endpoints of configs as an OpenAPI 3 document with the api gateway
+// extensions Deploy's resources, methods, models and CORS would need. It reads the Req and Res
+// schemas of methods like Deploy does. Marshal the document to json to pass it to ImportOpenAPI.
+func (s *AWSService) GenerateOpenAPI(ctx context.Context, configs []ie2datatypes.LambdaConfig, opts ie2datatypes.OpenAPIOptions) (*ie2datatypes.OpenAPIDocument, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context can not be empty")
+	}
+
+	if len(configs) <= 0 {
+		return nil, errors.New("configs can not be empty")
+	}
+
+	if len(opts.Title) <= 0 {
+		opts.Title = configs[0].Name
+	}
+
+	if len(opts.Version) <= 0 {
+		opts.Version = "1.0"
+	}
+
+	if len(opts.Region) <= 0 {
+		opts.Region = s.Region
+	}
+
+	if len(opts.AccountId) <= 0 {
+
+		id, e := s.GetAccountId(ctx)
+
+		if e != nil {
+			return nil, e
+		}
+
+		opts.AccountId = id
+	}
+
+	// models are shared by the api, configs can use the same schema but not the same name for two
+	models := []ie2datatypes.RESTModel{}
+	schemas := map[string]string{}
+
+	for i := range configs {
+
+		loaded, e := s.deployModels(ctx, &configs[i], &ie2datatypes.DeployOptions{SchemaDir: opts.SchemaDir})
+
+		if e != nil {
+			return nil, e
+		}
+
+		for _, model := range loaded {
+
+			if schema, ok := schemas[model.Name]; ok {
+
+				if schema != model.Schema {
+					return nil, fmt.Errorf("model %s has a different schema in lambda %s", model.Name, configs[i].Name)
+				}
+
+				continue
+			}
+
+			schemas[model.Name] = model.Schema
+			models = append(models, model)
+		}
+	}
+
+	return openAPIDocument(configs, models, &opts)
+}
+
+// ImportOpenAPI applies an OpenAPI document to an existing api with PutRestApi.
+// Merge adds the document to what the api already has, overwrite replaces the api with it.
+// Lambda permissions are not touched, see ReconcileApiGatewayPermissions.
+func (s *AWSService) ImportOpenAPI(ctx context.Context, input *ie2datatypes.OpenAPIImportInput) error {
+
+	if ctx == nil {
+		return errors.New("context can not be empty")
+	}
+
+	if input == nil {
+		return errors.New("input param can not be null")
+	}
+
+	if len(input.Document) <= 0 {
+		return errors.New("openapi document can not be empty")
+	}
+
+	mode := input.Mode
+
+	if len(mode) <= 0 {
+		mode = ie2datatypes.OpenAPIModeMerge
+	}
+
+	if mode != ie2datatypes.OpenAPIModeMerge && mode != ie2datatypes.OpenAPIModeOverwrite {
+		return fmt.Errorf("unsupported import mode %s", mode)
+	}
+
+	apiid := input.ApiId
+
+	if len(apiid) <= 0 {
+
+		if len(input.ApiName) <= 0 {
+			return errors.New("either an api id or an api name is required")
+		}
+
+		id, e := s.GetRESTApiIdFromName(ctx, input.ApiName)
+
+		if e != nil {
+			return e
+		}
+
+		if len(id) <= 0 {
+			return fmt.Errorf("rest api %s does not exist", input.ApiName)
+		}
+
+		apiid = id
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return e
+	}
+
+	log.Printf("Importing openapi document into api %s, mode %s", apiid, mode)
+	_, e = c.PutRestApi(ctx, &api.PutRestApiInput{
+		RestApiId:      aws.String(apiid),
+		Body:           input.Document,
+		Mode:           types.PutMode(mode),
+		FailOnWarnings: input.FailOnWarnings,
+	})
+
+	// whatever happened, the resources we know of may be stale now
+	s.ForgetRESTResources(apiid)
+
+	if e != nil {
+		log.Print(e)
+		return e
+	}
+
+	if len(input.Stage) <= 0 {
+		return nil
+	}
+
+	_, e = deployRESTStage(c, ctx, apiid, input.Stage)
+
+	return e
+}
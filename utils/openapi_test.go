@@ -0,0 +1,138 @@
+package ie2utilities
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+
+	opts := &ie2datatypes.OpenAPIOptions{
+		Title:       "papers",
+		Version:     "1.0",
+		AccountId:   "123456789012",
+		Region:      "us-east-1",
+		Authorizers: []ie2datatypes.RESTAuthorizer{{Name: "users", Type: ie2datatypes.RESTAuthorizerCognito, ProviderArns: []string{"arn:pool"}}},
+	}
+
+	papers := func(methods ...ie2datatypes.LambdaMethodConfig) ie2datatypes.LambdaConfig {
+		return ie2datatypes.LambdaConfig{Name: "papers", Alias: "live", Endpoint: []ie2datatypes.LambdaEndpointConfig{{Version: 1, Resource: "papers", Methods: methods}}}
+	}
+
+	noKey := false
+
+	tests := []struct {
+		name    string
+		configs []ie2datatypes.LambdaConfig
+		models  []ie2datatypes.RESTModel
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "api key by default",
+			configs: []ie2datatypes.LambdaConfig{papers(ie2datatypes.LambdaMethodConfig{Name: "get"})},
+			want:    []string{"/v1/papers get api_key"},
+		},
+		{
+			name: "authorizers and models",
+			configs: []ie2datatypes.LambdaConfig{papers(
+				ie2datatypes.LambdaMethodConfig{Name: "post", Req: "paper-request.json", Auth: &ie2datatypes.RESTAuthorization{Type: ie2datatypes.RESTAuthorizationCognito, Authorizer: "users", Scopes: []string{"papers/write"}}},
+				ie2datatypes.LambdaMethodConfig{Name: "any", Auth: &ie2datatypes.RESTAuthorization{Type: ie2datatypes.RESTAuthorizationIAM, ApiKeyRequired: &noKey}},
+			)},
+			models: []ie2datatypes.RESTModel{{Name: "PaperRequest", Schema: `{"type":"object"}`}},
+			want:   []string{"/v1/papers post api_key,users validator", "/v1/papers x-amazon-apigateway-any-method sigv4"},
+		},
+		{
+			name: "cors",
+			configs: []ie2datatypes.LambdaConfig{{Name: "papers", Endpoint: []ie2datatypes.LambdaEndpointConfig{{
+				Resource: "papers/{proxy+}",
+				Methods:  []ie2datatypes.LambdaMethodConfig{{Name: "get"}},
+				Cors:     &ie2datatypes.RESTCors{Origins: []string{"*"}},
+			}}}},
+			want: []string{"/papers/{proxy+} get api_key", "/papers/{proxy+} options"},
+		},
+		{
+			name:    "duplicate method",
+			configs: []ie2datatypes.LambdaConfig{papers(ie2datatypes.LambdaMethodConfig{Name: "get"}), papers(ie2datatypes.LambdaMethodConfig{Name: "GET"})},
+			wantErr: "declared more than once",
+		},
+		{
+			name:    "unknown authorizer",
+			configs: []ie2datatypes.LambdaConfig{papers(ie2datatypes.LambdaMethodConfig{Name: "get", Auth: &ie2datatypes.RESTAuthorization{Type: ie2datatypes.RESTAuthorizationCustom, Authorizer: "partners"}})},
+			wantErr: "partners",
+		},
+		{
+			name: "options with cors",
+			configs: []ie2datatypes.LambdaConfig{{Name: "papers", Endpoint: []ie2datatypes.LambdaEndpointConfig{{
+				Resource: "papers",
+				Methods:  []ie2datatypes.LambdaMethodConfig{{Name: "options"}},
+				Cors:     &ie2datatypes.RESTCors{Origins: []string{"*"}},
+			}}}},
+			wantErr: "OPTIONS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			doc, e := openAPIDocument(tt.configs, tt.models, opts)
+
+			if len(tt.wantErr) > 0 {
+
+				if e == nil || !strings.Contains(e.Error(), tt.wantErr) {
+					t.Fatalf("openAPIDocument() error = %v, want %s", e, tt.wantErr)
+				}
+
+				return
+			}
+
+			if e != nil {
+				t.Fatalf("openAPIDocument() error = %v", e)
+			}
+
+			got := []string{}
+
+			for path, item := range doc.Paths {
+				for method, op := range item {
+
+					schemes := []string{}
+
+					for _, requirement := range op.Security {
+						for scheme := range requirement {
+							schemes = append(schemes, scheme)
+						}
+					}
+
+					sort.Strings(schemes)
+					s := strings.TrimSpace(path + " " + method + " " + strings.Join(schemes, ","))
+
+					if len(op.RequestValidator) > 0 {
+						s += " validator"
+					}
+
+					got = append(got, s)
+
+					if method != "options" && op.Integration.Uri != lambdaIntegrationUri(opts.Region, opts.AccountId, tt.configs[0].Name, tt.configs[0].Alias) {
+						t.Errorf("%s %s integration uri = %s", method, path, op.Integration.Uri)
+					}
+				}
+			}
+
+			sort.Strings(got)
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("openAPIDocument() = %q, want %q", got, tt.want)
+			}
+
+			for _, model := range tt.models {
+				if _, ok := doc.Components.Schemas[model.Name]; !ok {
+					t.Errorf("model %s is missing from the schemas", model.Name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,615 @@
+package ie2utilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	api "github.com/aws/aws-sdk-go-v2/service/apigateway"
+	apitypes "github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+/***
+* Plan mode reads the current state of a deployment and compares it with the desired
+* config without changing anything.
+***/
+
+func planChange(kind string, target string, field string, current string, desired string) ie2datatypes.PlanChange {
+	return ie2datatypes.PlanChange{
+		Action:  ie2datatypes.PlanActionChange,
+		Kind:    kind,
+		Target:  target,
+		Field:   field,
+		Current: current,
+		Desired: desired,
+	}
+}
+
+// planMethodAuthorization compares the authorization of a method with auth. Authorizers
+// given by name are only compared by type, resolving them would need another lookup.
+func planMethodAuthorization(current *apitypes.Method, target string, auth *ie2datatypes.RESTAuthorization) []ie2datatypes.PlanChange {
+
+	ret := []ie2datatypes.PlanChange{}
+	desired := &restMethodAuth{Type: ie2datatypes.RESTAuthorizationNone, ApiKeyRequired: true}
+
+	if auth != nil {
+
+		desired = &restMethodAuth{Type: auth.Type, AuthorizerId: auth.AuthorizerId, Scopes: auth.Scopes, ApiKeyRequired: auth.RequiresApiKey()}
+
+		if len(desired.Type) <= 0 {
+			desired.Type = ie2datatypes.RESTAuthorizationNone
+		}
+	}
+
+	if aws.ToString(current.AuthorizationType) != desired.Type {
+		ret = append(ret, planChange(ie2datatypes.PlanKindMethod, target, "authorization", aws.ToString(current.AuthorizationType), desired.Type))
+	}
+
+	if len(desired.AuthorizerId) > 0 && aws.ToString(current.AuthorizerId) != desired.AuthorizerId {
+		ret = append(ret, planChange(ie2datatypes.PlanKindMethod, target, "authorizer", aws.ToString(current.AuthorizerId), desired.AuthorizerId))
+	}
+
+	if sortedList(current.AuthorizationScopes) != sortedList(desired.Scopes) {
+		ret = append(ret, planChange(ie2datatypes.PlanKindMethod, target, "scopes", sortedList(current.AuthorizationScopes), sortedList(desired.Scopes)))
+	}
+
+	if aws.ToBool(current.ApiKeyRequired) != desired.ApiKeyRequired {
+		ret = append(ret, planChange(ie2datatypes.PlanKindMethod, target, "apikeyrequired", strconv.FormatBool(aws.ToBool(current.ApiKeyRequired)), strconv.FormatBool(desired.ApiKeyRequired)))
+	}
+
+	return ret
+}
+
+// sortedModels lists models as content type=model, sorted so they compare equal.
+func sortedModels(models map[string]string) string {
+
+	ret := []string{}
+
+	for k, v := range models {
+		ret = append(ret, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	return sortedList(ret)
+}
+
+// planMethodModels compares the request validation and 200 response models of a method with method.
+func planMethodModels(current *apitypes.Method, target string, method *ie2datatypes.RESTMethod) []ie2datatypes.PlanChange {
+
+	ret := []ie2datatypes.PlanChange{}
+
+	if sortedModels(current.RequestModels) != sortedModels(method.ReqModel) {
+		ret = append(ret, planChange(ie2datatypes.PlanKindMethod, target, "requestmodels", sortedModels(current.RequestModels), sortedModels(method.ReqModel)))
+	}
+
+	validated := len(aws.ToString(current.RequestValidatorId)) > 0
+
+	if validated != (len(method.ReqModel) > 0) {
+		ret = append(ret, planChange(ie2datatypes.PlanKindMethod, target, "validation", strconv.FormatBool(validated), strconv.FormatBool(!validated)))
+	}
+
+	response := current.MethodResponses["200"]
+
+	if sortedModels(response.ResponseModels) != sortedModels(method.ResModel) {
+		ret = append(ret, planChange(ie2datatypes.PlanKindMethod, target, "responsemodels", sortedModels(response.ResponseModels), sortedModels(method.ResModel)))
+	}
+
+	return ret
+}
+
+// planMethods compares the methods and integrations on an existing resource with the desired ones.
+// With cors the resource also needs an OPTIONS method with a MOCK integration.
+func planMethods(resource *apitypes.Resource, path string, uri string, methods []ie2datatypes.RESTMethod, cors *ie2datatypes.RESTCors) []ie2datatypes.PlanChange {
+
+	ret := []ie2datatypes.PlanChange{}
+	desired := map[string]bool{}
+
+	if cors != nil {
+
+		desired["OPTIONS"] = true
+		names := []string{}
+
+		for _, method := range methods {
+			names = append(names, strings.ToUpper(method.Name))
+		}
+
+		var current *apitypes.Method
+
+		if resource != nil {
+			if m, ok := resource.ResourceMethods["OPTIONS"]; ok {
+				current = &m
+			}
+		}
+
+		ret = append(ret, planRESTCors(current, fmt.Sprintf("OPTIONS %s", path), cors, names)...)
+	}
+
+	for _, method := range methods {
+
+		name := strings.ToUpper(method.Name)
+		target := fmt.Sprintf("%s %s", name, path)
+		desired[name] = true
+
+		var current *apitypes.Method
+
+		if resource != nil {
+			if m, ok := resource.ResourceMethods[name]; ok {
+				current = &m
+			}
+		}
+
+		if current == nil {
+			ret = append(ret,
+				ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindMethod, Target: target},
+				ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindIntegration, Target: target, Desired: uri})
+			continue
+		}
+
+		ret = append(ret, planMethodAuthorization(current, target, method.Authorization)...)
+		ret = append(ret, planMethodModels(current, target, &method)...)
+
+		integration := current.MethodIntegration
+
+		if integration == nil {
+			ret = append(ret, ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindIntegration, Target: target, Desired: uri})
+			continue
+		}
+
+		if integration.Type != apitypes.IntegrationTypeAwsProxy {
+			ret = append(ret, planChange(ie2datatypes.PlanKindIntegration, target, "type", string(integration.Type), string(apitypes.IntegrationTypeAwsProxy)))
+		}
+
+		if aws.ToString(integration.Uri) != uri {
+			ret = append(ret, planChange(ie2datatypes.PlanKindIntegration, target, "uri", aws.ToString(integration.Uri), uri))
+		}
+	}
+
+	// anything left on the resource is not declared by the config,
+	// deploys leave these in place so they are reported as drift to clean up
+	if resource != nil {
+
+		names := []string{}
+
+		for name := range resource.ResourceMethods {
+			if !desired[name] {
+				names = append(names, name)
+			}
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			ret = append(ret, ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionRemove, Kind: ie2datatypes.PlanKindMethod, Target: fmt.Sprintf("%s %s", name, path)})
+		}
+	}
+
+	return ret
+}
+
+// planRESTEndpoint compares one endpoint against the resources of its api.
+func planRESTEndpoint(resources map[string]apitypes.Resource, input *ie2datatypes.RESTEndpointInput) ([]ie2datatypes.PlanChange, error) {
+
+	if input.Integration == nil || len(input.Integration.LambdaName) <= 0 {
+		return nil, errors.New("lambda name can not be empty")
+	}
+
+	ret := []ie2datatypes.PlanChange{}
+	path := "/" + strings.Trim(input.ResourceName, "/")
+	uri := lambdaIntegrationUri(input.Region, input.AccountId, input.Integration.LambdaName, input.Integration.Qualifier)
+
+	var resource *apitypes.Resource
+
+	if len(input.ResourceId) > 0 {
+
+		for _, r := range resources {
+			if aws.ToString(r.Id) == input.ResourceId {
+				found := r
+				resource = &found
+				path = aws.ToString(r.Path)
+				break
+			}
+		}
+
+		if resource == nil {
+			return nil, fmt.Errorf("resource %s does not exist on api %s", input.ResourceId, input.ApiId)
+		}
+
+	} else {
+
+		// every segment that doesn't exist yet will be created
+		current := ""
+
+		for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+
+			current += "/" + part
+
+			if _, ok := resources[current]; !ok {
+				ret = append(ret, ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindResource, Target: current})
+			}
+		}
+
+		if r, ok := resources[path]; ok {
+			resource = &r
+		}
+	}
+
+	ret = append(ret, planMethods(resource, path, uri, input.Methods, input.Cors)...)
+
+	return ret, nil
+}
+
+// planStage reports whether the stage will be created or pointed at a new deployment.
+// Deploy redeploys an existing stage every time, but that only changes what the stage
+// serves when something under it changed.
+func planStage(c APIGatewayAPI, ctx context.Context, apiid string, stage string, changed bool) ([]ie2datatypes.PlanChange, error) {
+
+	target := fmt.Sprintf("%s/%s", apiid, stage)
+
+	out, e := c.GetStage(ctx, &api.GetStageInput{
+		RestApiId: aws.String(apiid),
+		StageName: aws.String(stage),
+	})
+
+	if e != nil {
+
+		e = ClassifyAWSError(e)
+
+		if errors.Is(e, ErrNotFound) {
+			return []ie2datatypes.PlanChange{{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindStage, Target: target}}, nil
+		}
+
+		return nil, e
+	}
+
+	if !changed {
+		return []ie2datatypes.PlanChange{}, nil
+	}
+
+	return []ie2datatypes.PlanChange{planChange(ie2datatypes.PlanKindStage, target, "deploymentId", aws.ToString(out.DeploymentId), "new deployment")}, nil
+}
+
+// planLambdaAlias reports whether the alias will be created or moved to the version the deploy publishes.
+// Publishing an unchanged lambda returns its latest version, so the alias only moves when
+// the lambda changes or the alias isn't on that version. planned is the plan for the lambda itself.
+func (s *AWSService) planLambdaAlias(ctx context.Context, name string, alias string, planned []ie2datatypes.PlanChange) ([]ie2datatypes.PlanChange, error) {
+
+	target := fmt.Sprintf("%s:%s", name, alias)
+
+	c, e := s.lambdaClient()
+
+	if e != nil {
+		return nil, e
+	}
+
+	current, e := getLambdaAlias(c, ctx, name, alias)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if current == nil {
+		return []ie2datatypes.PlanChange{{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindAlias, Target: target, Desired: "new version"}}, nil
+	}
+
+	change := planChange(ie2datatypes.PlanKindAlias, target, "version", aws.ToString(current.FunctionVersion), "new version")
+
+	// the alias moves if the lambda does, and is as unknown as the lambda is
+	if len(planned) > 0 {
+
+		change.Action = ie2datatypes.PlanActionUnknown
+
+		for _, p := range planned {
+			if p.Action != ie2datatypes.PlanActionUnknown {
+				change.Action = ie2datatypes.PlanActionChange
+			}
+		}
+
+		return []ie2datatypes.PlanChange{change}, nil
+	}
+
+	versions, e := lambdaVersions(c, ctx, name)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if len(versions) > 0 && strconv.FormatInt(versions[len(versions)-1], 10) == aws.ToString(current.FunctionVersion) {
+		return []ie2datatypes.PlanChange{}, nil
+	}
+
+	return []ie2datatypes.PlanChange{change}, nil
+}
+
+// PlanLambda compares the deployed configuration of a lambda with input.
+// Code is reported as changing when its CodeSha256 differs from the deployed code, or with Force,
+// and as unknown when its CodeSha256 can't be known without deploying it, e.g. an image or
+// an S3 object without a recorded checksum.
+func (s *AWSService) PlanLambda(ctx context.Context, input *ie2datatypes.LambdaInput) ([]ie2datatypes.PlanChange, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context can not be empty")
+	}
+
+	if input == nil {
+		return nil, errors.New("lambdaconfig can not be empty")
+	}
+
+	c, e := s.lambdaClient()
+
+	if e != nil {
+		return nil, e
+	}
+
+	log.Printf("Planning lambda %s", input.Name)
+	out, e := c.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String(input.Name),
+	})
+
+	if e != nil {
+
+		e = ClassifyAWSError(e)
+
+		if errors.Is(e, ErrNotFound) {
+			return []ie2datatypes.PlanChange{{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindLambda, Target: input.Name}}, nil
+		}
+
+		return nil, e
+	}
+
+	current := out.Configuration
+	ret, _ := lambdaConfigurationChanges(current, input)
+
+	if input.Tags != nil {
+		ret = append(ret, lambdaTagPlan(input.Name, out.Tags, input.Tags)...)
+	}
+
+	arch := ""
+
+	if len(current.Architectures) > 0 {
+		arch = string(current.Architectures[0])
+	}
+
+	// an empty architecture keeps the deployed one, like lambdaCodeUnchanged
+	if len(input.Architecture) > 0 && arch != input.Architecture {
+		ret = append(ret, planChange(ie2datatypes.PlanKindLambda, input.Name, "architecture", arch, input.Architecture))
+	}
+
+	code, e := s.readLambdaCode(ctx, input)
+
+	if e != nil {
+		return nil, e
+	}
+
+	deployed := aws.ToString(current.CodeSha256)
+	change := planChange(ie2datatypes.PlanKindLambda, input.Name, "code", deployed, describeLambdaCode(code))
+
+	if input.Force || (len(code.CodeSha256) > 0 && code.CodeSha256 != deployed) {
+		ret = append(ret, change)
+	} else if len(code.CodeSha256) <= 0 {
+		change.Action = ie2datatypes.PlanActionUnknown
+		ret = append(ret, change)
+	}
+
+	return ret, nil
+}
+
+// PlanRESTEndpoint compares the resource, methods and integrations of one endpoint with input.
+// The resource is looked up by ResourceId when set, otherwise by ResourceName as a full path.
+func (s *AWSService) PlanRESTEndpoint(ctx context.Context, input *ie2datatypes.RESTEndpointInput) ([]ie2datatypes.PlanChange, error) {
+
+	if input == nil {
+		return nil, errors.New("input param can not be null")
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return nil, e
+	}
+
+	resources, e := getRESTResourcesByPath(c, ctx, input.ApiId)
+
+	if e != nil {
+		return nil, e
+	}
+
+	return planRESTEndpoint(resources, input)
+}
+
+// PlanDeploy reports what Deploy would do with the same config and options
+// without changing anything.
+func (s *AWSService) PlanDeploy(ctx context.Context, config ie2datatypes.LambdaConfig, opts ie2datatypes.DeployOptions) (*ie2datatypes.DeployPlan, error) {
+
+	plan := &ie2datatypes.DeployPlan{}
+
+	if ctx == nil {
+		return plan, errors.New("context can not be empty")
+	}
+
+	if len(config.Name) <= 0 {
+		return plan, errors.New("lambda name can not be empty")
+	}
+
+	region := opts.Region
+
+	if len(region) <= 0 {
+		region = s.Region
+	}
+
+	accountid := opts.AccountId
+
+	if len(accountid) <= 0 {
+
+		id, e := s.GetAccountId(ctx)
+
+		if e != nil {
+			return plan, e
+		}
+
+		accountid = id
+	}
+
+	changes, e := s.PlanLambda(ctx, deployLambdaInput(&config, &opts, accountid))
+
+	if e != nil {
+		return plan, e
+	}
+
+	plan.Changes = append(plan.Changes, changes...)
+
+	if len(config.Alias) > 0 {
+
+		alias, e := s.planLambdaAlias(ctx, config.Name, config.Alias, changes)
+
+		if e != nil {
+			return plan, e
+		}
+
+		plan.Changes = append(plan.Changes, alias...)
+	}
+
+	if len(config.Endpoint) <= 0 {
+		return plan, nil
+	}
+
+	if len(opts.Stage) <= 0 {
+		return plan, errors.New("stage can not be empty when deploying endpoints")
+	}
+
+	apiid := opts.ApiId
+
+	if len(apiid) <= 0 {
+
+		if len(opts.ApiName) <= 0 {
+			return plan, errors.New("either an api id or an api name is required when deploying endpoints")
+		}
+
+		apiid, e = s.GetRESTApiIdFromName(ctx, opts.ApiName)
+
+		if e != nil {
+			return plan, e
+		}
+
+		if len(apiid) <= 0 {
+			return plan, fmt.Errorf("rest api %s does not exist", opts.ApiName)
+		}
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return plan, e
+	}
+
+	models, e := s.deployModels(ctx, &config, &opts)
+
+	if e != nil {
+		return plan, e
+	}
+
+	// the models and endpoints are what the stage serves
+	unstaged := len(plan.Changes)
+
+	for i := range models {
+
+		changes, e := planRESTModel(c, ctx, apiid, &models[i])
+
+		if e != nil {
+			return plan, e
+		}
+
+		plan.Changes = append(plan.Changes, changes...)
+	}
+
+	resources, e := getRESTResourcesByPath(c, ctx, apiid)
+
+	if e != nil {
+		return plan, e
+	}
+
+	for i := range config.Endpoint {
+
+		input := endpointInput(&config, &config.Endpoint[i], &opts, accountid, region, apiid, "")
+		changes, e := planRESTEndpoint(resources, input)
+
+		if e != nil {
+			return plan, e
+		}
+
+		plan.Changes = append(plan.Changes, changes...)
+	}
+
+	staged := len(plan.Changes) > unstaged
+
+	changes, e = s.planLambdaPermissions(ctx, deployPermissions(&config, accountid, region, apiid))
+
+	if e != nil {
+		return plan, e
+	}
+
+	plan.Changes = append(plan.Changes, changes...)
+
+	changes, e = planStage(c, ctx, apiid, opts.Stage, staged)
+
+	if e != nil {
+		return plan, e
+	}
+
+	plan.Changes = append(plan.Changes, changes...)
+
+	return plan, nil
+}
+
+// AWSPlanLambda is AWSService.PlanLambda using clients built from conf.
+//
+// Deprecated: use AWSService.PlanLambda, which takes a context.Context.
+func AWSPlanLambda(conf *aws.Config, ctx *context.Context, input *ie2datatypes.LambdaInput) ([]ie2datatypes.PlanChange, error) {
+
+	if conf == nil {
+		return nil, errors.New("aws.config can not be empty")
+	}
+
+	if ctx == nil {
+		return nil, errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).PlanLambda(*ctx, input)
+}
+
+// AWSPlanRESTEndpoint is AWSService.PlanRESTEndpoint using clients built from conf.
+//
+// Deprecated: use AWSService.PlanRESTEndpoint, which takes a context.Context.
+func AWSPlanRESTEndpoint(conf *aws.Config, ctx *context.Context, input *ie2datatypes.RESTEndpointInput) ([]ie2datatypes.PlanChange, error) {
+
+	if conf == nil {
+		return nil, errors.New("aws.config param can not be null")
+	}
+
+	if ctx == nil {
+		return nil, errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).PlanRESTEndpoint(*ctx, input)
+}
+
+// AWSPlanDeploy is AWSService.PlanDeploy using clients built from conf.
+//
+// Deprecated: use AWSService.PlanDeploy, which takes a context.Context.
+func AWSPlanDeploy(conf *aws.Config, ctx *context.Context, config ie2datatypes.LambdaConfig, opts ie2datatypes.DeployOptions) (*ie2datatypes.DeployPlan, error) {
+
+	if conf == nil {
+		return &ie2datatypes.DeployPlan{}, errors.New("aws.config can not be empty")
+	}
+
+	if ctx == nil {
+		return &ie2datatypes.DeployPlan{}, errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).PlanDeploy(*ctx, config, opts)
+}
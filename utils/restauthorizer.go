@@ -0,0 +1,448 @@
+package ie2utilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	api "github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+/***
+* Authorizers on REST apis and the authorization of their methods
+***/
+
+// the header tokens are read from unless an authorizer says otherwise
+const REST_AUTHORIZER_IDENTITY_SOURCE = "method.request.header.Authorization"
+
+// restMethodAuth is a RESTAuthorization with the authorizer resolved to its id.
+type restMethodAuth struct {
+	Type           string
+	AuthorizerId   string
+	Scopes         []string
+	ApiKeyRequired bool
+}
+
+// getRESTAuthorizers lists every authorizer of an api.
+func getRESTAuthorizers(c APIGatewayAPI, ctx context.Context, apiid string) ([]types.Authorizer, error) {
+
+	ret := []types.Authorizer{}
+	var position *string
+
+	for {
+
+		out, e := c.GetAuthorizers(ctx, &api.GetAuthorizersInput{
+			RestApiId: aws.String(apiid),
+			Limit:     aws.Int32(500),
+			Position:  position,
+		})
+
+		if e != nil {
+			return nil, e
+		}
+
+		ret = append(ret, out.Items...)
+
+		if out.Position == nil {
+			break
+		}
+
+		position = out.Position
+	}
+
+	return ret, nil
+}
+
+// getRESTAuthorizer returns the authorizer with the name given, or nil if there is none.
+func getRESTAuthorizer(c APIGatewayAPI, ctx context.Context, apiid string, name string) (*types.Authorizer, error) {
+
+	authorizers, e := getRESTAuthorizers(c, ctx, apiid)
+
+	if e != nil {
+		return nil, e
+	}
+
+	for i := range authorizers {
+		if aws.ToString(authorizers[i].Name) == name {
+			return &authorizers[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// resolveRESTAuthorization checks auth and looks up its authorizer by name when needed.
+func resolveRESTAuthorization(c APIGatewayAPI, ctx context.Context, apiid string, auth *ie2datatypes.RESTAuthorization) (*restMethodAuth, error) {
+
+	if auth == nil {
+		return &restMethodAuth{Type: ie2datatypes.RESTAuthorizationNone, ApiKeyRequired: true}, nil
+	}
+
+	ret := &restMethodAuth{
+		Type:           auth.Type,
+		AuthorizerId:   auth.AuthorizerId,
+		Scopes:         auth.Scopes,
+		ApiKeyRequired: auth.RequiresApiKey(),
+	}
+
+	if len(ret.Type) <= 0 {
+		ret.Type = ie2datatypes.RESTAuthorizationNone
+	}
+
+	switch ret.Type {
+	case ie2datatypes.RESTAuthorizationNone, ie2datatypes.RESTAuthorizationIAM:
+
+		if len(auth.Authorizer) > 0 || len(auth.AuthorizerId) > 0 {
+			return nil, fmt.Errorf("%s authorization does not use an authorizer", ret.Type)
+		}
+
+	case ie2datatypes.RESTAuthorizationCognito, ie2datatypes.RESTAuthorizationCustom:
+
+		if len(ret.AuthorizerId) > 0 {
+			break
+		}
+
+		if len(auth.Authorizer) <= 0 {
+			return nil, fmt.Errorf("%s authorization requires an authorizer", ret.Type)
+		}
+
+		authorizer, e := getRESTAuthorizer(c, ctx, apiid, auth.Authorizer)
+
+		if e != nil {
+			return nil, e
+		}
+
+		if authorizer == nil {
+			return nil, fmt.Errorf("authorizer %s does not exist on api %s", auth.Authorizer, apiid)
+		}
+
+		ret.AuthorizerId = aws.ToString(authorizer.Id)
+
+	default:
+		return nil, fmt.Errorf("unsupported authorization type %s", ret.Type)
+	}
+
+	if len(ret.Scopes) > 0 && ret.Type != ie2datatypes.RESTAuthorizationCognito {
+		return nil, fmt.Errorf("scopes only apply to %s authorization", ie2datatypes.RESTAuthorizationCognito)
+	}
+
+	return ret, nil
+}
+
+// restMethodAuthPatch returns the operations that change the authorization of a method to auth.
+func restMethodAuthPatch(current *types.Method, auth *restMethodAuth) []types.PatchOperation {
+
+	ops := []types.PatchOperation{}
+
+	replace := func(path string, value string) {
+		ops = append(ops, types.PatchOperation{Op: types.OpReplace, Path: aws.String(path), Value: aws.String(value)})
+	}
+
+	if aws.ToString(current.AuthorizationType) != auth.Type {
+		replace("/authorizationType", auth.Type)
+	}
+
+	// NONE and AWS_IAM methods have no authorizer, an empty id can't be set so it is removed
+	if aws.ToString(current.AuthorizerId) != auth.AuthorizerId {
+
+		if len(auth.AuthorizerId) > 0 {
+			replace("/authorizerId", auth.AuthorizerId)
+		} else {
+			ops = append(ops, types.PatchOperation{Op: types.OpRemove, Path: aws.String("/authorizerId")})
+		}
+	}
+
+	if aws.ToBool(current.ApiKeyRequired) != auth.ApiKeyRequired {
+		replace("/apiKeyRequired", strconv.FormatBool(auth.ApiKeyRequired))
+	}
+
+	for _, scope := range auth.Scopes {
+		if !slices.Contains(current.AuthorizationScopes, scope) {
+			ops = append(ops, types.PatchOperation{Op: types.OpAdd, Path: aws.String("/authorizationScopes"), Value: aws.String(scope)})
+		}
+	}
+
+	for _, scope := range current.AuthorizationScopes {
+		if !slices.Contains(auth.Scopes, scope) {
+			ops = append(ops, types.PatchOperation{Op: types.OpRemove, Path: aws.String("/authorizationScopes"), Value: aws.String(scope)})
+		}
+	}
+
+	return ops
+}
+
+// updateRESTMethodAuthorization changes the authorization of an existing method to auth if it differs.
+func updateRESTMethodAuthorization(c APIGatewayAPI, ctx context.Context, apiid string, resourceid string, method string, auth *restMethodAuth) error {
+
+	out, e := c.GetMethod(ctx, &api.GetMethodInput{
+		HttpMethod: aws.String(method),
+		ResourceId: aws.String(resourceid),
+		RestApiId:  aws.String(apiid),
+	})
+
+	if e != nil {
+		return e
+	}
+
+	ops := restMethodAuthPatch(&types.Method{
+		ApiKeyRequired:      out.ApiKeyRequired,
+		AuthorizationScopes: out.AuthorizationScopes,
+		AuthorizationType:   out.AuthorizationType,
+		AuthorizerId:        out.AuthorizerId,
+	}, auth)
+
+	if len(ops) <= 0 {
+		return nil
+	}
+
+	log.Printf("Updating authorization of method %s on resource %s to %s", method, resourceid, auth.Type)
+	_, e = c.UpdateMethod(ctx, &api.UpdateMethodInput{
+		HttpMethod:      aws.String(method),
+		ResourceId:      aws.String(resourceid),
+		RestApiId:       aws.String(apiid),
+		PatchOperations: ops,
+	})
+
+	return e
+}
+
+// SetRESTMethodAuthorization attaches auth to an existing method, e.g. to put a method
+// behind an authorizer created with PutRESTAuthorizer. A nil auth restores the
+// default of NONE with an api key required.
+func (s *AWSService) SetRESTMethodAuthorization(ctx context.Context, apiid string, resourceid string, method string, auth *ie2datatypes.RESTAuthorization) error {
+
+	if ctx == nil {
+		return errors.New("context can not be empty")
+	}
+
+	if len(apiid) <= 0 || len(resourceid) <= 0 || len(method) <= 0 {
+		return errors.New("apiid, resourceid and method can not be empty")
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return e
+	}
+
+	resolved, e := resolveRESTAuthorization(c, ctx, apiid, auth)
+
+	if e != nil {
+		return e
+	}
+
+	return updateRESTMethodAuthorization(c, ctx, apiid, resourceid, method, resolved)
+}
+
+// GetRESTAuthorizerIdFromName returns the id of the authorizer with the name given,
+// or an empty string if there is none.
+func (s *AWSService) GetRESTAuthorizerIdFromName(ctx context.Context, apiid string, name string) (string, error) {
+
+	if ctx == nil {
+		return "", errors.New("context can not be empty")
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return "", e
+	}
+
+	authorizer, e := getRESTAuthorizer(c, ctx, apiid, name)
+
+	if e != nil || authorizer == nil {
+		return "", e
+	}
+
+	return aws.ToString(authorizer.Id), nil
+}
+
+// PutRESTAuthorizer creates the authorizer described by input on an api, or updates the
+// one with the same name, and returns its id. Lambda authorizers are given permission
+// to invoke their lambda.
+func (s *AWSService) PutRESTAuthorizer(ctx context.Context, apiid string, input *ie2datatypes.RESTAuthorizer) (string, error) {
+
+	if ctx == nil {
+		return "", errors.New("context can not be empty")
+	}
+
+	if input == nil {
+		return "", errors.New("input param can not be null")
+	}
+
+	if len(apiid) <= 0 || len(input.Name) <= 0 {
+		return "", errors.New("apiid and authorizer name can not be empty")
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return "", e
+	}
+
+	identity := input.IdentitySource
+	uri := ""
+	accountid := ""
+
+	switch input.Type {
+	case ie2datatypes.RESTAuthorizerToken, ie2datatypes.RESTAuthorizerRequest:
+
+		if len(input.LambdaName) <= 0 {
+			return "", fmt.Errorf("%s authorizer %s requires a lambda", input.Type, input.Name)
+		}
+
+		accountid, e = s.GetAccountId(ctx)
+
+		if e != nil {
+			return "", e
+		}
+
+		uri = lambdaIntegrationUri(s.Region, accountid, input.LambdaName, input.Qualifier)
+
+		if len(identity) <= 0 && input.Type == ie2datatypes.RESTAuthorizerToken {
+			identity = REST_AUTHORIZER_IDENTITY_SOURCE
+		}
+
+	case ie2datatypes.RESTAuthorizerCognito:
+
+		if len(input.ProviderArns) <= 0 {
+			return "", fmt.Errorf("%s authorizer %s requires user pool arns", input.Type, input.Name)
+		}
+
+		if len(identity) <= 0 {
+			identity = REST_AUTHORIZER_IDENTITY_SOURCE
+		}
+
+	default:
+		return "", fmt.Errorf("unsupported authorizer type %s", input.Type)
+	}
+
+	current, e := getRESTAuthorizer(c, ctx, apiid, input.Name)
+
+	if e != nil {
+		return "", e
+	}
+
+	id := ""
+
+	if current == nil {
+
+		req := &api.CreateAuthorizerInput{
+			Name:      aws.String(input.Name),
+			RestApiId: aws.String(apiid),
+			Type:      types.AuthorizerType(input.Type),
+		}
+
+		if len(uri) > 0 {
+			req.AuthorizerUri = aws.String(uri)
+		}
+
+		if len(identity) > 0 {
+			req.IdentitySource = aws.String(identity)
+		}
+
+		if len(input.ProviderArns) > 0 {
+			req.ProviderARNs = input.ProviderArns
+		}
+
+		if input.ResultTtl > 0 {
+			req.AuthorizerResultTtlInSeconds = aws.Int32(input.ResultTtl)
+		}
+
+		if len(input.CredentialsArn) > 0 {
+			req.AuthorizerCredentials = aws.String(input.CredentialsArn)
+		}
+
+		log.Printf("Creating %s authorizer %s on api %s", input.Type, input.Name, apiid)
+		out, e := c.CreateAuthorizer(ctx, req)
+
+		if e != nil {
+			log.Print(e)
+			return "", e
+		}
+
+		id = aws.ToString(out.Id)
+
+	} else {
+
+		id = aws.ToString(current.Id)
+		ops := []types.PatchOperation{}
+
+		// fields that are no longer wanted are removed, api gateway rejects empty values
+		replace := func(path string, from string, to string) {
+
+			if from == to {
+				return
+			}
+
+			if len(to) <= 0 {
+				ops = append(ops, types.PatchOperation{Op: types.OpRemove, Path: aws.String(path)})
+				return
+			}
+
+			ops = append(ops, types.PatchOperation{Op: types.OpReplace, Path: aws.String(path), Value: aws.String(to)})
+		}
+
+		replace("/type", string(current.Type), input.Type)
+		replace("/authorizerUri", aws.ToString(current.AuthorizerUri), uri)
+		replace("/identitySource", aws.ToString(current.IdentitySource), identity)
+		replace("/authorizerCredentials", aws.ToString(current.AuthorizerCredentials), input.CredentialsArn)
+
+		if input.ResultTtl > 0 {
+			replace("/authorizerResultTtlInSeconds", strconv.Itoa(int(aws.ToInt32(current.AuthorizerResultTtlInSeconds))), strconv.Itoa(int(input.ResultTtl)))
+		}
+
+		for _, arn := range input.ProviderArns {
+			if !slices.Contains(current.ProviderARNs, arn) {
+				ops = append(ops, types.PatchOperation{Op: types.OpAdd, Path: aws.String("/providerARNs"), Value: aws.String(arn)})
+			}
+		}
+
+		for _, arn := range current.ProviderARNs {
+			if !slices.Contains(input.ProviderArns, arn) {
+				ops = append(ops, types.PatchOperation{Op: types.OpRemove, Path: aws.String("/providerARNs"), Value: aws.String(arn)})
+			}
+		}
+
+		if len(ops) > 0 {
+
+			log.Printf("Updating authorizer %s on api %s", input.Name, apiid)
+			_, e = c.UpdateAuthorizer(ctx, &api.UpdateAuthorizerInput{
+				AuthorizerId:    aws.String(id),
+				RestApiId:       aws.String(apiid),
+				PatchOperations: ops,
+			})
+
+			if e != nil {
+				log.Print(e)
+				return "", e
+			}
+
+		} else {
+
+			log.Printf("Authorizer %s on api %s is up to date.", input.Name, apiid)
+		}
+	}
+
+	if len(input.LambdaName) <= 0 {
+		return id, nil
+	}
+
+	// the statement is named after the authorizer so a conflict means it is already there
+	sourcearn := fmt.Sprintf("arn:aws:execute-api:%s:%s:%s/authorizers/%s", s.Region, accountid, apiid, id)
+	sid := fmt.Sprintf("apigateway-%s-authorizer-%s", apiid, id)
+	e = ClassifyAWSError(s.addApiGatewayPermission(ctx, sid, sourcearn, input.LambdaName, input.Qualifier))
+
+	if e != nil && !errors.Is(e, ErrConflict) {
+		log.Print(e)
+		return "", e
+	}
+
+	return id, nil
+}
@@ -0,0 +1,76 @@
+package ie2utilities
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+)
+
+func TestRESTMethodAuthPatch(t *testing.T) {
+
+	custom := types.Method{AuthorizationType: aws.String("CUSTOM"), AuthorizerId: aws.String("auth1"), ApiKeyRequired: aws.Bool(true)}
+	cognito := types.Method{AuthorizationType: aws.String("COGNITO_USER_POOLS"), AuthorizerId: aws.String("pool1"), AuthorizationScopes: []string{"papers/read", "papers/write"}}
+
+	tests := []struct {
+		name    string
+		current types.Method
+		auth    restMethodAuth
+		want    []string
+	}{
+		{
+			name:    "unchanged",
+			current: custom,
+			auth:    restMethodAuth{Type: "CUSTOM", AuthorizerId: "auth1", ApiKeyRequired: true},
+			want:    []string{},
+		},
+		{
+			name:    "none to custom",
+			current: types.Method{AuthorizationType: aws.String("NONE")},
+			auth:    restMethodAuth{Type: "CUSTOM", AuthorizerId: "auth1"},
+			want:    []string{"replace /authorizationType CUSTOM", "replace /authorizerId auth1"},
+		},
+		{
+			name:    "custom to none",
+			current: custom,
+			auth:    restMethodAuth{Type: "NONE", ApiKeyRequired: true},
+			want:    []string{"replace /authorizationType NONE", "remove /authorizerId"},
+		},
+		{
+			name:    "custom to iam",
+			current: custom,
+			auth:    restMethodAuth{Type: "AWS_IAM"},
+			want:    []string{"replace /authorizationType AWS_IAM", "remove /authorizerId", "replace /apiKeyRequired false"},
+		},
+		{
+			name:    "other authorizer",
+			current: custom,
+			auth:    restMethodAuth{Type: "CUSTOM", AuthorizerId: "auth2", ApiKeyRequired: true},
+			want:    []string{"replace /authorizerId auth2"},
+		},
+		{
+			name:    "empty authorizer id",
+			current: types.Method{AuthorizationType: aws.String("NONE"), AuthorizerId: aws.String("")},
+			auth:    restMethodAuth{Type: "NONE"},
+			want:    []string{},
+		},
+		{
+			name:    "scopes",
+			current: cognito,
+			auth:    restMethodAuth{Type: "COGNITO_USER_POOLS", AuthorizerId: "pool1", Scopes: []string{"papers/read", "papers/admin"}},
+			want:    []string{"add /authorizationScopes papers/admin", "remove /authorizationScopes papers/write"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got := patchStrings(restMethodAuthPatch(&tt.current, &tt.auth))
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("restMethodAuthPatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
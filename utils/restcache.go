@@ -0,0 +1,64 @@
+package ie2utilities
+
+import (
+	"context"
+	"maps"
+
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+)
+
+/***
+* Resources of each api are listed once per AWSService and kept up to date as
+* resources are created, so deploying many routes doesn't re-list the whole tree.
+***/
+
+// restResources returns the resources of an api keyed by full path, listing them on first use.
+// The methods of the resources aren't included, plans list them fresh with getRESTResourcesByPath.
+func (s *AWSService) restResources(c APIGatewayAPI, ctx context.Context, apiid string) (map[string]types.Resource, error) {
+
+	s.restCacheMu.Lock()
+	cached, ok := s.restCache[apiid]
+	s.restCacheMu.Unlock()
+
+	if ok {
+		return maps.Clone(cached), nil
+	}
+
+	resources, e := listRESTResources(c, ctx, apiid, false)
+
+	if e != nil {
+		return nil, e
+	}
+
+	s.restCacheMu.Lock()
+	defer s.restCacheMu.Unlock()
+
+	if s.restCache == nil {
+		s.restCache = map[string]map[string]types.Resource{}
+	}
+
+	s.restCache[apiid] = resources
+
+	return maps.Clone(resources), nil
+}
+
+// cacheRESTResource records a resource created by this service.
+func (s *AWSService) cacheRESTResource(apiid string, resource types.Resource) {
+
+	s.restCacheMu.Lock()
+	defer s.restCacheMu.Unlock()
+
+	if cached, ok := s.restCache[apiid]; ok && resource.Path != nil {
+		cached[*resource.Path] = resource
+	}
+}
+
+// ForgetRESTResources drops the cached resources of an api, e.g. after they were
+// changed outside this service. They are listed again on next use.
+func (s *AWSService) ForgetRESTResources(apiid string) {
+
+	s.restCacheMu.Lock()
+	defer s.restCacheMu.Unlock()
+
+	delete(s.restCache, apiid)
+}
@@ -0,0 +1,363 @@
+package ie2utilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	api "github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+/***
+* CORS preflight methods and headers on REST resources
+***/
+
+// the headers api gateway clients send, the same defaults the console uses
+var REST_CORS_HEADERS = []string{"Content-Type", "X-Amz-Date", "Authorization", "X-Api-Key", "X-Amz-Security-Token"}
+
+// the gateway responses that get CORS headers, so browsers can read errors from api gateway itself
+var REST_CORS_GATEWAY_RESPONSES = []types.GatewayResponseType{types.GatewayResponseTypeDefault4xx, types.GatewayResponseTypeDefault5xx}
+
+// validateRESTCors checks cors and the methods it is for before anything is created.
+func validateRESTCors(cors *ie2datatypes.RESTCors, methods []ie2datatypes.RESTMethod) error {
+
+	for _, m := range methods {
+		if strings.EqualFold(m.Name, "OPTIONS") {
+			return errors.New("the OPTIONS method is created by cors and can not be declared as well")
+		}
+	}
+
+	if len(cors.Origins) <= 0 {
+		return errors.New("cors needs at least one origin")
+	}
+
+	if cors.Credentials && slices.Contains(cors.Origins, "*") {
+		return errors.New("cors can not allow credentials from any origin")
+	}
+
+	return nil
+}
+
+// restCorsHeaders returns the CORS response headers and their values for a resource with methods.
+// Only one origin fits in a static header, see restCorsTemplate for the others.
+func restCorsHeaders(cors *ie2datatypes.RESTCors, methods []string) map[string]string {
+
+	allowed := cors.Methods
+
+	if len(allowed) <= 0 {
+		allowed = methods
+	}
+
+	upper := []string{}
+
+	for _, m := range allowed {
+		upper = append(upper, strings.ToUpper(m))
+	}
+
+	if !slices.Contains(upper, "OPTIONS") {
+		upper = append(upper, "OPTIONS")
+	}
+
+	headers := cors.Headers
+
+	if len(headers) <= 0 {
+		headers = REST_CORS_HEADERS
+	}
+
+	origin := cors.Origins[0]
+
+	if slices.Contains(cors.Origins, "*") {
+		origin = "*"
+	}
+
+	ret := map[string]string{
+		"Access-Control-Allow-Origin":  origin,
+		"Access-Control-Allow-Methods": strings.Join(upper, ","),
+		"Access-Control-Allow-Headers": strings.Join(headers, ","),
+	}
+
+	if cors.MaxAge > 0 {
+		ret["Access-Control-Max-Age"] = strconv.Itoa(int(cors.MaxAge))
+	}
+
+	if cors.Credentials {
+		ret["Access-Control-Allow-Credentials"] = "true"
+	}
+
+	return ret
+}
+
+// restCorsTemplate is the preflight response template. With more than one origin it echoes
+// the Origin of the request back when it is one of them, otherwise the first origin is sent.
+func restCorsTemplate(cors *ie2datatypes.RESTCors) string {
+
+	if len(cors.Origins) <= 1 || slices.Contains(cors.Origins, "*") {
+		return ""
+	}
+
+	quoted := []string{}
+
+	for _, origin := range cors.Origins {
+		quoted = append(quoted, fmt.Sprintf("%q", origin))
+	}
+
+	return fmt.Sprintf(`#set($origin = $input.params().header.get("Origin"))
+#if($origin == "")#set($origin = $input.params().header.get("origin"))#end
+#if([%s].contains($origin))
+#set($context.responseOverride.header.Access-Control-Allow-Origin = $origin)
+#end`, strings.Join(quoted, ", "))
+}
+
+// restCorsParameters turns headers into the response parameters of a method, integration or
+// gateway response. prefix is e.g. method.response.header, values are quoted as static values.
+func restCorsParameters(prefix string, headers map[string]string) map[string]string {
+
+	ret := map[string]string{}
+
+	for name, value := range headers {
+		ret[fmt.Sprintf("%s.%s", prefix, name)] = fmt.Sprintf("'%s'", value)
+	}
+
+	return ret
+}
+
+// planRESTCors compares the OPTIONS method of a resource, nil when there is none, with the one
+// cors needs for methods. Headers are reported one by one as header:<name>.
+func planRESTCors(current *types.Method, target string, cors *ie2datatypes.RESTCors, methods []string) []ie2datatypes.PlanChange {
+
+	if current == nil {
+		return []ie2datatypes.PlanChange{{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindMethod, Target: target, Desired: string(types.IntegrationTypeMock)}}
+	}
+
+	ret := []ie2datatypes.PlanChange{}
+
+	if aws.ToString(current.AuthorizationType) != ie2datatypes.RESTAuthorizationNone {
+		ret = append(ret, planChange(ie2datatypes.PlanKindMethod, target, "authorization", aws.ToString(current.AuthorizationType), ie2datatypes.RESTAuthorizationNone))
+	}
+
+	if aws.ToBool(current.ApiKeyRequired) {
+		ret = append(ret, planChange(ie2datatypes.PlanKindMethod, target, "apikeyrequired", "true", "false"))
+	}
+
+	integration := current.MethodIntegration
+
+	if integration == nil {
+		return append(ret, ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindIntegration, Target: target, Desired: string(types.IntegrationTypeMock)})
+	}
+
+	if integration.Type != types.IntegrationTypeMock {
+		ret = append(ret, planChange(ie2datatypes.PlanKindIntegration, target, "type", string(integration.Type), string(types.IntegrationTypeMock)))
+	}
+
+	response := integration.IntegrationResponses["200"]
+	desired := restCorsHeaders(cors, methods)
+	names := []string{}
+
+	for name := range desired {
+		names = append(names, name)
+	}
+
+	for param := range response.ResponseParameters {
+		if name, ok := strings.CutPrefix(param, "method.response.header."); ok {
+			if _, ok := desired[name]; !ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		value, ok := response.ResponseParameters["method.response.header."+name]
+		value = strings.Trim(value, "'")
+		want, wanted := desired[name]
+		field := "header:" + name
+
+		switch {
+		case !ok:
+			ret = append(ret, ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindIntegration, Target: target, Field: field, Desired: want})
+		case !wanted:
+			ret = append(ret, ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionRemove, Kind: ie2datatypes.PlanKindIntegration, Target: target, Field: field, Current: value})
+		case value != want:
+			ret = append(ret, planChange(ie2datatypes.PlanKindIntegration, target, field, value, want))
+		default:
+			// the integration sends the header, the method response has to declare it as well
+			if _, declared := current.MethodResponses["200"].ResponseParameters["method.response.header."+name]; !declared {
+				ret = append(ret, ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindMethod, Target: target, Field: field})
+			}
+		}
+	}
+
+	if template := restCorsTemplate(cors); response.ResponseTemplates[REST_MODEL_CONTENT_TYPE] != template {
+		ret = append(ret, planChange(ie2datatypes.PlanKindIntegration, target, "origins", response.ResponseTemplates[REST_MODEL_CONTENT_TYPE], template))
+	}
+
+	return ret
+}
+
+// putRESTCorsMethod replaces the OPTIONS method of a resource with a MOCK integration
+// answering preflight requests, unless it already answers them the way cors wants.
+// Browsers don't send api keys or credentials on preflight requests so the method needs neither.
+func putRESTCorsMethod(c APIGatewayAPI, ctx context.Context, apiid string, resourceid string, cors *ie2datatypes.RESTCors, methods []string) error {
+
+	out, e := c.GetMethod(ctx, &api.GetMethodInput{
+		RestApiId:  aws.String(apiid),
+		ResourceId: aws.String(resourceid),
+		HttpMethod: aws.String("OPTIONS"),
+	})
+
+	if e != nil && !IsAWSNotFound(e) {
+		return e
+	}
+
+	if e == nil {
+
+		changes := planRESTCors(&types.Method{
+			AuthorizationType: out.AuthorizationType,
+			ApiKeyRequired:    out.ApiKeyRequired,
+			MethodIntegration: out.MethodIntegration,
+			MethodResponses:   out.MethodResponses,
+		}, "OPTIONS", cors, methods)
+
+		if len(changes) <= 0 {
+			log.Printf("OPTIONS method of resource %s is up to date", resourceid)
+			return nil
+		}
+
+		log.Printf("Replacing OPTIONS method of resource %s", resourceid)
+		_, e = c.DeleteMethod(ctx, &api.DeleteMethodInput{
+			RestApiId:  aws.String(apiid),
+			ResourceId: aws.String(resourceid),
+			HttpMethod: aws.String("OPTIONS"),
+		})
+
+		if e != nil {
+			return e
+		}
+	}
+
+	_, e = c.PutMethod(ctx, &api.PutMethodInput{
+		RestApiId:         aws.String(apiid),
+		ResourceId:        aws.String(resourceid),
+		HttpMethod:        aws.String("OPTIONS"),
+		AuthorizationType: aws.String(ie2datatypes.RESTAuthorizationNone),
+		ApiKeyRequired:    false,
+	})
+
+	if e != nil {
+		return e
+	}
+
+	headers := restCorsHeaders(cors, methods)
+	declared := map[string]bool{}
+
+	for name := range headers {
+		declared[fmt.Sprintf("method.response.header.%s", name)] = false
+	}
+
+	_, e = c.PutMethodResponse(ctx, &api.PutMethodResponseInput{
+		RestApiId:          aws.String(apiid),
+		ResourceId:         aws.String(resourceid),
+		HttpMethod:         aws.String("OPTIONS"),
+		StatusCode:         aws.String("200"),
+		ResponseParameters: declared,
+	})
+
+	if e != nil {
+		return e
+	}
+
+	_, e = c.PutIntegration(ctx, &api.PutIntegrationInput{
+		RestApiId:           aws.String(apiid),
+		ResourceId:          aws.String(resourceid),
+		HttpMethod:          aws.String("OPTIONS"),
+		Type:                types.IntegrationTypeMock,
+		PassthroughBehavior: aws.String("WHEN_NO_MATCH"),
+		RequestTemplates:    map[string]string{REST_MODEL_CONTENT_TYPE: `{"statusCode": 200}`},
+	})
+
+	if e != nil {
+		return e
+	}
+
+	req := &api.PutIntegrationResponseInput{
+		RestApiId:          aws.String(apiid),
+		ResourceId:         aws.String(resourceid),
+		HttpMethod:         aws.String("OPTIONS"),
+		StatusCode:         aws.String("200"),
+		ResponseParameters: restCorsParameters("method.response.header", headers),
+	}
+
+	if template := restCorsTemplate(cors); len(template) > 0 {
+		req.ResponseTemplates = map[string]string{REST_MODEL_CONTENT_TYPE: template}
+	}
+
+	_, e = c.PutIntegrationResponse(ctx, req)
+
+	return e
+}
+
+// restCorsGatewayParameters returns the response parameters of the 4XX and 5XX gateway responses.
+// Gateway responses can't choose between origins, so with more than one the Origin of the
+// request is echoed back and credentials are left out.
+func restCorsGatewayParameters(cors *ie2datatypes.RESTCors, methods []string) map[string]string {
+
+	params := restCorsParameters("gatewayresponse.header", restCorsHeaders(cors, methods))
+
+	if len(restCorsTemplate(cors)) > 0 {
+		params["gatewayresponse.header.Access-Control-Allow-Origin"] = "method.request.header.Origin"
+		delete(params, "gatewayresponse.header.Access-Control-Allow-Credentials")
+	}
+
+	return params
+}
+
+// putRESTCorsGatewayResponses adds the CORS headers to the default 4XX and 5XX responses of an api.
+func putRESTCorsGatewayResponses(c APIGatewayAPI, ctx context.Context, apiid string, cors *ie2datatypes.RESTCors, methods []string) error {
+
+	params := restCorsGatewayParameters(cors, methods)
+
+	for _, response := range REST_CORS_GATEWAY_RESPONSES {
+
+		log.Printf("Setting CORS headers on %s responses of api %s", response, apiid)
+		_, e := c.PutGatewayResponse(ctx, &api.PutGatewayResponseInput{
+			RestApiId:          aws.String(apiid),
+			ResponseType:       response,
+			ResponseParameters: params,
+		})
+
+		if e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// putRESTCors sets up CORS for the methods of one resource, see ie2datatypes.RESTCors.
+// cors must have been checked with validateRESTCors.
+func putRESTCors(c APIGatewayAPI, ctx context.Context, apiid string, resourceid string, cors *ie2datatypes.RESTCors, methods []ie2datatypes.RESTMethod) error {
+
+	names := []string{}
+
+	for _, m := range methods {
+		names = append(names, strings.ToUpper(m.Name))
+	}
+
+	e := putRESTCorsMethod(c, ctx, apiid, resourceid, cors, names)
+
+	if e != nil {
+		return e
+	}
+
+	return putRESTCorsGatewayResponses(c, ctx, apiid, cors, names)
+}
@@ -0,0 +1,189 @@
+package ie2utilities
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+const testCorsDefaultHeaders = "Content-Type,X-Amz-Date,Authorization,X-Api-Key,X-Amz-Security-Token"
+
+func TestRESTCorsHeaders(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		cors    ie2datatypes.RESTCors
+		methods []string
+		want    map[string]string
+	}{
+		{
+			name:    "defaults",
+			cors:    ie2datatypes.RESTCors{Origins: []string{"https://app.example.com"}},
+			methods: []string{"GET", "POST"},
+			want: map[string]string{
+				"Access-Control-Allow-Origin":  "https://app.example.com",
+				"Access-Control-Allow-Methods": "GET,POST,OPTIONS",
+				"Access-Control-Allow-Headers": testCorsDefaultHeaders,
+			},
+		},
+		{
+			name:    "any origin wins",
+			cors:    ie2datatypes.RESTCors{Origins: []string{"https://app.example.com", "*"}},
+			methods: []string{"GET"},
+			want: map[string]string{
+				"Access-Control-Allow-Origin":  "*",
+				"Access-Control-Allow-Methods": "GET,OPTIONS",
+				"Access-Control-Allow-Headers": testCorsDefaultHeaders,
+			},
+		},
+		{
+			name: "explicit methods, headers, max age and credentials",
+			cors: ie2datatypes.RESTCors{
+				Origins:     []string{"https://app.example.com"},
+				Methods:     []string{"get", "options"},
+				Headers:     []string{"Content-Type"},
+				MaxAge:      600,
+				Credentials: true,
+			},
+			methods: []string{"GET", "DELETE"},
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Methods":     "GET,OPTIONS",
+				"Access-Control-Allow-Headers":     "Content-Type",
+				"Access-Control-Max-Age":           "600",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if got := restCorsHeaders(&tt.cors, tt.methods); !maps.Equal(got, tt.want) {
+				t.Errorf("restCorsHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRESTCors(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		cors    ie2datatypes.RESTCors
+		methods []ie2datatypes.RESTMethod
+		wantErr bool
+	}{
+		{name: "valid", cors: ie2datatypes.RESTCors{Origins: []string{"*"}}, methods: []ie2datatypes.RESTMethod{{Name: "GET"}}},
+		{name: "no origins", cors: ie2datatypes.RESTCors{}, wantErr: true},
+		{name: "credentials from any origin", cors: ie2datatypes.RESTCors{Origins: []string{"*"}, Credentials: true}, wantErr: true},
+		{name: "options declared", cors: ie2datatypes.RESTCors{Origins: []string{"*"}}, methods: []ie2datatypes.RESTMethod{{Name: "options"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if e := validateRESTCors(&tt.cors, tt.methods); (e != nil) != tt.wantErr {
+				t.Errorf("validateRESTCors() error = %v, wantErr %v", e, tt.wantErr)
+			}
+		})
+	}
+}
+
+// corsMethod builds the OPTIONS method putRESTCorsMethod creates for cors.
+func corsMethod(cors *ie2datatypes.RESTCors, methods []string) *types.Method {
+
+	headers := restCorsHeaders(cors, methods)
+	declared := map[string]bool{}
+
+	for name := range headers {
+		declared["method.response.header."+name] = false
+	}
+
+	response := types.IntegrationResponse{
+		StatusCode:         aws.String("200"),
+		ResponseParameters: restCorsParameters("method.response.header", headers),
+	}
+
+	if template := restCorsTemplate(cors); len(template) > 0 {
+		response.ResponseTemplates = map[string]string{REST_MODEL_CONTENT_TYPE: template}
+	}
+
+	return &types.Method{
+		AuthorizationType: aws.String(ie2datatypes.RESTAuthorizationNone),
+		ApiKeyRequired:    aws.Bool(false),
+		MethodResponses:   map[string]types.MethodResponse{"200": {StatusCode: aws.String("200"), ResponseParameters: declared}},
+		MethodIntegration: &types.Integration{
+			Type:                 types.IntegrationTypeMock,
+			IntegrationResponses: map[string]types.IntegrationResponse{"200": response},
+		},
+	}
+}
+
+func TestPlanRESTCors(t *testing.T) {
+
+	deployed := &ie2datatypes.RESTCors{Origins: []string{"https://app.example.com"}, MaxAge: 600}
+	methods := []string{"GET"}
+
+	tests := []struct {
+		name    string
+		current *types.Method
+		cors    ie2datatypes.RESTCors
+		methods []string
+		want    []string
+	}{
+		{
+			name:    "missing",
+			cors:    *deployed,
+			methods: methods,
+			want:    []string{"add method "},
+		},
+		{
+			name:    "up to date",
+			current: corsMethod(deployed, methods),
+			cors:    *deployed,
+			methods: methods,
+			want:    []string{},
+		},
+		{
+			name:    "new method",
+			current: corsMethod(deployed, methods),
+			cors:    *deployed,
+			methods: []string{"GET", "POST"},
+			want:    []string{"change integration header:Access-Control-Allow-Methods"},
+		},
+		{
+			name:    "max age dropped",
+			current: corsMethod(deployed, methods),
+			cors:    ie2datatypes.RESTCors{Origins: deployed.Origins},
+			methods: methods,
+			want:    []string{"remove integration header:Access-Control-Max-Age"},
+		},
+		{
+			name:    "credentials and another origin",
+			current: corsMethod(deployed, methods),
+			cors:    ie2datatypes.RESTCors{Origins: []string{"https://app.example.com", "http://localhost"}, MaxAge: 600, Credentials: true},
+			methods: methods,
+			want:    []string{"add integration header:Access-Control-Allow-Credentials", "change integration origins"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got := []string{}
+
+			for _, change := range planRESTCors(tt.current, "OPTIONS /v1/papers", &tt.cors, tt.methods) {
+				got = append(got, change.Action+" "+change.Kind+" "+change.Field)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("planRESTCors() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -16,29 +17,17 @@ import (
 /***
 * Internal Functions
 ***/
-func createApiGatewayClient(conf *aws.Config, ctx *context.Context) (*api.Client, error) {
-
-	if conf == nil {
-		e := errors.New("aws.config param can not be null")
-		return nil, e
-	}
+func (s *AWSService) createApiGatewayClient(ctx context.Context) (APIGatewayAPI, error) {
 
 	if ctx == nil {
 		e := errors.New("context param can not be null")
 		return nil, e
 	}
 
-	c := api.NewFromConfig(*conf)
-
-	if c == nil {
-		e := errors.New("failed to create apigatewayv2 client using provided config")
-		return nil, e
-	}
-
-	return c, nil
+	return s.apiGatewayClient()
 }
 
-func lambdaIntegrationExists(client *api.Client, ctx *context.Context, apiid string, resourceid string, in *ie2datatypes.RESTMethod) (bool, error) {
+func lambdaIntegrationExists(client APIGatewayAPI, ctx context.Context, apiid string, resourceid string, in *ie2datatypes.RESTMethod) (bool, error) {
 
 	if client == nil {
 		return false, errors.New("client is null")
@@ -52,24 +41,28 @@ func lambdaIntegrationExists(client *api.Client, ctx *context.Context, apiid str
 		return false, errors.New("input RESTMethod object is empty")
 	}
 
-	out, _ := client.GetIntegration(*ctx, &api.GetIntegrationInput{
+	_, e := client.GetIntegration(ctx, &api.GetIntegrationInput{
 		HttpMethod: aws.String(in.Name),
 		ResourceId: aws.String(resourceid),
 		RestApiId:  aws.String(apiid),
 	})
 
-	// if the integration does NOT exist, we get an error...
-	// since I can't currently find a clean way to handle a 404 response when an integration for the given HttpMethod does NOT exist
-	// i'm ignoring the error here as if it were a 404 and swallowing the error...ugh ;/
+	// a method without an integration returns a NotFoundException
+	if e != nil {
+
+		e = ClassifyAWSError(e)
+
+		if errors.Is(e, ErrNotFound) {
+			return false, nil
+		}
 
-	if out != nil {
-		return true, nil
+		return false, e
 	}
 
-	return false, nil
+	return true, nil
 }
 
-func createLambdaIntegration(client *api.Client, ctx *context.Context, apiid string, resourceid string, uri string, in *ie2datatypes.RESTMethod) error {
+func createLambdaIntegration(client APIGatewayAPI, ctx context.Context, apiid string, resourceid string, uri string, in *ie2datatypes.RESTMethod) error {
 
 	if client == nil {
 		return errors.New("client is null")
@@ -83,7 +76,7 @@ func createLambdaIntegration(client *api.Client, ctx *context.Context, apiid str
 		return errors.New("input RESTMethod object is null")
 	}
 
-	_, e := client.PutIntegration(*ctx, &api.PutIntegrationInput{
+	_, e := client.PutIntegration(ctx, &api.PutIntegrationInput{
 		HttpMethod:            aws.String(in.Name),
 		IntegrationHttpMethod: aws.String("POST"),
 		ResourceId:            aws.String(resourceid),
@@ -97,7 +90,7 @@ func createLambdaIntegration(client *api.Client, ctx *context.Context, apiid str
 	return e
 }
 
-func deleteLambdaIntegration(client *api.Client, ctx *context.Context, apiid string, resourceid string, in *ie2datatypes.RESTMethod) error {
+func deleteLambdaIntegration(client APIGatewayAPI, ctx context.Context, apiid string, resourceid string, in *ie2datatypes.RESTMethod) error {
 
 	if client == nil {
 		return errors.New("client is null")
@@ -111,7 +104,7 @@ func deleteLambdaIntegration(client *api.Client, ctx *context.Context, apiid str
 		return errors.New("input RESTMethod object is null")
 	}
 
-	_, e := client.DeleteIntegration(*ctx, &api.DeleteIntegrationInput{
+	_, e := client.DeleteIntegration(ctx, &api.DeleteIntegrationInput{
 		HttpMethod: aws.String(in.Name),
 		ResourceId: aws.String(resourceid),
 		RestApiId:  aws.String(apiid),
@@ -120,7 +113,7 @@ func deleteLambdaIntegration(client *api.Client, ctx *context.Context, apiid str
 	return e
 }
 
-func createRESTMethod(client *api.Client, ctx *context.Context, apiid string, resourceid string, in *ie2datatypes.RESTMethod) error {
+func createRESTMethod(client APIGatewayAPI, ctx context.Context, apiid string, resourceid string, in *ie2datatypes.RESTMethod, auth *restMethodAuth) error {
 
 	if client == nil {
 		return errors.New("client is null")
@@ -134,18 +127,29 @@ func createRESTMethod(client *api.Client, ctx *context.Context, apiid string, re
 		return errors.New("input RESTMethod object is null")
 	}
 
-	_, e := client.PutMethod(*ctx, &api.PutMethodInput{
-		ApiKeyRequired:    true,
-		AuthorizationType: aws.String("NONE"),
-		HttpMethod:        aws.String(in.Name),
-		ResourceId:        aws.String(resourceid),
-		RestApiId:         aws.String(apiid),
-	})
+	if auth == nil {
+		return errors.New("method authorization is null")
+	}
+
+	req := &api.PutMethodInput{
+		ApiKeyRequired:      auth.ApiKeyRequired,
+		AuthorizationType:   aws.String(auth.Type),
+		AuthorizationScopes: auth.Scopes,
+		HttpMethod:          aws.String(in.Name),
+		ResourceId:          aws.String(resourceid),
+		RestApiId:           aws.String(apiid),
+	}
+
+	if len(auth.AuthorizerId) > 0 {
+		req.AuthorizerId = aws.String(auth.AuthorizerId)
+	}
+
+	_, e := client.PutMethod(ctx, req)
 
 	return e
 }
 
-func stageExists(client *api.Client, ctx *context.Context, apiid string, stage string) (bool, error) {
+func stageExists(client APIGatewayAPI, ctx context.Context, apiid string, stage string) (bool, error) {
 
 	if client == nil {
 		return false, errors.New("client is null")
@@ -159,23 +163,26 @@ func stageExists(client *api.Client, ctx *context.Context, apiid string, stage s
 		return false, errors.New("stage value is empty")
 	}
 
-	out, e := client.GetStage(*ctx, &api.GetStageInput{
+	_, e := client.GetStage(ctx, &api.GetStageInput{
 		RestApiId: aws.String(apiid),
 		StageName: aws.String(stage),
 	})
 
 	if e != nil {
-		return false, e
-	}
 
-	if out == nil {
-		return false, nil
+		e = ClassifyAWSError(e)
+
+		if errors.Is(e, ErrNotFound) {
+			return false, nil
+		}
+
+		return false, e
 	}
 
 	return true, nil
 }
 
-func createStage(client *api.Client, ctx *context.Context, apiid string, stage string, deploymentid string) error {
+func createStage(client APIGatewayAPI, ctx context.Context, apiid string, stage string, deploymentid string) error {
 
 	if client == nil {
 		return errors.New("client is null")
@@ -189,7 +196,7 @@ func createStage(client *api.Client, ctx *context.Context, apiid string, stage s
 		return errors.New("stage value is empty")
 	}
 
-	_, e := client.CreateStage(*ctx, &api.CreateStageInput{
+	_, e := client.CreateStage(ctx, &api.CreateStageInput{
 		DeploymentId: aws.String(deploymentid),
 		RestApiId:    aws.String(apiid),
 		StageName:    aws.String(stage),
@@ -198,17 +205,270 @@ func createStage(client *api.Client, ctx *context.Context, apiid string, stage s
 	return e
 }
 
+// lambdaIntegrationUri is the uri api gateway uses to invoke a lambda function,
+// or one of its aliases when qualifier is set.
+func lambdaIntegrationUri(region string, accountid string, lambdaname string, qualifier string) string {
+
+	if len(qualifier) > 0 {
+		lambdaname += ":" + qualifier
+	}
+
+	return fmt.Sprintf("arn:aws:apigateway:%s:lambda:path/2015-03-31/functions/arn:aws:lambda:%s:%s:function:%s/invocations", region, region, accountid, lambdaname)
+}
+
+// getRESTResourcesByPath returns every resource of the api keyed by its full path (e.g. /v1/papers).
+// Each resource embeds its methods and their integrations.
+func getRESTResourcesByPath(client APIGatewayAPI, ctx context.Context, apiid string) (map[string]types.Resource, error) {
+
+	return listRESTResources(client, ctx, apiid, true)
+}
+
+// listRESTResources reads every page of the resources of an api, keyed by full path.
+func listRESTResources(client APIGatewayAPI, ctx context.Context, apiid string, methods bool) (map[string]types.Resource, error) {
+
+	if client == nil {
+		return nil, errors.New("client is null")
+	}
+
+	if ctx == nil {
+		return nil, errors.New("context is null")
+	}
+
+	req := &api.GetResourcesInput{
+		RestApiId: aws.String(apiid),
+		Limit:     aws.Int32(500),
+	}
+
+	if methods {
+		req.Embed = []string{"methods"}
+	}
+
+	ret := map[string]types.Resource{}
+	pages := api.NewGetResourcesPaginator(client, req)
+
+	for pages.HasMorePages() {
+
+		out, e := pages.NextPage(ctx)
+
+		if e != nil {
+			return nil, e
+		}
+
+		for _, item := range out.Items {
+			if item.Path != nil {
+				ret[*item.Path] = item
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// restPathParameter is true for path parts like {id} and {proxy+}.
+func restPathParameter(part string) bool {
+	return strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}")
+}
+
+// validateRESTResourcePath checks a full resource path before anything is created.
+// Path parameters must be a whole segment, e.g. /papers/{id}, and a greedy
+// parameter like {proxy+} can only be the last one.
+func validateRESTResourcePath(path string) error {
+
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("resource path %s must start with /", path)
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, part := range parts {
+
+		if len(part) <= 0 {
+
+			if len(parts) == 1 {
+				break
+			}
+
+			return fmt.Errorf("resource path %s has an empty segment", path)
+		}
+
+		if !restPathParameter(part) {
+
+			if strings.ContainsAny(part, "{}") {
+				return fmt.Errorf("resource path %s segment %s must be a whole path parameter like {id}", path, part)
+			}
+
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.Trim(part, "{}"), "+")
+
+		if len(name) <= 0 || strings.ContainsAny(name, "{}+") {
+			return fmt.Errorf("resource path %s has an invalid path parameter %s", path, part)
+		}
+
+		if strings.HasSuffix(part, "+}") && i != len(parts)-1 {
+			return fmt.Errorf("resource path %s has the greedy path parameter %s before its last segment", path, part)
+		}
+	}
+
+	return nil
+}
+
+// createRESTResourcePath walks path one segment at a time, creating any segment that
+// doesn't exist under its parent. It returns the id of the last segment and whether anything was created.
+func (s *AWSService) createRESTResourcePath(client APIGatewayAPI, ctx context.Context, apiid string, path string) (string, bool, error) {
+
+	e := validateRESTResourcePath(path)
+
+	if e != nil {
+		return "", false, e
+	}
+
+	resources, e := s.restResources(client, ctx, apiid)
+
+	if e != nil {
+		return "", false, e
+	}
+
+	root, ok := resources["/"]
+
+	if !ok {
+		return "", false, fmt.Errorf("api %s has no root resource", apiid)
+	}
+
+	id := *root.Id
+	current := ""
+	created := false
+
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+
+		if len(part) <= 0 {
+			continue
+		}
+
+		current += "/" + part
+
+		if existing, ok := resources[current]; ok {
+			id = *existing.Id
+			continue
+		}
+
+		// api gateway allows one path parameter per parent, /papers/{id} and /papers/{paperId} can't both exist
+		if restPathParameter(part) {
+			for p, sibling := range resources {
+				if aws.ToString(sibling.ParentId) == id && restPathParameter(aws.ToString(sibling.PathPart)) {
+					return "", created, fmt.Errorf("resource %s conflicts with the existing resource %s", current, p)
+				}
+			}
+		}
+
+		log.Printf("Creating resource %s on API %s", current, apiid)
+		out, e := client.CreateResource(ctx, &api.CreateResourceInput{
+			ParentId:  aws.String(id),
+			PathPart:  aws.String(part),
+			RestApiId: aws.String(apiid),
+		})
+
+		if e != nil {
+
+			// the cache is out of date if the parent is gone or the segment was created elsewhere
+			s.ForgetRESTResources(apiid)
+
+			return "", created, e
+		}
+
+		id = *out.Id
+		created = true
+
+		s.cacheRESTResource(apiid, types.Resource{
+			Id:       out.Id,
+			ParentId: out.ParentId,
+			Path:     aws.String(current),
+			PathPart: out.PathPart,
+		})
+	}
+
+	return id, created, nil
+}
+
+// CreateRESTResourcePath creates every missing resource of a full path like /v1/papers/{id},
+// including path parameters and a trailing greedy {proxy+}, and returns the id of the last one.
+func (s *AWSService) CreateRESTResourcePath(ctx context.Context, apiid string, path string) (string, error) {
+
+	if ctx == nil {
+		return "", errors.New("context can not be null")
+	}
+
+	if len(apiid) <= 0 {
+		return "", errors.New("apiid value can not be empty")
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return "", e
+	}
+
+	id, _, e := s.createRESTResourcePath(c, ctx, apiid, path)
+
+	if e != nil {
+		log.Print(e)
+		return "", e
+	}
+
+	return id, nil
+}
+
+// GetRESTResourceIdFromPath returns the id of the resource with the full path given, e.g. /v1/papers,
+// or an empty string if there is none.
+func (s *AWSService) GetRESTResourceIdFromPath(ctx context.Context, apiid string, path string) (string, error) {
+
+	if ctx == nil {
+		return "", errors.New("context can not be null")
+	}
+
+	if len(apiid) <= 0 {
+		return "", errors.New("apiid value can not be empty")
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return "", e
+	}
+
+	resources, e := s.restResources(c, ctx, apiid)
+
+	if e != nil {
+		return "", e
+	}
+
+	// /v1/papers/ and /v1/papers are the same resource
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	resource, ok := resources[path]
+
+	if !ok {
+		log.Printf("Unable to find a resource with the path %s", path)
+		return "", nil
+	}
+
+	return aws.ToString(resource.Id), nil
+}
+
 /***
-* Exported Functions
+* AWSService methods
 ***/
-func AWSRESTMethodExists(conf *aws.Config, ctx *context.Context, apiid string, resourceid string, method *ie2datatypes.RESTMethod) (bool, error) {
+func (s *AWSService) RESTMethodExists(ctx context.Context, apiid string, resourceid string, method *ie2datatypes.RESTMethod) (bool, error) {
 
 	if method == nil {
 		e := errors.New("method param can not be null")
 		return false, e
 	}
 
-	c, e := createApiGatewayClient(conf, ctx)
+	c, e := s.createApiGatewayClient(ctx)
 
 	if e != nil {
 		log.Print(e)
@@ -216,40 +476,37 @@ func AWSRESTMethodExists(conf *aws.Config, ctx *context.Context, apiid string, r
 	}
 
 	log.Printf("Checking if Method %s exists on API %s for Resource %s", method.Name, apiid, resourceid)
-	_, e = c.GetMethod(*ctx, &api.GetMethodInput{
+	_, e = c.GetMethod(ctx, &api.GetMethodInput{
 		HttpMethod: aws.String(method.Name),
 		ResourceId: aws.String(resourceid),
 		RestApiId:  aws.String(apiid),
 	})
 
-	// this is freaking stupid that the GetMethod call returns an error on 404 (ie. method does NOT exist)
-	// technically accurate, but I have to parse the error string and look for statuscode 404 to
-	// see if it's a HttpResponse vs another type of error...wtf...this can't be accurate
+	// GetMethod returns a NotFoundException when the method does NOT exist
 	if e != nil {
-		src := strings.ToLower(e.Error())
-		status := strings.Contains(src, "statuscode")
-		notfound := strings.Contains(src, "404")
 
-		if status && notfound {
+		e = ClassifyAWSError(e)
+
+		if errors.Is(e, ErrNotFound) {
 			return false, nil
-		} else {
-			// some other error occurred...
-			log.Print(e)
-			return false, e
 		}
+
+		// some other error occurred...
+		log.Print(e)
+		return false, e
 	}
 
 	return true, nil
 }
 
-func AWSRESTApiExists(conf *aws.Config, ctx *context.Context, input *ie2datatypes.RESTEndpointInput) (bool, error) {
+func (s *AWSService) RESTApiExists(ctx context.Context, input *ie2datatypes.RESTEndpointInput) (bool, error) {
 
 	if input == nil {
 		e := errors.New("input param can not be null")
 		return false, e
 	}
 
-	c, err := createApiGatewayClient(conf, ctx)
+	c, err := s.createApiGatewayClient(ctx)
 
 	if err != nil {
 		log.Print(err)
@@ -258,11 +515,20 @@ func AWSRESTApiExists(conf *aws.Config, ctx *context.Context, input *ie2datatype
 
 	log.Printf("Checking if API exists using id %s", input.ApiId)
 
-	_, err = c.GetRestApi(*ctx, &api.GetRestApiInput{
+	_, err = c.GetRestApi(ctx, &api.GetRestApiInput{
 		RestApiId: aws.String(input.ApiId),
 	})
 
 	if err != nil {
+
+		err = ClassifyAWSError(err)
+
+		if errors.Is(err, ErrNotFound) {
+			log.Printf("API %s does NOT exist.", input.ApiId)
+			return false, nil
+		}
+
+		log.Print(err)
 		return false, err
 	}
 
@@ -271,7 +537,7 @@ func AWSRESTApiExists(conf *aws.Config, ctx *context.Context, input *ie2datatype
 	return true, nil
 }
 
-func AWSCreateRESTResource(conf *aws.Config, ctx *context.Context, input *ie2datatypes.RESTEndpointInput) (string, error) {
+func (s *AWSService) CreateRESTResource(ctx context.Context, input *ie2datatypes.RESTEndpointInput) (string, error) {
 
 	if input == nil {
 		return "", errors.New("lambdaconfig can not be null")
@@ -285,13 +551,18 @@ func AWSCreateRESTResource(conf *aws.Config, ctx *context.Context, input *ie2dat
 		return "", errors.New("input ResourceName can not be empty")
 	}
 
+	// a full path creates every resource along it, ParentResourceId and Route aren't needed
+	if strings.HasPrefix(input.ResourceName, "/") {
+		return s.CreateRESTResourcePath(ctx, input.ApiId, input.ResourceName)
+	}
+
 	if len(input.Route) <= 0 {
 		return "", errors.New("input Route can not be empty")
 	}
 
 	// does the resource already exist?
 	// both the api and the resource should be present
-	exists, e := AWSRESTApiExists(conf, ctx, input)
+	exists, e := s.RESTApiExists(ctx, input)
 
 	if e != nil {
 		log.Print(e)
@@ -302,13 +573,13 @@ func AWSCreateRESTResource(conf *aws.Config, ctx *context.Context, input *ie2dat
 		return "", errors.New("api does not exist")
 	}
 
-	c, e := createApiGatewayClient(conf, ctx)
+	c, e := s.createApiGatewayClient(ctx)
 
 	if e != nil {
 		return "", e
 	}
 
-	id, e := AWSGetRESTResourceIdFromName(conf, ctx, input.ApiId, input.ResourceName)
+	id, e := s.GetRESTResourceIdFromName(ctx, input.ApiId, input.ResourceName)
 
 	if e != nil {
 		return "", e
@@ -320,7 +591,7 @@ func AWSCreateRESTResource(conf *aws.Config, ctx *context.Context, input *ie2dat
 	}
 
 	// we need to create the REST resource
-	out, e := c.CreateResource(*ctx, &api.CreateResourceInput{
+	out, e := c.CreateResource(ctx, &api.CreateResourceInput{
 		ParentId:  aws.String(input.ParentResourceId),
 		PathPart:  aws.String(input.Route),
 		RestApiId: aws.String(input.ApiId),
@@ -330,10 +601,17 @@ func AWSCreateRESTResource(conf *aws.Config, ctx *context.Context, input *ie2dat
 		return "", e
 	}
 
+	s.cacheRESTResource(input.ApiId, types.Resource{
+		Id:       out.Id,
+		ParentId: out.ParentId,
+		Path:     out.Path,
+		PathPart: out.PathPart,
+	})
+
 	return *out.Id, nil
 }
 
-func AWSGetRESTApiIdFromName(conf *aws.Config, ctx *context.Context, name string) (string, error) {
+func (s *AWSService) GetRESTApiIdFromName(ctx context.Context, name string) (string, error) {
 
 	id := ""
 
@@ -342,18 +620,12 @@ func AWSGetRESTApiIdFromName(conf *aws.Config, ctx *context.Context, name string
 		return id, errors.New("resource name can not be empty")
 	}
 
-	if conf == nil {
-		return id, errors.New("config can not be null")
-	}
-
 	if ctx == nil {
 		return id, errors.New("context can not be null")
 	}
 
 	name = strings.ToLower(name)
-	c := api.NewFromConfig(*conf)
-
-	out, e := c.GetRestApis(*ctx, &api.GetRestApisInput{})
+	c, e := s.createApiGatewayClient(ctx)
 
 	if e != nil {
 		return id, e
@@ -361,22 +633,36 @@ func AWSGetRESTApiIdFromName(conf *aws.Config, ctx *context.Context, name string
 
 	log.Printf("Looking for a REST api named %s", name)
 
-	for _, item := range out.Items {
-		if *item.Name == name {
-			log.Printf("Success! Found REST api named %s", name)
-			id = *item.Id
-			break
+	count := 0
+	pages := api.NewGetRestApisPaginator(c, &api.GetRestApisInput{Limit: aws.Int32(500)})
+
+	for pages.HasMorePages() {
+
+		out, e := pages.NextPage(ctx)
+
+		if e != nil {
+			return id, e
 		}
-	}
 
-	if len(id) <= 0 {
-		log.Printf("Failed to find a REST api named %s after searching through %d REST APIs", name, len(out.Items))
+		for _, item := range out.Items {
+			if aws.ToString(item.Name) == name {
+				log.Printf("Success! Found REST api named %s", name)
+				return *item.Id, nil
+			}
+		}
+
+		count += len(out.Items)
 	}
 
+	log.Printf("Failed to find a REST api named %s after searching through %d REST APIs", name, count)
+
 	return id, nil
 }
 
-func AWSGetRESTResourceIdFromName(conf *aws.Config, ctx *context.Context, apiid string, name string) (string, error) {
+// GetRESTResourceIdFromName returns the id of the first resource with the path part name,
+// or of the resource at name when it is a full path like /v1/papers.
+// It returns an empty string if there is none.
+func (s *AWSService) GetRESTResourceIdFromName(ctx context.Context, apiid string, name string) (string, error) {
 
 	id := ""
 
@@ -390,18 +676,23 @@ func AWSGetRESTResourceIdFromName(conf *aws.Config, ctx *context.Context, apiid
 		return id, errors.New("resource name can not be empty")
 	}
 
-	if conf == nil {
-		return id, errors.New("config can not be null")
-	}
-
 	if ctx == nil {
 		return id, errors.New("context can not be null")
 	}
 
+	// path parts repeat across versions (/v1/papers, /v2/papers), a full path is unambiguous
+	if strings.HasPrefix(name, "/") {
+		return s.GetRESTResourceIdFromPath(ctx, apiid, name)
+	}
+
 	name = strings.ToLower(name)
-	c := api.NewFromConfig(*conf)
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return id, e
+	}
 
-	out, e := c.GetResources(*ctx, &api.GetResourcesInput{RestApiId: aws.String(apiid)})
+	resources, e := s.restResources(c, ctx, apiid)
 
 	if e != nil {
 		return id, e
@@ -409,7 +700,25 @@ func AWSGetRESTResourceIdFromName(conf *aws.Config, ctx *context.Context, apiid
 
 	log.Printf("Looking for a resource with a path part named %s", name)
 
-	for _, item := range out.Items {
+	// shortest path first so the same name always finds the same resource
+	paths := []string{}
+
+	for p := range resources {
+		paths = append(paths, p)
+	}
+
+	sort.Slice(paths, func(i int, j int) bool {
+
+		if len(paths[i]) != len(paths[j]) {
+			return len(paths[i]) < len(paths[j])
+		}
+
+		return paths[i] < paths[j]
+	})
+
+	for _, p := range paths {
+
+		item := resources[p]
 
 		if item.PathPart != nil {
 
@@ -424,24 +733,18 @@ func AWSGetRESTResourceIdFromName(conf *aws.Config, ctx *context.Context, apiid
 	}
 
 	if len(id) <= 0 {
-		log.Printf("Unable to find a resource with a path part named %s after searching %d resources", name, len(out.Items))
+		log.Printf("Unable to find a resource with a path part named %s after searching %d resources", name, len(resources))
 	}
 
 	return id, nil
 }
 
-func AWSCreateLambdaIntegrations(conf *aws.Config, ctx *context.Context, input *ie2datatypes.RESTEndpointInput) error {
-
-	if conf == nil {
-		s := "config can not be null"
-		log.Print(s)
-		return errors.New(s)
-	}
+func (s *AWSService) createLambdaIntegrations(ctx context.Context, input *ie2datatypes.RESTEndpointInput) error {
 
 	if ctx == nil {
-		s := "context can not be null"
-		log.Print(s)
-		return errors.New(s)
+		msg := "context can not be null"
+		log.Print(msg)
+		return errors.New(msg)
 	}
 
 	if input == nil {
@@ -456,10 +759,20 @@ func AWSCreateLambdaIntegrations(conf *aws.Config, ctx *context.Context, input *
 
 	lambdaname := input.Integration.LambdaName
 
+	if input.Cors != nil {
+
+		e := validateRESTCors(input.Cors, input.Methods)
+
+		if e != nil {
+			log.Print(e)
+			return e
+		}
+	}
+
 	log.Printf("Attempting to create an integration for Lambda Function: %s", lambdaname)
 	log.Printf("Making sure lambda '%s' exists.", lambdaname)
 
-	exists, e := AWSLambdaExists(conf, ctx, lambdaname)
+	exists, e := s.LambdaExists(ctx, lambdaname)
 
 	if e != nil {
 		log.Print("Failure calling AWSLambdaExists.")
@@ -473,10 +786,34 @@ func AWSCreateLambdaIntegrations(conf *aws.Config, ctx *context.Context, input *
 	}
 
 	// create a client object
-	c := api.NewFromConfig(*conf)
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return e
+	}
 
 	// create the uri to the lambda function provided
-	uri := fmt.Sprintf("arn:aws:apigateway:%s:lambda:path/2015-03-31/functions/arn:aws:lambda:%s:%s:function:%s/invocations", input.Region, input.Region, input.AccountId, lambdaname)
+	qualifier := input.Integration.Qualifier
+	uri := lambdaIntegrationUri(input.Region, input.AccountId, lambdaname, qualifier)
+
+	// request bodies are only validated for methods with a model to validate against
+	validatorid := ""
+
+	for _, method := range input.Methods {
+
+		if len(method.ReqModel) <= 0 {
+			continue
+		}
+
+		validatorid, e = putRESTRequestValidator(c, ctx, input.ApiId)
+
+		if e != nil {
+			log.Print(e)
+			return e
+		}
+
+		break
+	}
 
 	// iterate through each method
 	// check if an integration exists
@@ -484,23 +821,30 @@ func AWSCreateLambdaIntegrations(conf *aws.Config, ctx *context.Context, input *
 	// create if no
 	for _, method := range input.Methods {
 
+		auth, e := resolveRESTAuthorization(c, ctx, input.ApiId, method.Authorization)
+
+		if e != nil {
+			log.Print(e)
+			return e
+		}
+
 		log.Printf("Checking if REST Method %s exists", method.Name)
 		// does the method exist?
-		exists, e := AWSRESTMethodExists(conf, ctx, input.ApiId, input.ResourceId, &method)
+		exists, e := s.RESTMethodExists(ctx, input.ApiId, input.ResourceId, &method)
 
 		if e != nil {
 			log.Print(e)
-			break
+			return e
 		}
 
 		if !exists {
 
 			// create the method
-			e := createRESTMethod(c, ctx, input.ApiId, input.ResourceId, &method)
+			e := createRESTMethod(c, ctx, input.ApiId, input.ResourceId, &method, auth)
 
 			if e != nil {
 				log.Print(e)
-				break
+				return e
 			}
 
 			log.Printf("Successfully created REST method %s", method.Name)
@@ -508,6 +852,19 @@ func AWSCreateLambdaIntegrations(conf *aws.Config, ctx *context.Context, input *
 		} else {
 
 			log.Printf("REST Method %s exists!", method.Name)
+			e := updateRESTMethodAuthorization(c, ctx, input.ApiId, input.ResourceId, method.Name, auth)
+
+			if e != nil {
+				log.Print(e)
+				return e
+			}
+		}
+
+		e = updateRESTMethodModels(c, ctx, input.ApiId, input.ResourceId, &method, validatorid)
+
+		if e != nil {
+			log.Print(e)
+			return e
 		}
 
 		log.Printf("Checking integration for ApiID %s ResourceId %s Method %s", input.ApiId, input.ResourceId, method.Name)
@@ -515,7 +872,7 @@ func AWSCreateLambdaIntegrations(conf *aws.Config, ctx *context.Context, input *
 
 		if e != nil {
 			log.Print(e)
-			break
+			return e
 		}
 
 		if exists {
@@ -540,57 +897,97 @@ func AWSCreateLambdaIntegrations(conf *aws.Config, ctx *context.Context, input *
 
 		if e != nil {
 			log.Print(e)
-			break
+			return e
 		}
 
 		log.Printf("Successfully created an integration for method %s", method.Name)
+	}
+
+	if input.Cors != nil {
+
+		log.Printf("Setting up CORS on resource %s", input.ResourceId)
+		e = putRESTCors(c, ctx, input.ApiId, input.ResourceId, input.Cors, input.Methods)
+
+		if e != nil {
+			log.Print(e)
+			return e
+		}
+	}
 
-		// make sure permissions exist on the lambda function
-		// to allow invocation from the apigateway
-		sourcearn := fmt.Sprintf("arn:aws:execute-api:%s:%s:%s/*/%s/%s", input.Region, input.AccountId, input.ApiId, method.Name, input.ResourceName)
+	// make sure permissions exist on the lambda function
+	// to allow invocation from the apigateway, for these methods of this resource only
+	_, e = s.ReconcileApiGatewayPermissions(ctx, endpointPermissions(input))
 
-		AWSAddApiGatewayPermission(conf, ctx, method.Name, sourcearn, lambdaname)
+	if e != nil {
+		log.Print(e)
+		return e
 	}
 
-	log.Printf("Deploying API %s into environment %s", input.ApiId, input.Stage)
+	return nil
+}
+
+// endpointPermissions lists the methods of one endpoint that may invoke its lambda.
+func endpointPermissions(input *ie2datatypes.RESTEndpointInput) *ie2datatypes.LambdaPermissionInput {
+
+	ret := &ie2datatypes.LambdaPermissionInput{
+		LambdaName: input.Integration.LambdaName,
+		Qualifier:  input.Integration.Qualifier,
+		Region:     input.Region,
+		AccountId:  input.AccountId,
+		ApiId:      input.ApiId,
+		Path:       "/" + strings.TrimPrefix(input.ResourceName, "/"),
+	}
+
+	for _, method := range input.Methods {
+		ret.Methods = append(ret.Methods, ie2datatypes.LambdaPermissionMethod{Method: method.Name, Path: ret.Path})
+	}
+
+	return ret
+}
+
+// deployRESTStage creates a new deployment of the api and points stage at it,
+// creating the stage if needed. It returns the new deployment id.
+func deployRESTStage(c APIGatewayAPI, ctx context.Context, apiid string, stage string) (string, error) {
+
+	log.Printf("Deploying API %s into environment %s", apiid, stage)
 	log.Printf("Creating a new Deployment")
-	newDeployment, e := c.CreateDeployment(*ctx, &api.CreateDeploymentInput{
-		RestApiId: &input.ApiId,
+	newDeployment, e := c.CreateDeployment(ctx, &api.CreateDeploymentInput{
+		RestApiId: aws.String(apiid),
 	})
 
 	if e != nil {
 		log.Print(e)
-		return e
+		return "", e
 	}
 
 	log.Print("Successfully created a new Deployment.")
-	log.Printf("Checking if stage %s exists", input.Stage)
-	exists, e = stageExists(c, ctx, input.ApiId, input.Stage)
+	log.Printf("Checking if stage %s exists", stage)
+	exists, e := stageExists(c, ctx, apiid, stage)
 
 	if e != nil {
 		log.Print(e)
-		return e
+		return "", e
 	}
 
 	if !exists {
 
-		log.Printf("Stage %s does NOT exist", input.Stage)
-		log.Printf("Creating stage %s", input.Stage)
-		e = createStage(c, ctx, input.ApiId, input.Stage, *newDeployment.Id)
+		log.Printf("Stage %s does NOT exist", stage)
+		log.Printf("Creating stage %s", stage)
+		e = createStage(c, ctx, apiid, stage, *newDeployment.Id)
 
 		if e != nil {
 			log.Print(e)
-			return e
+			return "", e
 		}
 
-		log.Printf("Successfully created stage %s", input.Stage)
+		log.Printf("Successfully created stage %s", stage)
 
 	} else {
 
-		log.Printf("Stage %s exists!", input.Stage)
+		log.Printf("Stage %s exists!", stage)
 	}
 
-	log.Printf("Updating API %s Resource %s Stage %s and DeploymentID %s", input.ApiId, input.ResourceId, input.Stage, *newDeployment.Id)
+	log.Printf("Updating API %s Stage %s and DeploymentID %s", apiid, stage, *newDeployment.Id)
 
 	op := types.PatchOperation{
 		Op:    types.OpReplace,
@@ -598,18 +995,141 @@ func AWSCreateLambdaIntegrations(conf *aws.Config, ctx *context.Context, input *
 		Value: aws.String(*newDeployment.Id),
 	}
 
-	_, e = c.UpdateStage(*ctx, &api.UpdateStageInput{
-		RestApiId:       aws.String(input.ApiId),
-		StageName:       aws.String(input.Stage),
+	_, e = c.UpdateStage(ctx, &api.UpdateStageInput{
+		RestApiId:       aws.String(apiid),
+		StageName:       aws.String(stage),
 		PatchOperations: []types.PatchOperation{op},
 	})
 
 	if e != nil {
 		log.Print(e)
-		return e
+		return "", e
 	}
 
 	log.Printf("Successfully updated API.")
 
-	return nil
+	return *newDeployment.Id, nil
+}
+
+func (s *AWSService) CreateLambdaIntegrations(ctx context.Context, input *ie2datatypes.RESTEndpointInput) error {
+
+	e := s.createLambdaIntegrations(ctx, input)
+
+	if e != nil {
+		return e
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return e
+	}
+
+	_, e = deployRESTStage(c, ctx, input.ApiId, input.Stage)
+
+	return e
+}
+
+/***
+* Package level helpers that build their clients from an aws.Config
+***/
+
+// AWSRESTMethodExists is AWSService.RESTMethodExists using clients built from conf.
+//
+// Deprecated: use AWSService.RESTMethodExists, which takes a context.Context.
+func AWSRESTMethodExists(conf *aws.Config, ctx *context.Context, apiid string, resourceid string, method *ie2datatypes.RESTMethod) (bool, error) {
+
+	if conf == nil {
+		return false, errors.New("aws.config param can not be null")
+	}
+
+	if ctx == nil {
+		return false, errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).RESTMethodExists(*ctx, apiid, resourceid, method)
+}
+
+// AWSRESTApiExists is AWSService.RESTApiExists using clients built from conf.
+//
+// Deprecated: use AWSService.RESTApiExists, which takes a context.Context.
+func AWSRESTApiExists(conf *aws.Config, ctx *context.Context, input *ie2datatypes.RESTEndpointInput) (bool, error) {
+
+	if conf == nil {
+		return false, errors.New("aws.config param can not be null")
+	}
+
+	if ctx == nil {
+		return false, errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).RESTApiExists(*ctx, input)
+}
+
+// AWSCreateRESTResource is AWSService.CreateRESTResource using clients built from conf.
+//
+// Deprecated: use AWSService.CreateRESTResource, which takes a context.Context.
+func AWSCreateRESTResource(conf *aws.Config, ctx *context.Context, input *ie2datatypes.RESTEndpointInput) (string, error) {
+
+	if conf == nil {
+		return "", errors.New("aws.config param can not be null")
+	}
+
+	if ctx == nil {
+		return "", errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).CreateRESTResource(*ctx, input)
+}
+
+// AWSGetRESTApiIdFromName is AWSService.GetRESTApiIdFromName using clients built from conf.
+//
+// Deprecated: use AWSService.GetRESTApiIdFromName, which takes a context.Context.
+func AWSGetRESTApiIdFromName(conf *aws.Config, ctx *context.Context, name string) (string, error) {
+
+	if conf == nil {
+		return "", errors.New("config can not be null")
+	}
+
+	if ctx == nil {
+		return "", errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).GetRESTApiIdFromName(*ctx, name)
+}
+
+// AWSGetRESTResourceIdFromName is AWSService.GetRESTResourceIdFromName using clients built from conf.
+//
+// Deprecated: use AWSService.GetRESTResourceIdFromName, which takes a context.Context.
+func AWSGetRESTResourceIdFromName(conf *aws.Config, ctx *context.Context, apiid string, name string) (string, error) {
+
+	if conf == nil {
+		return "", errors.New("config can not be null")
+	}
+
+	if ctx == nil {
+		return "", errors.New("context can not be empty")
+	}
+
+	return NewAWSService(*conf).GetRESTResourceIdFromName(*ctx, apiid, name)
+}
+
+// AWSCreateLambdaIntegrations is AWSService.CreateLambdaIntegrations using clients built from conf.
+//
+// Deprecated: use AWSService.CreateLambdaIntegrations, which takes a context.Context.
+func AWSCreateLambdaIntegrations(conf *aws.Config, ctx *context.Context, input *ie2datatypes.RESTEndpointInput) error {
+
+	if conf == nil {
+		msg := "config can not be null"
+		log.Print(msg)
+		return errors.New(msg)
+	}
+
+	if ctx == nil {
+		msg := "config can not be null"
+		log.Print(msg)
+		return errors.New(msg)
+	}
+
+	return NewAWSService(*conf).CreateLambdaIntegrations(*ctx, input)
 }
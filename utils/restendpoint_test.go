@@ -0,0 +1,178 @@
+package ie2utilities_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	api "github.com/aws/aws-sdk-go-v2/service/apigateway"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	ie2testing "github.com/insightengine2/ie2-utilities/testing"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+	ie2utilities "github.com/insightengine2/ie2-utilities/utils"
+)
+
+func TestCreateRESTResourcePath(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		existing []string
+		path     string
+		wantErr  bool
+	}{
+		{name: "root", path: "/"},
+		{name: "nested", path: "/v1/papers/{id}/authors"},
+		{name: "greedy parameter last", path: "/v1/{proxy+}"},
+		{name: "under an existing path", existing: []string{"/v1/papers/{id}"}, path: "/v1/papers/{id}/authors"},
+		{name: "trailing slash", path: "/v1/papers/"},
+		{name: "relative", path: "v1/papers", wantErr: true},
+		{name: "empty segment", path: "/v1//papers", wantErr: true},
+		{name: "partial parameter", path: "/v1/papers-{id}", wantErr: true},
+		{name: "empty parameter", path: "/v1/{}", wantErr: true},
+		{name: "invalid parameter", path: "/v1/{a+b}", wantErr: true},
+		{name: "greedy parameter not last", path: "/v1/{proxy+}/papers", wantErr: true},
+		{name: "conflicting sibling parameter", existing: []string{"/v1/papers/{id}"}, path: "/v1/papers/{paperId}/authors", wantErr: true},
+		{name: "conflicting sibling greedy parameter", existing: []string{"/v1/{proxy+}"}, path: "/v1/{id}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx := context.Background()
+			fa := ie2testing.NewFakeAPIGateway()
+			apiid := fa.AddRestApi("papers")
+			s := &ie2utilities.AWSService{APIGateway: fa}
+
+			for _, path := range tt.existing {
+				if _, e := s.CreateRESTResourcePath(ctx, apiid, path); e != nil {
+					t.Fatal(e)
+				}
+			}
+
+			before := len(fa.Apis[apiid].Resources)
+			id, e := s.CreateRESTResourcePath(ctx, apiid, tt.path)
+
+			if (e != nil) != tt.wantErr {
+				t.Fatalf("CreateRESTResourcePath() error = %v, wantErr %v", e, tt.wantErr)
+			}
+
+			if tt.wantErr {
+
+				if len(fa.Apis[apiid].Resources) != before {
+					t.Errorf("CreateRESTResourcePath() created %d resources before failing", len(fa.Apis[apiid].Resources)-before)
+				}
+
+				return
+			}
+
+			found, e := s.GetRESTResourceIdFromPath(ctx, apiid, tt.path)
+
+			if e != nil || found != id {
+				t.Errorf("GetRESTResourceIdFromPath() = %s, %v, want %s", found, e, id)
+			}
+
+			// creating it again finds what is there
+			again, e := s.CreateRESTResourcePath(ctx, apiid, tt.path)
+
+			if e != nil || again != id {
+				t.Errorf("second CreateRESTResourcePath() = %s, %v, want %s", again, e, id)
+			}
+		})
+	}
+}
+
+func TestCreateLambdaIntegrationsRequestModel(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		reqModel      map[string]string
+		wantValidator bool
+	}{
+		{name: "model kept", reqModel: map[string]string{"application/json": "Paper"}, wantValidator: true},
+		{name: "model dropped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx := context.Background()
+			fl := ie2testing.NewFakeLambda()
+			fl.Functions["papers"] = &lambdatypes.FunctionConfiguration{FunctionName: aws.String("papers")}
+			fa := ie2testing.NewFakeAPIGateway()
+			apiid := fa.AddRestApi("papers")
+			s := &ie2utilities.AWSService{Region: "us-east-1", Lambda: fl, APIGateway: fa}
+
+			_, e := s.PutRESTModel(ctx, apiid, &ie2datatypes.RESTModel{Name: "Paper", ContentType: "application/json", Schema: `{"type":"object"}`})
+
+			if e != nil {
+				t.Fatal(e)
+			}
+
+			resourceid, e := s.CreateRESTResourcePath(ctx, apiid, "/v1/papers")
+
+			if e != nil {
+				t.Fatal(e)
+			}
+
+			input := &ie2datatypes.RESTEndpointInput{
+				AccountId:   "123456789012",
+				Region:      "us-east-1",
+				ApiId:       apiid,
+				ResourceId:  resourceid,
+				Stage:       "dev",
+				Integration: &ie2datatypes.LambdaIntegration{LambdaName: "papers"},
+				Methods:     []ie2datatypes.RESTMethod{{Name: "POST", ReqModel: map[string]string{"application/json": "Paper"}}},
+			}
+
+			e = s.CreateLambdaIntegrations(ctx, input)
+
+			if e != nil {
+				t.Fatal(e)
+			}
+
+			input.Methods[0].ReqModel = tt.reqModel
+			e = s.CreateLambdaIntegrations(ctx, input)
+
+			if e != nil {
+				t.Fatalf("CreateLambdaIntegrations() error = %v", e)
+			}
+
+			m, e := fa.GetMethod(ctx, &api.GetMethodInput{RestApiId: aws.String(apiid), ResourceId: aws.String(resourceid), HttpMethod: aws.String("POST")})
+
+			if e != nil {
+				t.Fatal(e)
+			}
+
+			if (m.RequestValidatorId != nil) != tt.wantValidator {
+				t.Errorf("request validator = %v, want one %v", aws.ToString(m.RequestValidatorId), tt.wantValidator)
+			}
+		})
+	}
+}
+
+func TestCreateRESTResource(t *testing.T) {
+
+	ctx := context.Background()
+	fa := ie2testing.NewFakeAPIGateway()
+	apiid := fa.AddRestApi("papers")
+	s := &ie2utilities.AWSService{APIGateway: fa}
+
+	// creating the parent by path lists the resources of the api
+	parentid, e := s.CreateRESTResourcePath(ctx, apiid, "/v1")
+
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	id, e := s.CreateRESTResource(ctx, &ie2datatypes.RESTEndpointInput{ApiId: apiid, ParentResourceId: parentid, ResourceName: "papers", Route: "papers"})
+
+	if e != nil {
+		t.Fatalf("CreateRESTResource() error = %v", e)
+	}
+
+	found, e := s.GetRESTResourceIdFromPath(ctx, apiid, "/v1/papers")
+
+	if e != nil || found != id {
+		t.Errorf("GetRESTResourceIdFromPath() = %s, %v, want %s", found, e, id)
+	}
+}
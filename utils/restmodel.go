@@ -0,0 +1,486 @@
+package ie2utilities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	api "github.com/aws/aws-sdk-go-v2/service/apigateway"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+/***
+* Models of REST apis and the request validation and responses of their methods
+***/
+
+const REST_MODEL_CONTENT_TYPE = "application/json"
+
+// the request validator methods with a ReqModel use, it only checks request bodies
+const REST_REQUEST_VALIDATOR = "ie2-validate-body"
+
+// restPatchPathEscape escapes a map key for use in a patch path, / becomes ~1.
+func restPatchPathEscape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// restModelName turns a schema file into a model name, which can only hold letters and digits,
+// e.g. schemas/paper-request.json is PaperRequest.
+func restModelName(path string) string {
+
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+
+	words := strings.FieldsFunc(base, func(r rune) bool {
+		return r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+
+	ret := ""
+
+	for _, word := range words {
+		ret += strings.ToUpper(word[:1]) + word[1:]
+	}
+
+	return ret
+}
+
+// normalizeRESTSchema re-encodes a schema as compact json with sorted keys,
+// so schemas written in yaml or formatted differently compare equal.
+func normalizeRESTSchema(data []byte, format DocumentFormat) (string, error) {
+
+	schema, e := DecodeDocument[map[string]any](data, format)
+
+	if e != nil {
+		return "", e
+	}
+
+	out, e := json.Marshal(schema)
+
+	if e != nil {
+		return "", e
+	}
+
+	return string(out), nil
+}
+
+// loadRESTSchema reads a JSON Schema file, in json or yaml, from dir or from an s3://bucket/key url.
+func (s *AWSService) loadRESTSchema(ctx context.Context, dir string, path string) (string, error) {
+
+	if strings.HasPrefix(path, "s3://") {
+
+		bucket, key, _ := strings.Cut(strings.TrimPrefix(path, "s3://"), "/")
+
+		c, e := s.s3Client()
+
+		if e != nil {
+			return "", e
+		}
+
+		schema, e := LoadS3DocumentContext[map[string]any](ctx, c, bucket, key)
+
+		if e != nil {
+			return "", e
+		}
+
+		out, e := json.Marshal(schema)
+
+		if e != nil {
+			return "", e
+		}
+
+		return string(out), nil
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	data, e := os.ReadFile(path)
+
+	if e != nil {
+		return "", e
+	}
+
+	schema, e := normalizeRESTSchema(data, DetectDocumentFormat("", path))
+
+	if e != nil {
+		return "", fmt.Errorf("can not read schema %s: %w", path, e)
+	}
+
+	return schema, nil
+}
+
+// deployModels loads the Req and Res schemas of every endpoint method in config.
+// Methods can share a schema, but two schemas can't share a model name.
+func (s *AWSService) deployModels(ctx context.Context, config *ie2datatypes.LambdaConfig, opts *ie2datatypes.DeployOptions) ([]ie2datatypes.RESTModel, error) {
+
+	ret := []ie2datatypes.RESTModel{}
+	paths := map[string]string{}
+
+	for _, endpoint := range config.Endpoint {
+		for _, m := range endpoint.Methods {
+			for _, path := range []string{m.Req, m.Res} {
+
+				if len(path) <= 0 {
+					continue
+				}
+
+				name := restModelName(path)
+
+				if len(name) <= 0 {
+					return nil, fmt.Errorf("can not name a model after schema %s", path)
+				}
+
+				if existing, ok := paths[name]; ok {
+
+					if existing != path {
+						return nil, fmt.Errorf("schemas %s and %s are both model %s", existing, path, name)
+					}
+
+					continue
+				}
+
+				schema, e := s.loadRESTSchema(ctx, opts.SchemaDir, path)
+
+				if e != nil {
+					return nil, e
+				}
+
+				paths[name] = path
+				ret = append(ret, ie2datatypes.RESTModel{
+					Name:        name,
+					ContentType: REST_MODEL_CONTENT_TYPE,
+					Schema:      schema,
+				})
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// getRESTModel returns the model with the name given, or nil if there is none.
+func getRESTModel(c APIGatewayAPI, ctx context.Context, apiid string, name string) (*api.GetModelOutput, error) {
+
+	out, e := c.GetModel(ctx, &api.GetModelInput{
+		RestApiId: aws.String(apiid),
+		ModelName: aws.String(name),
+	})
+
+	if e != nil {
+
+		if IsAWSNotFound(e) {
+			return nil, nil
+		}
+
+		return nil, e
+	}
+
+	return out, nil
+}
+
+// restModelPatch returns the operations that change a model to match model.
+func restModelPatch(current *api.GetModelOutput, model *ie2datatypes.RESTModel) []types.PatchOperation {
+
+	ops := []types.PatchOperation{}
+
+	// schemas read back from api gateway keep whatever formatting they were sent with
+	schema, e := normalizeRESTSchema([]byte(aws.ToString(current.Schema)), DocumentFormatJSON)
+
+	if e != nil || schema != model.Schema {
+		ops = append(ops, types.PatchOperation{Op: types.OpReplace, Path: aws.String("/schema"), Value: aws.String(model.Schema)})
+	}
+
+	if aws.ToString(current.Description) != model.Description {
+		ops = append(ops, types.PatchOperation{Op: types.OpReplace, Path: aws.String("/description"), Value: aws.String(model.Description)})
+	}
+
+	return ops
+}
+
+// planRESTModel reports whether a model will be created or its schema changed.
+func planRESTModel(c APIGatewayAPI, ctx context.Context, apiid string, model *ie2datatypes.RESTModel) ([]ie2datatypes.PlanChange, error) {
+
+	target := fmt.Sprintf("%s/%s", apiid, model.Name)
+	current, e := getRESTModel(c, ctx, apiid, model.Name)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if current == nil {
+		return []ie2datatypes.PlanChange{{Action: ie2datatypes.PlanActionAdd, Kind: ie2datatypes.PlanKindModel, Target: target}}, nil
+	}
+
+	ret := []ie2datatypes.PlanChange{}
+
+	for _, op := range restModelPatch(current, model) {
+
+		field := strings.TrimPrefix(aws.ToString(op.Path), "/")
+
+		// schemas are too long to show, just say they differ
+		if field == "schema" {
+			ret = append(ret, ie2datatypes.PlanChange{Action: ie2datatypes.PlanActionChange, Kind: ie2datatypes.PlanKindModel, Target: target, Field: field})
+			continue
+		}
+
+		ret = append(ret, planChange(ie2datatypes.PlanKindModel, target, field, aws.ToString(current.Description), model.Description))
+	}
+
+	return ret, nil
+}
+
+// PutRESTModel creates the model, or updates the schema of the model with the same name.
+// It reports whether the model was created. Models must exist before methods can use them.
+func (s *AWSService) PutRESTModel(ctx context.Context, apiid string, model *ie2datatypes.RESTModel) (bool, error) {
+
+	if ctx == nil {
+		return false, errors.New("context can not be empty")
+	}
+
+	if model == nil {
+		return false, errors.New("input param can not be null")
+	}
+
+	if len(apiid) <= 0 || len(model.Name) <= 0 {
+		return false, errors.New("api id and model name can not be empty")
+	}
+
+	normalized, e := normalizeRESTSchema([]byte(model.Schema), DocumentFormatJSON)
+
+	if e != nil {
+		return false, fmt.Errorf("schema of model %s is not valid json: %w", model.Name, e)
+	}
+
+	desired := *model
+	desired.Schema = normalized
+
+	if len(desired.ContentType) <= 0 {
+		desired.ContentType = REST_MODEL_CONTENT_TYPE
+	}
+
+	c, e := s.createApiGatewayClient(ctx)
+
+	if e != nil {
+		return false, e
+	}
+
+	current, e := getRESTModel(c, ctx, apiid, desired.Name)
+
+	if e != nil {
+		return false, e
+	}
+
+	if current == nil {
+
+		log.Printf("Creating model %s on api %s", desired.Name, apiid)
+		_, e = c.CreateModel(ctx, &api.CreateModelInput{
+			RestApiId:   aws.String(apiid),
+			Name:        aws.String(desired.Name),
+			ContentType: aws.String(desired.ContentType),
+			Description: aws.String(desired.Description),
+			Schema:      aws.String(desired.Schema),
+		})
+
+		if e != nil {
+			log.Print(e)
+			return false, e
+		}
+
+		return true, nil
+	}
+
+	ops := restModelPatch(current, &desired)
+
+	if len(ops) <= 0 {
+		log.Printf("Model %s on api %s is up to date.", desired.Name, apiid)
+		return false, nil
+	}
+
+	log.Printf("Updating model %s on api %s", desired.Name, apiid)
+	_, e = c.UpdateModel(ctx, &api.UpdateModelInput{
+		RestApiId:       aws.String(apiid),
+		ModelName:       aws.String(desired.Name),
+		PatchOperations: ops,
+	})
+
+	if e != nil {
+		log.Print(e)
+		return false, e
+	}
+
+	return false, nil
+}
+
+// putRESTRequestValidator returns the id of the body validator of an api, creating it if needed.
+func putRESTRequestValidator(c APIGatewayAPI, ctx context.Context, apiid string) (string, error) {
+
+	var position *string
+
+	for {
+
+		out, e := c.GetRequestValidators(ctx, &api.GetRequestValidatorsInput{
+			RestApiId: aws.String(apiid),
+			Limit:     aws.Int32(500),
+			Position:  position,
+		})
+
+		if e != nil {
+			return "", e
+		}
+
+		for _, validator := range out.Items {
+			if aws.ToString(validator.Name) == REST_REQUEST_VALIDATOR {
+				return aws.ToString(validator.Id), nil
+			}
+		}
+
+		if out.Position == nil {
+			break
+		}
+
+		position = out.Position
+	}
+
+	log.Printf("Creating request validator %s on api %s", REST_REQUEST_VALIDATOR, apiid)
+	out, e := c.CreateRequestValidator(ctx, &api.CreateRequestValidatorInput{
+		RestApiId:           aws.String(apiid),
+		Name:                aws.String(REST_REQUEST_VALIDATOR),
+		ValidateRequestBody: true,
+	})
+
+	if e != nil {
+		return "", e
+	}
+
+	return aws.ToString(out.Id), nil
+}
+
+// restModelsPatch returns the operations that change the models under path,
+// e.g. /requestModels, from current to desired.
+func restModelsPatch(path string, current map[string]string, desired map[string]string) []types.PatchOperation {
+
+	ops := []types.PatchOperation{}
+	keys := []string{}
+
+	for k := range current {
+		keys = append(keys, k)
+	}
+
+	for k := range desired {
+		if _, ok := current[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+
+		have, inCurrent := current[k]
+		want, inDesired := desired[k]
+		key := aws.String(fmt.Sprintf("%s/%s", path, restPatchPathEscape(k)))
+
+		switch {
+		case !inDesired:
+			ops = append(ops, types.PatchOperation{Op: types.OpRemove, Path: key})
+		case !inCurrent:
+			ops = append(ops, types.PatchOperation{Op: types.OpAdd, Path: key, Value: aws.String(want)})
+		case have != want:
+			ops = append(ops, types.PatchOperation{Op: types.OpReplace, Path: key, Value: aws.String(want)})
+		}
+	}
+
+	return ops
+}
+
+// updateRESTMethodModels sets the request models, validator and 200 response models of a method.
+// validatorid is only used when the method has a ReqModel.
+func updateRESTMethodModels(c APIGatewayAPI, ctx context.Context, apiid string, resourceid string, in *ie2datatypes.RESTMethod, validatorid string) error {
+
+	current, e := c.GetMethod(ctx, &api.GetMethodInput{
+		RestApiId:  aws.String(apiid),
+		ResourceId: aws.String(resourceid),
+		HttpMethod: aws.String(in.Name),
+	})
+
+	if e != nil {
+		return e
+	}
+
+	ops := restModelsPatch("/requestModels", current.RequestModels, in.ReqModel)
+
+	if len(in.ReqModel) <= 0 {
+		validatorid = ""
+	}
+
+	// without a request model the validator is removed, an empty id can't be set
+	if aws.ToString(current.RequestValidatorId) != validatorid {
+
+		if len(validatorid) > 0 {
+			ops = append(ops, types.PatchOperation{Op: types.OpReplace, Path: aws.String("/requestValidatorId"), Value: aws.String(validatorid)})
+		} else {
+			ops = append(ops, types.PatchOperation{Op: types.OpRemove, Path: aws.String("/requestValidatorId")})
+		}
+	}
+
+	if len(ops) > 0 {
+
+		log.Printf("Updating request validation of REST method %s", in.Name)
+		_, e = c.UpdateMethod(ctx, &api.UpdateMethodInput{
+			RestApiId:       aws.String(apiid),
+			ResourceId:      aws.String(resourceid),
+			HttpMethod:      aws.String(in.Name),
+			PatchOperations: ops,
+		})
+
+		if e != nil {
+			return e
+		}
+	}
+
+	response, ok := current.MethodResponses["200"]
+
+	if !ok {
+
+		if len(in.ResModel) <= 0 {
+			return nil
+		}
+
+		log.Printf("Creating 200 response of REST method %s", in.Name)
+		_, e = c.PutMethodResponse(ctx, &api.PutMethodResponseInput{
+			RestApiId:      aws.String(apiid),
+			ResourceId:     aws.String(resourceid),
+			HttpMethod:     aws.String(in.Name),
+			StatusCode:     aws.String("200"),
+			ResponseModels: in.ResModel,
+		})
+
+		return e
+	}
+
+	ops = restModelsPatch("/responseModels", response.ResponseModels, in.ResModel)
+
+	if len(ops) <= 0 {
+		return nil
+	}
+
+	log.Printf("Updating 200 response of REST method %s", in.Name)
+	_, e = c.UpdateMethodResponse(ctx, &api.UpdateMethodResponseInput{
+		RestApiId:       aws.String(apiid),
+		ResourceId:      aws.String(resourceid),
+		HttpMethod:      aws.String(in.Name),
+		StatusCode:      aws.String("200"),
+		PatchOperations: ops,
+	})
+
+	return e
+}
@@ -0,0 +1,77 @@
+package ie2utilities
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRESTModelName(t *testing.T) {
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "paper-request.json", want: "PaperRequest"},
+		{path: "schemas/paper_response.yaml", want: "PaperResponse"},
+		{path: "s3://bucket/models/paper.v2.json", want: "PaperV2"},
+		{path: "Paper.json", want: "Paper"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+
+			if got := restModelName(tt.path); got != tt.want {
+				t.Errorf("restModelName() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRESTModelsPatch(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		current map[string]string
+		desired map[string]string
+		want    []string
+	}{
+		{
+			name: "none",
+			want: []string{},
+		},
+		{
+			name:    "unchanged",
+			current: map[string]string{"application/json": "Paper"},
+			desired: map[string]string{"application/json": "Paper"},
+			want:    []string{},
+		},
+		{
+			name:    "added",
+			desired: map[string]string{"application/json": "Paper"},
+			want:    []string{"add /requestModels/application~1json Paper"},
+		},
+		{
+			name:    "replaced",
+			current: map[string]string{"application/json": "Paper"},
+			desired: map[string]string{"application/json": "PaperV2"},
+			want:    []string{"replace /requestModels/application~1json PaperV2"},
+		},
+		{
+			name:    "removed",
+			current: map[string]string{"application/json": "Paper", "text/plain": "Empty"},
+			desired: map[string]string{"application/json": "Paper"},
+			want:    []string{"remove /requestModels/text~1plain"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got := patchStrings(restModelsPatch("/requestModels", tt.current, tt.desired))
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("restModelsPatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package ie2utilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+)
+
+const LAMBDA_WAIT_TIMEOUT = 5 * time.Minute
+const LAMBDA_WAIT_MIN_DELAY = time.Second
+const LAMBDA_WAIT_MAX_DELAY = 20 * time.Second
+
+var ErrLambdaWaitTimeout = errors.New("timed out waiting for lambda")
+
+// lambdaWaitParams fills in the defaults for anything params leaves empty.
+func lambdaWaitParams(params *ie2datatypes.LambdaWaitParams) ie2datatypes.LambdaWaitParams {
+
+	ret := ie2datatypes.LambdaWaitParams{}
+
+	if params != nil {
+		ret = *params
+	}
+
+	if ret.Timeout <= 0 {
+		ret.Timeout = LAMBDA_WAIT_TIMEOUT
+	}
+
+	if ret.MinDelay <= 0 {
+		ret.MinDelay = LAMBDA_WAIT_MIN_DELAY
+	}
+
+	if ret.MaxDelay < ret.MinDelay {
+		ret.MaxDelay = LAMBDA_WAIT_MAX_DELAY
+
+		if ret.MaxDelay < ret.MinDelay {
+			ret.MaxDelay = ret.MinDelay
+		}
+	}
+
+	return ret
+}
+
+// lambdaWaitDelay doubles the delay on every attempt up to max,
+// then picks a random delay between half and all of it so concurrent deploys spread out.
+func lambdaWaitDelay(attempt int, min time.Duration, max time.Duration) time.Duration {
+
+	delay := min
+
+	for i := 0; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// lambdaSettled reports whether a lambda has finished changing, and returns an error
+// if it settled in a failed state.
+func lambdaSettled(conf *types.FunctionConfiguration) (bool, error) {
+
+	if conf.State == types.StateFailed {
+		return true, fmt.Errorf("lambda %s failed: %s", aws.ToString(conf.FunctionName), aws.ToString(conf.StateReason))
+	}
+
+	if conf.LastUpdateStatus == types.LastUpdateStatusFailed {
+		return true, fmt.Errorf("lambda %s failed to update: %s", aws.ToString(conf.FunctionName), aws.ToString(conf.LastUpdateStatusReason))
+	}
+
+	if conf.State == types.StatePending || conf.LastUpdateStatus == types.LastUpdateStatusInProgress {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// waitForLambda polls GetFunction until the lambda is no longer Pending or InProgress.
+func waitForLambda(c LambdaAPI, ctx context.Context, name string, params *ie2datatypes.LambdaWaitParams) (*types.FunctionConfiguration, error) {
+
+	if c == nil {
+		return nil, errors.New("client is null")
+	}
+
+	if ctx == nil {
+		return nil, errors.New("context can not be empty")
+	}
+
+	p := lambdaWaitParams(params)
+	start := time.Now()
+
+	waitCtx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+
+		o, e := c.GetFunction(waitCtx, &lambda.GetFunctionInput{
+			FunctionName: aws.String(name),
+		})
+
+		if e != nil {
+
+			// our own timeout expiring mid call is still a timeout
+			if ctx.Err() == nil && waitCtx.Err() != nil {
+				return nil, fmt.Errorf("%w %s after %s", ErrLambdaWaitTimeout, name, p.Timeout)
+			}
+
+			return nil, e
+		}
+
+		conf := o.Configuration
+		done, e := lambdaSettled(conf)
+		delay := time.Duration(0)
+
+		if !done {
+			delay = lambdaWaitDelay(attempt, p.MinDelay, p.MaxDelay)
+		}
+
+		log.Printf("Lambda %s is %s, last update %s.", name, conf.State, conf.LastUpdateStatus)
+
+		if p.Progress != nil {
+			p.Progress(ie2datatypes.LambdaWaitProgress{
+				Name:             name,
+				Attempt:          attempt + 1,
+				Elapsed:          time.Since(start),
+				State:            string(conf.State),
+				LastUpdateStatus: string(conf.LastUpdateStatus),
+				NextDelay:        delay,
+			})
+		}
+
+		if e != nil {
+			log.Print(e)
+			return conf, e
+		}
+
+		if done {
+			return conf, nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+
+			if ctx.Err() != nil {
+				log.Printf("Stopped waiting for lambda %s: %v", name, ctx.Err())
+				return conf, ctx.Err()
+			}
+
+			return conf, fmt.Errorf("%w %s after %s, it is still %s/%s", ErrLambdaWaitTimeout, name, p.Timeout, conf.State, conf.LastUpdateStatus)
+
+		case <-time.After(delay):
+		}
+	}
+}
+
+// WaitForLambda waits until the lambda has finished being created or updated,
+// i.e. its State is no longer Pending and its LastUpdateStatus no longer InProgress.
+// It returns ErrLambdaWaitTimeout (wrapped) if that takes longer than params.Timeout,
+// and an error if the lambda ends up Failed.
+func (s *AWSService) WaitForLambda(ctx context.Context, name string, params *ie2datatypes.LambdaWaitParams) (*types.FunctionConfiguration, error) {
+
+	if len(name) <= 0 {
+		return nil, errors.New("lambda name can not be empty")
+	}
+
+	c, e := s.lambdaClient()
+
+	if e != nil {
+		return nil, e
+	}
+
+	return waitForLambda(c, ctx, name, params)
+}
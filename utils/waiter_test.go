@@ -0,0 +1,125 @@
+package ie2utilities_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	ie2testing "github.com/insightengine2/ie2-utilities/testing"
+	ie2datatypes "github.com/insightengine2/ie2-utilities/types"
+	ie2utilities "github.com/insightengine2/ie2-utilities/utils"
+)
+
+func TestWaitForLambda(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		create       bool
+		pendingPolls int
+		timeout      time.Duration
+		maxDelay     time.Duration
+		cancelAfter  int
+		wantAttempts int
+		wantErr      error
+		wantNotFound bool
+	}{
+		{name: "settled", create: true, wantAttempts: 1},
+		{name: "pending", create: true, pendingPolls: 3, maxDelay: 8 * time.Millisecond, wantAttempts: 4},
+		{name: "backoff capped", create: true, pendingPolls: 5, maxDelay: 2 * time.Millisecond, wantAttempts: 6},
+		{name: "timeout", create: true, pendingPolls: 1000, timeout: 30 * time.Millisecond, maxDelay: 4 * time.Millisecond, wantErr: ie2utilities.ErrLambdaWaitTimeout},
+		{name: "cancelled", create: true, pendingPolls: 1000, maxDelay: 4 * time.Millisecond, cancelAfter: 2, wantAttempts: 2, wantErr: context.Canceled},
+		{name: "missing", wantNotFound: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			fl := ie2testing.NewFakeLambda()
+			fl.PendingPolls = tt.pendingPolls
+
+			if tt.create {
+
+				_, e := fl.CreateFunction(ctx, &lambda.CreateFunctionInput{FunctionName: aws.String("papers")})
+
+				if e != nil {
+					t.Fatal(e)
+				}
+			}
+
+			progress := []ie2datatypes.LambdaWaitProgress{}
+			params := &ie2datatypes.LambdaWaitParams{
+				Timeout:  tt.timeout,
+				MinDelay: time.Millisecond,
+				MaxDelay: tt.maxDelay,
+				Progress: func(p ie2datatypes.LambdaWaitProgress) {
+
+					progress = append(progress, p)
+
+					if len(progress) == tt.cancelAfter {
+						cancel()
+					}
+				},
+			}
+
+			s := &ie2utilities.AWSService{Lambda: fl}
+			_, e := s.WaitForLambda(ctx, "papers", params)
+
+			if tt.wantNotFound {
+
+				if !ie2utilities.IsAWSNotFound(e) {
+					t.Errorf("WaitForLambda() error = %v, want not found", e)
+				}
+
+				return
+			}
+
+			if !errors.Is(e, tt.wantErr) || (e == nil) != (tt.wantErr == nil) {
+				t.Fatalf("WaitForLambda() error = %v, want %v", e, tt.wantErr)
+			}
+
+			if tt.wantAttempts > 0 && len(progress) != tt.wantAttempts {
+				t.Errorf("WaitForLambda() polled %d times, want %d", len(progress), tt.wantAttempts)
+			}
+
+			maxDelay := tt.maxDelay
+
+			if maxDelay < time.Millisecond {
+				maxDelay = ie2utilities.LAMBDA_WAIT_MAX_DELAY
+			}
+
+			for i, p := range progress {
+
+				if p.Name != "papers" || p.Attempt != i+1 {
+					t.Errorf("progress %d = %+v", i, p)
+				}
+
+				// the last poll of a settled lambda has nothing left to wait for
+				if tt.wantErr == nil && i == len(progress)-1 {
+
+					if p.NextDelay != 0 {
+						t.Errorf("progress %d NextDelay = %s after the lambda settled", i, p.NextDelay)
+					}
+
+					continue
+				}
+
+				// the delay doubles from MinDelay up to MaxDelay, then is jittered down to no less than half
+				delay := time.Millisecond << i
+
+				if i > 20 || delay > maxDelay {
+					delay = maxDelay
+				}
+
+				if p.NextDelay < delay/2 || p.NextDelay > delay {
+					t.Errorf("progress %d NextDelay = %s, want between %s and %s", i, p.NextDelay, delay/2, delay)
+				}
+			}
+		})
+	}
+}